@@ -2,20 +2,36 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/gorilla/mux"
 
 	"github.com/liuerfire/boxpractice/internal/services"
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
 )
 
+// Admins are the employees allowed to see the soft-deleted items.
+type Admins []int64
+
 type API struct {
 	logger          logr.Logger
+	admins          Admins
 	hospitalService *services.HospitalService
 	employeeService *services.EmployeeService
 	taskService     *services.TaskService
+	commentService  *services.CommentService
+
+	attachmentService *services.AttachmentService
+	labelService      *services.LabelService
+	recurrenceService *services.RecurrenceService
+	slaService        *services.SLAService
+	shiftService      *services.ShiftService
+	templateService   *services.TemplateService
 }
 
 func ProvideAPI(
@@ -23,31 +39,116 @@ func ProvideAPI(
 	hospitalService *services.HospitalService,
 	employeeService *services.EmployeeService,
 	taskService *services.TaskService,
+	commentService *services.CommentService,
+	attachmentService *services.AttachmentService,
+	labelService *services.LabelService,
+	recurrenceService *services.RecurrenceService,
+	slaService *services.SLAService,
+	shiftService *services.ShiftService,
+	templateService *services.TemplateService,
+	admins Admins,
 ) *API {
 	return &API{
 		logger:          logger.WithName("api"),
+		admins:          admins,
 		hospitalService: hospitalService,
 		employeeService: employeeService,
 		taskService:     taskService,
+		commentService:  commentService,
+
+		attachmentService: attachmentService,
+		labelService:      labelService,
+		recurrenceService: recurrenceService,
+		slaService:        slaService,
+		shiftService:      shiftService,
+		templateService:   templateService,
 	}
 }
 
 func (api *API) RegisterRouter(router *mux.Router) {
 	r := router.PathPrefix("/api").Subrouter()
+	r.Use(api.withActor)
 	r.Methods(http.MethodGet).Path("/hospitals").HandlerFunc(api.handleListHospitals)
 	r.Methods(http.MethodPost).Path("/hospitals").HandlerFunc(api.handleCreateHospital)
 	r.Methods(http.MethodGet).Path("/hospitals/{id}").HandlerFunc(api.handleGetHospital)
 	r.Methods(http.MethodPut).Path("/hospitals/{id}").HandlerFunc(api.handleUpdateHospital)
+	r.Methods(http.MethodDelete).Path("/hospitals/{id}").HandlerFunc(api.handleDeleteHospital)
+	r.Methods(http.MethodPost).Path("/hospitals/{id}/restore").HandlerFunc(api.handleRestoreHospital)
 
 	r.Methods(http.MethodGet).Path("/hospitals/{id}/employees").HandlerFunc(api.handleListEmployees)
 	r.Methods(http.MethodPost).Path("/hospitals/{id}/employees").HandlerFunc(api.handleCreateEmployee)
 	r.Methods(http.MethodGet).Path("/employees/{id}").HandlerFunc(api.handleGetEmployee)
+	r.Methods(http.MethodDelete).Path("/employees/{id}").HandlerFunc(api.handleDeleteEmployee)
+	r.Methods(http.MethodPost).Path("/employees/{id}/restore").HandlerFunc(api.handleRestoreEmployee)
 
 	r.Methods(http.MethodGet).Path("/hospitals/{id}/tasks").HandlerFunc(api.handleListHospitalTasks)
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/tasks/overdue").HandlerFunc(api.handleListOverdueTasks)
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/tasks/unassigned").HandlerFunc(api.handleListUnassignedTasks)
 	r.Methods(http.MethodGet).Path("/employees/{id}/tasks").HandlerFunc(api.handleListEmployeeTasks)
 	r.Methods(http.MethodPost).Path("/hospitals/{id}/tasks").HandlerFunc(api.handleCreateTask)
+	r.Methods(http.MethodPost).Path("/hospitals/{id}/tasks:bulk").HandlerFunc(api.handleBulkUpdateTasks)
+	r.Methods(http.MethodGet).Path("/tasks/{id}").HandlerFunc(api.handleGetTask)
 	r.Methods(http.MethodPut).Path("/tasks/{id}").HandlerFunc(api.handleUpdateTask)
+	r.Methods(http.MethodDelete).Path("/tasks/{id}").HandlerFunc(api.handleDeleteTask)
+	r.Methods(http.MethodPost).Path("/tasks/{id}/restore").HandlerFunc(api.handleRestoreTask)
 	r.Methods(http.MethodPost).Path("/tasks/{id}/assign").HandlerFunc(api.handleAssignTask)
+	r.Methods(http.MethodPost).Path("/tasks/{id}/claim").HandlerFunc(api.handleClaimTask)
+	r.Methods(http.MethodPost).Path("/tasks/{id}/start").HandlerFunc(api.handleTransitionTask(models.TaskStatusInProgress))
+	r.Methods(http.MethodPost).Path("/tasks/{id}/complete").HandlerFunc(api.handleTransitionTask(models.TaskStatusCOMPLETED))
+	r.Methods(http.MethodPost).Path("/tasks/{id}/fail").HandlerFunc(api.handleTransitionTask(models.TaskStatusFAILED))
+	r.Methods(http.MethodPost).Path("/tasks/{id}/reopen").HandlerFunc(api.handleTransitionTask(models.TaskStatusOpen))
+	r.Methods(http.MethodGet).Path("/tasks/{id}/transitions").HandlerFunc(api.handleListTaskTransitions)
+	r.Methods(http.MethodGet).Path("/tasks/{id}/history").HandlerFunc(api.handleListTaskHistory)
+	r.Methods(http.MethodPost).Path("/tasks/{id}/revert").HandlerFunc(api.handleRevertTask)
+
+	r.Methods(http.MethodGet).Path("/tasks/{id}/comments").HandlerFunc(api.handleListComments)
+	r.Methods(http.MethodPost).Path("/tasks/{id}/comments").HandlerFunc(api.handleCreateComment)
+	r.Methods(http.MethodPut).Path("/comments/{id}").HandlerFunc(api.handleUpdateComment)
+	r.Methods(http.MethodDelete).Path("/comments/{id}").HandlerFunc(api.handleDeleteComment)
+
+	r.Methods(http.MethodGet).Path("/tasks/{id}/attachments").HandlerFunc(api.handleListAttachments)
+	r.Methods(http.MethodPost).Path("/tasks/{id}/attachments").HandlerFunc(api.handleCreateAttachment)
+	r.Methods(http.MethodGet).Path("/tasks/{id}/attachments/{aid}").HandlerFunc(api.handleDownloadAttachment)
+	r.Methods(http.MethodDelete).Path("/tasks/{id}/attachments/{aid}").HandlerFunc(api.handleDeleteAttachment)
+
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/labels").HandlerFunc(api.handleListLabels)
+	r.Methods(http.MethodPost).Path("/hospitals/{id}/labels").HandlerFunc(api.handleCreateLabel)
+	r.Methods(http.MethodGet).Path("/labels/{id}").HandlerFunc(api.handleGetLabel)
+	r.Methods(http.MethodPut).Path("/labels/{id}").HandlerFunc(api.handleUpdateLabel)
+	r.Methods(http.MethodDelete).Path("/labels/{id}").HandlerFunc(api.handleDeleteLabel)
+	r.Methods(http.MethodPut).Path("/tasks/{id}/labels/{lid}").HandlerFunc(api.handleLabelTask)
+	r.Methods(http.MethodDelete).Path("/tasks/{id}/labels/{lid}").HandlerFunc(api.handleUnlabelTask)
+	r.Methods(http.MethodPut).Path("/tasks/{id}/parent").HandlerFunc(api.handleSetTaskParent)
+	r.Methods(http.MethodDelete).Path("/tasks/{id}/parent").HandlerFunc(api.handleUnsetTaskParent)
+	r.Methods(http.MethodGet).Path("/tasks/{id}/subtasks").HandlerFunc(api.handleListSubtasks)
+	r.Methods(http.MethodGet).Path("/tasks/{id}/blockers").HandlerFunc(api.handleListTaskBlockers)
+	r.Methods(http.MethodPut).Path("/tasks/{id}/blockers/{bid}").HandlerFunc(api.handleAddTaskBlocker)
+	r.Methods(http.MethodDelete).Path("/tasks/{id}/blockers/{bid}").HandlerFunc(api.handleRemoveTaskBlocker)
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/recurrences").HandlerFunc(api.handleListRecurrences)
+	r.Methods(http.MethodPost).Path("/hospitals/{id}/recurrences").HandlerFunc(api.handleCreateRecurrence)
+	r.Methods(http.MethodGet).Path("/recurrences/{id}").HandlerFunc(api.handleGetRecurrence)
+	r.Methods(http.MethodPut).Path("/recurrences/{id}").HandlerFunc(api.handleUpdateRecurrence)
+	r.Methods(http.MethodDelete).Path("/recurrences/{id}").HandlerFunc(api.handleDeleteRecurrence)
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/slas").HandlerFunc(api.handleListTaskSLAs)
+	r.Methods(http.MethodPut).Path("/hospitals/{id}/slas/{priority}").HandlerFunc(api.handleSetTaskSLA)
+	r.Methods(http.MethodDelete).Path("/hospitals/{id}/slas/{priority}").HandlerFunc(api.handleDeleteTaskSLA)
+	r.Methods(http.MethodGet).Path("/tasks/{id}/escalations").HandlerFunc(api.handleListTaskEscalations)
+	r.Methods(http.MethodGet).Path("/tasks/{id}/watchers").HandlerFunc(api.handleListTaskWatchers)
+	r.Methods(http.MethodPost).Path("/tasks/{id}/watchers").HandlerFunc(api.handleWatchTask)
+	r.Methods(http.MethodDelete).Path("/tasks/{id}/watchers").HandlerFunc(api.handleUnwatchTask)
+	r.Methods(http.MethodGet).Path("/employees/{id}/watched-tasks").HandlerFunc(api.handleListWatchedTasks)
+	r.Methods(http.MethodGet).Path("/employees/{id}/events").HandlerFunc(api.handleListTaskEvents)
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/shifts").HandlerFunc(api.handleListShifts)
+	r.Methods(http.MethodPost).Path("/hospitals/{id}/shifts").HandlerFunc(api.handleCreateShift)
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/shifts/current").HandlerFunc(api.handleListCurrentShifts)
+	r.Methods(http.MethodGet).Path("/shifts/{id}").HandlerFunc(api.handleGetShift)
+	r.Methods(http.MethodPut).Path("/shifts/{id}").HandlerFunc(api.handleUpdateShift)
+	r.Methods(http.MethodDelete).Path("/shifts/{id}").HandlerFunc(api.handleDeleteShift)
+	r.Methods(http.MethodGet).Path("/hospitals/{id}/task-templates").HandlerFunc(api.handleListTaskTemplates)
+	r.Methods(http.MethodPost).Path("/hospitals/{id}/task-templates").HandlerFunc(api.handleCreateTaskTemplate)
+	r.Methods(http.MethodGet).Path("/task-templates/{id}").HandlerFunc(api.handleGetTaskTemplate)
+	r.Methods(http.MethodPut).Path("/task-templates/{id}").HandlerFunc(api.handleUpdateTaskTemplate)
+	r.Methods(http.MethodDelete).Path("/task-templates/{id}").HandlerFunc(api.handleDeleteTaskTemplate)
 }
 
 func parsePaginationParams(pageStr, limitStr string) (uint, uint) {
@@ -67,6 +168,120 @@ func parsePaginationParams(pageStr, limitStr string) (uint, uint) {
 	return uint(page), uint(limit)
 }
 
+// parseListOptions parses the pagination params and includeDeleted, which
+// makes the soft-deleted items part of the list. A cursor takes precedence
+// over the page.
+func parseListOptions(r *http.Request) (dto.ListOptions, error) {
+	q := r.URL.Query()
+	page, limit := parsePaginationParams(q.Get("page"), q.Get("limit"))
+	opts := dto.ListOptions{Limit: limit}
+	if v := q.Get("cursor"); v != "" {
+		id, key, err := dto.DecodeCursor(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid cursor: %s", v)
+		}
+		opts.AfterID, opts.AfterKey = id, key
+	} else if page > 1 {
+		opts.Offset = (page - 1) * limit
+	}
+	if v := q.Get("includeDeleted"); v != "" {
+		includeDeleted, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid includeDeleted: %s", v)
+		}
+		opts.IncludeDeleted = includeDeleted
+	}
+	return opts, nil
+}
+
+// parseDeleteOptions parses the policy and reassignTo params, which tell
+// what happens to the open tasks of a deleted hospital or employee.
+func parseDeleteOptions(r *http.Request) (dto.DeleteOptions, error) {
+	q := r.URL.Query()
+	opts := dto.DeleteOptions{Policy: q.Get("policy")}
+	if v := q.Get("reassignTo"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid reassignTo: %s", v)
+		}
+		opts.ReassignTo = id
+	}
+	return opts, nil
+}
+
+// actorHeader identifies the employee making the request.
+const actorHeader = "X-Employee-ID"
+
+// parseActor returns the id of the employee given by the actorHeader.
+func parseActor(r *http.Request) (int64, error) {
+	v := r.Header.Get(actorHeader)
+	if v == "" {
+		return 0, fmt.Errorf("missing %s header", actorHeader)
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s header: %s", actorHeader, v)
+	}
+	return id, nil
+}
+
+// withActor passes the employee given by the actorHeader, if any, to the
+// services through the context of the request. Only the admins can list the
+// soft-deleted items: includeDeleted fails with ErrPermissionDenied for the
+// other employees. Like the actor itself, this trusts the header.
+func (api *API) withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var actor int64
+		if r.Header.Get(actorHeader) != "" {
+			var err error
+			actor, err = parseActor(r)
+			if err != nil {
+				renderBadRequestErr(w, err)
+				return
+			}
+			r = r.WithContext(services.WithActor(r.Context(), actor))
+		}
+		if includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("includeDeleted")); includeDeleted && !api.isAdmin(actor) {
+			renderSvcError(w, &services.ServiceError{Code: services.ErrPermissionDenied, Msg: "includeDeleted is reserved to the admins"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAdmin tells whether the employee id is one of the admins.
+func (api *API) isAdmin(id int64) bool {
+	if id == 0 {
+		return false
+	}
+	for _, admin := range api.admins {
+		if admin == id {
+			return true
+		}
+	}
+	return false
+}
+
+// setETag sets the ETag header to the version of the resource.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// parseIfMatch returns the version given by the If-Match header, 0 if there
+// is no such header or if it is "*".
+func parseIfMatch(r *http.Request) (int64, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+	v = strings.TrimPrefix(v, "W/")
+	version, err := strconv.ParseInt(strings.Trim(v, `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, &services.ServiceError{Code: services.ErrPreconditionFailed, Msg: fmt.Sprintf("invalid If-Match: %s", v)}
+	}
+	return version, nil
+}
+
 func renderJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
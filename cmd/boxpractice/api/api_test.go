@@ -5,21 +5,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/liuerfire/boxpractice/pkg/blob"
 	"github.com/liuerfire/boxpractice/pkg/dto"
 	"github.com/liuerfire/boxpractice/pkg/log"
 	"github.com/liuerfire/boxpractice/pkg/models"
 	"github.com/liuerfire/boxpractice/pkg/store"
 )
 
+// testAdmin is the employee id the e2e tests use as an admin.
+const testAdmin = 1000
+
 func setup(t *testing.T) (api *API, cleanup func()) {
 	t.Helper()
 	assert := require.New(t)
@@ -27,16 +34,17 @@ func setup(t *testing.T) (api *API, cleanup func()) {
 	ctx := context.Background()
 	logger := log.Init(10)
 
-	sqlStore, err := store.NewSQLStore()
-	assert.NoError(err)
+	memStore := store.NewMemoryStore()
 
 	cleanup = func() {
-		err := sqlStore.Cleanup()
+		err := memStore.Cleanup()
 		assert.NoError(err)
 	}
-	cleanup()
 
-	api, err = InitAPIHandler(ctx, logger, sqlStore)
+	blobStore, err := blob.NewFSStore(t.TempDir())
+	assert.NoError(err)
+
+	api, err = InitAPIHandler(ctx, logger, memStore, blobStore, Admins{testAdmin})
 	assert.NoError(err)
 
 	return
@@ -204,6 +212,43 @@ func TestE2E(t *testing.T) {
 		assert.GreaterOrEqual(t, list.Total, uint(2))
 	})
 
+	t.Run("ListEmployeesWithCursor", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/hospitals/%d/employees?limit=1", server.URL, hospital.ID)
+
+		list := func(query string) dto.EmployeeList {
+			resp, err := client.Get(path + query)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var list dto.EmployeeList
+			err = json.NewDecoder(resp.Body).Decode(&list)
+			assert.NoError(t, err)
+			return list
+		}
+
+		first := list("")
+		assert.Equal(t, uint(2), first.Total)
+		assert.Equal(t, 1, len(first.Items))
+		assert.Equal(t, employeeA.ID, first.Items[0].ID)
+		assert.NotEmpty(t, first.NextCursor)
+
+		second := list("&cursor=" + first.NextCursor)
+		assert.Equal(t, 1, len(second.Items))
+		assert.Equal(t, employeeB.ID, second.Items[0].ID)
+		assert.Empty(t, second.NextCursor)
+
+		// page still works, and counts from 1.
+		second = list("&page=2")
+		assert.Equal(t, 1, len(second.Items))
+		assert.Equal(t, employeeB.ID, second.Items[0].ID)
+
+		resp, err := client.Get(path + "&cursor=bogus")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 	t.Run("GetEmployee", func(t *testing.T) {
 		path := fmt.Sprintf("%s/api/employees/%d", server.URL, employeeA.ID)
 
@@ -335,6 +380,53 @@ func TestE2E(t *testing.T) {
 		assert.Equal(t, uint(1), tmp.Total)
 	})
 
+	t.Run("ListTasksWithFilter", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/hospitals/%d/tasks", server.URL, hospital.ID)
+
+		list := func(query string) dto.TaskList {
+			resp, err := client.Get(path + query)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var list dto.TaskList
+			err = json.NewDecoder(resp.Body).Decode(&list)
+			assert.NoError(t, err)
+			return list
+		}
+
+		tasks := list("?priority=URGENT,HIGHT&status=OPEN")
+		assert.Equal(t, uint(1), tasks.Total)
+		assert.Equal(t, taskA.ID, tasks.Items[0].ID)
+
+		tasks = list(fmt.Sprintf("?ownerId=%d", employeeB.ID))
+		assert.Equal(t, uint(1), tasks.Total)
+		assert.Equal(t, taskB.ID, tasks.Items[0].ID)
+
+		tasks = list("?createdAfter=2000-01-01T00:00:00Z&createdBefore=2001-01-01T00:00:00Z")
+		assert.Equal(t, uint(0), tasks.Total)
+
+		// Neither task has a due date, so none is overdue either.
+		tasks = list("?dueBefore=2100-01-01T00:00:00Z")
+		assert.Equal(t, uint(0), tasks.Total)
+		tasks = list("/overdue")
+		assert.Equal(t, uint(0), tasks.Total)
+
+		tasks = list("?sort=priority&limit=1")
+		assert.Equal(t, uint(2), tasks.Total)
+		assert.Equal(t, taskB.ID, tasks.Items[0].ID)
+		tasks = list("?sort=priority&limit=1&cursor=" + tasks.NextCursor)
+		assert.Equal(t, taskA.ID, tasks.Items[0].ID)
+		assert.Empty(t, tasks.NextCursor)
+
+		for _, query := range []string{"?status=DONE", "?sort=title", "?createdAfter=yesterday", "?dueBefore=tomorrow"} {
+			resp, err := client.Get(path + query)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+
 	t.Run("AssignTask", func(t *testing.T) {
 		path := fmt.Sprintf("%s/api/tasks/%d/assign", server.URL, taskA.ID)
 		data, _ := json.Marshal(map[string]int64{"ownerId": taskB.ID})
@@ -358,4 +450,1094 @@ func TestE2E(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 		assert.Equal(t, uint(2), tmp.Total)
 	})
+
+	t.Run("UpdateTaskIfMatch", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/tasks/%d", server.URL, taskA.ID)
+
+		resp, err := client.Get(path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		var task dto.Task
+		err = json.NewDecoder(resp.Body).Decode(&task)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		etag := resp.Header.Get("ETag")
+		assert.Equal(t, fmt.Sprintf(`"%d"`, task.Version), etag)
+
+		update := func(etag string) int {
+			task.Title = "a updated"
+			data, _ := json.Marshal(task)
+			req, err := http.NewRequest("PUT", path, bytes.NewReader(data))
+			assert.NoError(t, err)
+			req.Header.Set("If-Match", etag)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			return resp.StatusCode
+		}
+
+		assert.Equal(t, http.StatusOK, update(etag))
+		// The first update bumped the version, so the ETag is stale now.
+		assert.Equal(t, http.StatusPreconditionFailed, update(etag))
+
+		resp, err = client.Get(path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+	})
+	t.Run("TransitionTask", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/tasks/%d", server.URL, taskA.ID)
+
+		transition := func(action string) (int, dto.Task) {
+			resp, err := client.Post(path+"/"+action, "application/json", nil)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			var task dto.Task
+			if resp.StatusCode == http.StatusOK {
+				err = json.NewDecoder(resp.Body).Decode(&task)
+				assert.NoError(t, err)
+			}
+			return resp.StatusCode, task
+		}
+
+		code, _ := transition("complete")
+		assert.Equal(t, http.StatusConflict, code)
+		code, task := transition("start")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, models.TaskStatusInProgress, task.Status)
+		code, task = transition("complete")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, models.TaskStatusCOMPLETED, task.Status)
+
+		// PUT can't skip the actions, even to reopen the task.
+		task.Status = models.TaskStatusOpen
+		data, _ := json.Marshal(task)
+		req, err := http.NewRequest("PUT", path, bytes.NewReader(data))
+		assert.NoError(t, err)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		code, task = transition("reopen")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, models.TaskStatusOpen, task.Status)
+
+		resp, err = client.Get(path + "/transitions")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var list dto.TaskTransitionList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(4), list.Total)
+		assert.Equal(t, models.TaskStatusCOMPLETED, list.Items[3].From)
+		assert.Equal(t, models.TaskStatusOpen, list.Items[3].To)
+	})
+
+	t.Run("Comments", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/tasks/%d/comments", server.URL, taskA.ID)
+
+		do := func(method, path string, actor int64, body string) *http.Response {
+			req, err := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			if actor > 0 {
+				req.Header.Set("X-Employee-ID", fmt.Sprint(actor))
+			}
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", path, 0, `{"body": "handover"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var comment dto.Comment
+		for _, body := range []string{"first", "second"} {
+			resp = do("POST", path, employeeA.ID, fmt.Sprintf(`{"body": %q}`, body))
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			err := json.NewDecoder(resp.Body).Decode(&comment)
+			assert.NoError(t, err)
+			assert.Equal(t, employeeA.ID, comment.AuthorID)
+		}
+
+		resp = do("GET", path+"?limit=1", 0, "")
+		defer resp.Body.Close()
+		var list dto.CommentList
+		err := json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), list.Total)
+		assert.Equal(t, "first", list.Items[0].Body)
+		assert.NotEmpty(t, list.NextCursor)
+
+		commentPath := fmt.Sprintf("%s/api/comments/%d", server.URL, comment.ID)
+		resp = do("PUT", commentPath, employeeB.ID, `{"body": "not mine"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp = do("PUT", commentPath, employeeA.ID, `{"body": "mine"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do("DELETE", commentPath, employeeB.ID, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp = do("DELETE", commentPath, employeeA.ID, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("TaskHistory", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/tasks/%d", server.URL, taskB.ID)
+
+		resp, err := client.Get(path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var task dto.Task
+		err = json.NewDecoder(resp.Body).Decode(&task)
+		assert.NoError(t, err)
+		title := task.Title
+
+		task.Title = "b renamed"
+		data, _ := json.Marshal(task)
+		req, err := http.NewRequest("PUT", path, bytes.NewReader(data))
+		assert.NoError(t, err)
+		req.Header.Set("X-Employee-ID", fmt.Sprint(employeeB.ID))
+		resp, err = client.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = client.Get(path + "/history")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var history dto.TaskChangeList
+		err = json.NewDecoder(resp.Body).Decode(&history)
+		assert.NoError(t, err)
+		rename := history.Items[len(history.Items)-1]
+		assert.Equal(t, "title", rename.Field)
+		assert.Equal(t, title, rename.OldValue)
+		assert.Equal(t, employeeB.ID, rename.ActorID)
+
+		resp, err = client.Post(fmt.Sprintf("%s/revert?to=%d", path, history.Items[len(history.Items)-2].ID), "application/json", nil)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		err = json.NewDecoder(resp.Body).Decode(&task)
+		assert.NoError(t, err)
+		assert.Equal(t, title, task.Title)
+
+		resp, err = client.Post(path+"/revert?to=x", "application/json", nil)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Attachments", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/tasks/%d/attachments", server.URL, taskA.ID)
+
+		upload := func(field, filename string, content []byte) *http.Response {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			fw, err := mw.CreateFormFile(field, filename)
+			assert.NoError(t, err)
+			fw.Write(content)
+			assert.NoError(t, mw.Close())
+			req, err := http.NewRequest("POST", path, &body)
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			req.Header.Set("X-Employee-ID", fmt.Sprint(employeeA.ID))
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+		resp := upload("file", "../../wound.png", png)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var attachment dto.Attachment
+		err := json.NewDecoder(resp.Body).Decode(&attachment)
+		assert.NoError(t, err)
+		assert.Equal(t, "wound.png", attachment.Filename)
+		assert.Equal(t, "image/png", attachment.ContentType)
+		assert.Equal(t, int64(len(png)), attachment.Size)
+		assert.Equal(t, employeeA.ID, attachment.UploaderID)
+
+		resp = upload("file", "notes.txt", []byte("plain text"))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+		resp = upload("other", "wound.png", png)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, err = client.Get(path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var list dto.AttachmentList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+
+		attachmentPath := fmt.Sprintf("%s/%d", path, attachment.ID)
+		resp, err = client.Get(attachmentPath)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=wound.png`, resp.Header.Get("Content-Disposition"))
+		content, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, png, content)
+
+		// The attachment belongs to taskA only.
+		resp, err = client.Get(fmt.Sprintf("%s/api/tasks/%d/attachments/%d", server.URL, taskB.ID, attachment.ID))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		req, err := http.NewRequest("DELETE", attachmentPath, nil)
+		assert.NoError(t, err)
+		resp, err = client.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, err = client.Get(attachmentPath)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Labels", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/hospitals/%d/labels", server.URL, hospital.ID)
+
+		resp, err := client.Post(path, "application/json", bytes.NewReader([]byte(`{"name": "wounds", "color": "red"}`)))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var labels []dto.Label
+		for _, body := range []string{`{"name": "wounds", "color": "#FF0000"}`, `{"name": "icu", "color": "#00ff00"}`} {
+			resp, err := client.Post(path, "application/json", bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			var label dto.Label
+			err = json.NewDecoder(resp.Body).Decode(&label)
+			assert.NoError(t, err)
+			labels = append(labels, label)
+		}
+		assert.Equal(t, "#ff0000", labels[0].Color)
+
+		resp, err = client.Post(path, "application/json", bytes.NewReader([]byte(`{"name": "icu", "color": "#000000"}`)))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		put := func(path string) *http.Response {
+			req, err := http.NewRequest("PUT", path, nil)
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+		for _, l := range labels {
+			resp := put(fmt.Sprintf("%s/api/tasks/%d/labels/%d", server.URL, taskA.ID, l.ID))
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+		resp = put(fmt.Sprintf("%s/api/tasks/%d/labels/%d", server.URL, taskB.ID, labels[0].ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = client.Get(fmt.Sprintf("%s/api/tasks/%d", server.URL, taskA.ID))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var task dto.Task
+		err = json.NewDecoder(resp.Body).Decode(&task)
+		assert.NoError(t, err)
+		assert.Len(t, task.Labels, 2)
+
+		tasksPath := fmt.Sprintf("%s/api/hospitals/%d/tasks?label=%d,%d", server.URL, hospital.ID, labels[0].ID, labels[1].ID)
+		for query, total := range map[string]uint{"": 2, "&labelMatch=any": 2, "&labelMatch=all": 1} {
+			resp, err := client.Get(tasksPath + query)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			var list dto.TaskList
+			err = json.NewDecoder(resp.Body).Decode(&list)
+			assert.NoError(t, err)
+			assert.Equal(t, total, list.Total, query)
+		}
+		for _, query := range []string{"?label=x", "?labelMatch=some"} {
+			resp, err := client.Get(fmt.Sprintf("%s/api/hospitals/%d/tasks%s", server.URL, hospital.ID, query))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/tasks/%d/labels/%d", server.URL, taskB.ID, labels[0].ID), nil)
+		assert.NoError(t, err)
+		resp, err = client.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		req, err = http.NewRequest("DELETE", fmt.Sprintf("%s/api/labels/%d", server.URL, labels[1].ID), nil)
+		assert.NoError(t, err)
+		resp, err = client.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = client.Get(path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var list dto.LabelList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		do := func(method, path, body string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("PUT", fmt.Sprintf("/api/tasks/%d/parent", taskB.ID), fmt.Sprintf(`{"parentId": %d}`, taskA.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var task dto.Task
+		err := json.NewDecoder(resp.Body).Decode(&task)
+		assert.NoError(t, err)
+		assert.Equal(t, taskA.ID, task.ParentID)
+
+		resp = do("PUT", fmt.Sprintf("/api/tasks/%d/parent", taskA.ID), fmt.Sprintf(`{"parentId": %d}`, taskB.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp, err = client.Get(fmt.Sprintf("%s/api/tasks/%d", server.URL, taskA.ID))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		task = dto.Task{}
+		err = json.NewDecoder(resp.Body).Decode(&task)
+		assert.NoError(t, err)
+		assert.Equal(t, &dto.SubtaskRollup{Total: 1, Done: 0}, task.Subtasks)
+
+		resp, err = client.Get(fmt.Sprintf("%s/api/tasks/%d/subtasks", server.URL, taskA.ID))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var list dto.TaskList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+
+		resp = do("PUT", fmt.Sprintf("/api/tasks/%d/blockers/%d", taskA.ID, taskA.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("PUT", fmt.Sprintf("/api/tasks/%d/blockers/%d", taskA.ID, taskB.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("PUT", fmt.Sprintf("/api/tasks/%d/blockers/%d", taskB.ID, taskA.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp, err = client.Get(fmt.Sprintf("%s/api/tasks/%d/blockers", server.URL, taskA.ID))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		list = dto.TaskList{}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		if assert.Len(t, list.Items, 1) {
+			assert.Equal(t, taskB.ID, list.Items[0].ID)
+		}
+
+		resp = do("DELETE", fmt.Sprintf("/api/tasks/%d/blockers/%d", taskA.ID, taskB.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("DELETE", fmt.Sprintf("/api/tasks/%d/parent", taskB.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Recurrences", func(t *testing.T) {
+		do := func(method, path, body string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", "/api/hospitals", `{"name": "mars", "timezone": "Mars/Base"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		path := fmt.Sprintf("/api/hospitals/%d/recurrences", hospital.ID)
+		resp = do("POST", path, fmt.Sprintf(`{"title": "round", "priority": "LOW", "schedule": "0 25 * * *", "ownerIds": [%d]}`, employeeA.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("POST", path, `{"title": "round", "priority": "LOW", "schedule": "0 8 * * *"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = do("POST", path, fmt.Sprintf(`{"title": "round", "priority": "LOW", "schedule": "0 8 * * *", "dueIn": 3600, "ownerIds": [%d, %d]}`, employeeA.ID, employeeB.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var recurrence dto.TaskRecurrence
+		err := json.NewDecoder(resp.Body).Decode(&recurrence)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{employeeA.ID, employeeB.ID}, recurrence.OwnerIDs)
+		assert.True(t, recurrence.NextAt.After(time.Now()))
+
+		recurrencePath := fmt.Sprintf("/api/recurrences/%d", recurrence.ID)
+		resp = do("GET", recurrencePath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		etag := resp.Header.Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		update := func() *http.Response {
+			body := fmt.Sprintf(`{"title": "round", "priority": "URGENT", "schedule": "@hourly", "ownerIds": [%d]}`, employeeB.ID)
+			req, err := http.NewRequest("PUT", server.URL+recurrencePath, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			req.Header.Set("If-Match", etag)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+		resp = update()
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		recurrence = dto.TaskRecurrence{}
+		err = json.NewDecoder(resp.Body).Decode(&recurrence)
+		assert.NoError(t, err)
+		assert.Equal(t, "@hourly", recurrence.Schedule)
+		assert.Equal(t, []int64{employeeB.ID}, recurrence.OwnerIDs)
+
+		resp = update()
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = do("GET", path, "")
+		defer resp.Body.Close()
+		var list dto.TaskRecurrenceList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+
+		resp = do("DELETE", recurrencePath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("DELETE", recurrencePath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("BulkUpdateTasks", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/hospitals/%d/tasks:bulk", server.URL, hospital.ID)
+		post := func(url, body string) *dto.TaskBulkReport {
+			resp, err := client.Post(url, "application/json", bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			var report dto.TaskBulkReport
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+			return &report
+		}
+
+		countUrgent := func() uint {
+			resp, err := client.Get(fmt.Sprintf("%s/api/hospitals/%d/tasks?priority=URGENT", server.URL, hospital.ID))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			var list dto.TaskList
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+			return list.Total
+		}
+		urgent := countUrgent()
+		// Neither ids nor a filter select the tasks, whatever the other params.
+		for _, query := range []string{"", "?foo=1", "?status=", "?labelMatch=all"} {
+			resp, err := client.Post(path+query, "application/json", bytes.NewReader([]byte(`{"action": "setPriority", "priority": "URGENT"}`)))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+		assert.Equal(t, urgent, countUrgent())
+		resp, err := client.Post(path, "application/json", bytes.NewReader([]byte(fmt.Sprintf(`{"taskIds": [%d], "action": "archive"}`, taskA.ID))))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		report := post(path, fmt.Sprintf(`{"taskIds": [%d], "action": "setPriority", "priority": "URGENT", "dryRun": true}`, taskA.ID))
+		assert.True(t, report.DryRun)
+		assert.False(t, report.Applied)
+		if assert.Len(t, report.Items, 1) {
+			assert.Equal(t, models.TaskPriorityUrgent, report.Items[0].Task.Priority)
+		}
+
+		report = post(path+"?status=OPEN,IN_PROGRESS,BLOCKED", `{"action": "setPriority", "priority": "URGENT"}`)
+		assert.True(t, report.Applied)
+		assert.Equal(t, uint(0), report.Failed)
+		assert.NotEmpty(t, report.Items)
+
+		resp, err = client.Get(fmt.Sprintf("%s/api/tasks/%d", server.URL, report.Items[0].TaskID))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var task dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		assert.Equal(t, models.TaskPriorityUrgent, task.Priority)
+	})
+
+	t.Run("TaskPool", func(t *testing.T) {
+		do := func(method, path, body string, actor int64) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			if actor > 0 {
+				req.Header.Set("X-Employee-ID", fmt.Sprint(actor))
+			}
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", "/api/hospitals", `{"name": "pool"}`, 0)
+		defer resp.Body.Close()
+		var h dto.Hospital
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+		var employees []dto.Employee
+		for _, name := range []string{"pool-a", "pool-b"} {
+			resp = do("POST", fmt.Sprintf("/api/hospitals/%d/employees", h.ID), fmt.Sprintf(`{"username": %q}`, name), 0)
+			defer resp.Body.Close()
+			var e dto.Employee
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+			employees = append(employees, e)
+		}
+
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/tasks", h.ID), `{"title": "pooled", "priority": "LOW", "status": "OPEN"}`, 0)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var task dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		assert.Equal(t, int64(0), task.OwnerID)
+
+		poolPath := fmt.Sprintf("/api/hospitals/%d/tasks/unassigned", h.ID)
+		resp = do("GET", poolPath, "", 0)
+		defer resp.Body.Close()
+		var list dto.TaskList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		assert.Equal(t, uint(1), list.Total)
+
+		claimPath := fmt.Sprintf("/api/tasks/%d/claim", task.ID)
+		resp = do("POST", claimPath, "", 0)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("POST", claimPath, "", employees[0].ID)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		task = dto.Task{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		assert.Equal(t, employees[0].ID, task.OwnerID)
+		resp = do("POST", claimPath, "", employees[1].ID)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = do("GET", poolPath, "", 0)
+		defer resp.Body.Close()
+		list = dto.TaskList{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		assert.Equal(t, uint(0), list.Total)
+	})
+
+	t.Run("TaskAssignment", func(t *testing.T) {
+		do := func(method, path, body string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", "/api/hospitals", `{"name": "assign", "assignmentStrategy": "leastOpen"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var h dto.Hospital
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+		assert.Equal(t, "leastOpen", h.AssignmentStrategy)
+		assert.False(t, h.AutoAssign)
+		var employees []dto.Employee
+		for _, name := range []string{"assign-a", "assign-b"} {
+			resp = do("POST", fmt.Sprintf("/api/hospitals/%d/employees", h.ID), fmt.Sprintf(`{"username": %q}`, name))
+			defer resp.Body.Close()
+			var e dto.Employee
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+			employees = append(employees, e)
+		}
+
+		tasksPath := fmt.Sprintf("/api/hospitals/%d/tasks", h.ID)
+		resp = do("POST", tasksPath, `{"title": "t", "ownerId": "anyone", "priority": "LOW", "status": "OPEN"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		for _, want := range []int64{employees[0].ID, employees[1].ID} {
+			resp = do("POST", tasksPath, `{"title": "t", "ownerId": "auto", "priority": "LOW", "status": "OPEN"}`)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			var task dto.Task
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+			assert.Equal(t, want, task.OwnerID)
+		}
+
+		hospitalPath := fmt.Sprintf("/api/hospitals/%d", h.ID)
+		resp = do("PUT", hospitalPath, `{"name": "assign", "assignmentStrategy": "random"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("PUT", hospitalPath, `{"name": "assign", "autoAssign": true}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("GET", hospitalPath, "")
+		defer resp.Body.Close()
+		h = dto.Hospital{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+		assert.Equal(t, "leastOpen", h.AssignmentStrategy)
+		assert.True(t, h.AutoAssign)
+
+		resp = do("POST", tasksPath, `{"title": "t", "priority": "LOW", "status": "OPEN"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var task dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		assert.Equal(t, employees[0].ID, task.OwnerID)
+	})
+
+	t.Run("SLAs", func(t *testing.T) {
+		do := func(method, path, body string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", "/api/hospitals", `{"name": "sla"}`)
+		defer resp.Body.Close()
+		var h dto.Hospital
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/employees", h.ID), `{"username": "sla-a"}`)
+		defer resp.Body.Close()
+		var e dto.Employee
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+
+		slasPath := fmt.Sprintf("/api/hospitals/%d/slas", h.ID)
+		resp = do("PUT", slasPath+"/HIGH", `{"respondWithin": 60}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("PUT", slasPath+"/URGENT", `{"respondWithin": 0}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("PUT", slasPath+"/URGENT", `{"respondWithin": 60, "escalation": "page"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("PUT", slasPath+"/URGENT", `{"respondWithin": 60, "escalation": "reassign"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = do("PUT", slasPath+"/URGENT", fmt.Sprintf(`{"respondWithin": 60, "escalation": "reassign", "escalationChain": [%d]}`, e.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var sla dto.TaskSLA
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&sla))
+		assert.Equal(t, "URGENT", sla.Priority)
+		assert.Equal(t, []int64{e.ID}, sla.EscalationChain)
+		resp = do("PUT", slasPath+"/LOW", `{"respondWithin": 3600}`)
+		defer resp.Body.Close()
+		sla = dto.TaskSLA{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&sla))
+		assert.Equal(t, "none", sla.Escalation)
+
+		resp = do("GET", slasPath, "")
+		defer resp.Body.Close()
+		var slas dto.TaskSLAList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&slas))
+		assert.Equal(t, uint(2), slas.Total)
+
+		resp = do("DELETE", slasPath+"/LOW", "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("DELETE", slasPath+"/LOW", "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = do("GET", fmt.Sprintf("/api/hospitals/%d/slas", h.ID+100), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/tasks", h.ID), fmt.Sprintf(`{"title": "t", "ownerId": %d, "priority": "URGENT", "status": "OPEN"}`, e.ID))
+		defer resp.Body.Close()
+		var task dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		resp = do("GET", fmt.Sprintf("/api/tasks/%d/escalations", task.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var escalations dto.TaskEscalationList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&escalations))
+		assert.Equal(t, uint(0), escalations.Total)
+
+		resp = do("GET", fmt.Sprintf("/api/hospitals/%d/tasks?breached=yes", h.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("GET", fmt.Sprintf("/api/hospitals/%d/tasks?breached=true", h.ID), "")
+		defer resp.Body.Close()
+		var list dto.TaskList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		assert.Equal(t, uint(0), list.Total)
+	})
+
+	t.Run("TaskWatchers", func(t *testing.T) {
+		do := func(method, path, body string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", "/api/hospitals", `{"name": "watch"}`)
+		defer resp.Body.Close()
+		var h dto.Hospital
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+		var employees []dto.Employee
+		for _, name := range []string{"watch-owner", "watch-supervisor"} {
+			resp = do("POST", fmt.Sprintf("/api/hospitals/%d/employees", h.ID), fmt.Sprintf(`{"username": %q}`, name))
+			defer resp.Body.Close()
+			var e dto.Employee
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+			employees = append(employees, e)
+		}
+		owner, supervisor := employees[0], employees[1]
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/employees", hospital.ID), `{"username": "watch-stranger"}`)
+		defer resp.Body.Close()
+		var stranger dto.Employee
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&stranger))
+
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/tasks", h.ID), fmt.Sprintf(`{"title": "t", "ownerId": %d, "priority": "LOW", "status": "OPEN"}`, owner.ID))
+		defer resp.Body.Close()
+		var task dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+
+		watchersPath := fmt.Sprintf("/api/tasks/%d/watchers", task.ID)
+		resp = do("POST", watchersPath, `{}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("POST", watchersPath, fmt.Sprintf(`{"employeeId": %d}`, stranger.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp = do("POST", watchersPath, fmt.Sprintf(`{"employeeId": %d}`, supervisor.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do("GET", watchersPath, "")
+		defer resp.Body.Close()
+		var watchers dto.EmployeeList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&watchers))
+		assert.Equal(t, uint(1), watchers.Total)
+		resp = do("GET", fmt.Sprintf("/api/employees/%d/watched-tasks", supervisor.ID), "")
+		defer resp.Body.Close()
+		var watched dto.TaskList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&watched))
+		if assert.Equal(t, uint(1), watched.Total) {
+			assert.Equal(t, task.ID, watched.Items[0].ID)
+		}
+
+		resp = do("POST", fmt.Sprintf("/api/tasks/%d/start", task.ID), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("GET", fmt.Sprintf("/api/employees/%d/events", supervisor.ID), "")
+		defer resp.Body.Close()
+		var events dto.TaskEventList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&events))
+		if assert.Equal(t, uint(1), events.Total) {
+			assert.Equal(t, "status", events.Items[0].Change.Field)
+			assert.Equal(t, "IN_PROGRESS", events.Items[0].Change.NewValue)
+		}
+
+		resp = do("DELETE", watchersPath, fmt.Sprintf(`{"employeeId": %d}`, supervisor.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("DELETE", watchersPath, fmt.Sprintf(`{"employeeId": %d}`, supervisor.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = do("GET", fmt.Sprintf("/api/employees/%d/events", supervisor.ID+100), "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Shifts", func(t *testing.T) {
+		do := func(method, path, body string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", "/api/hospitals", `{"name": "shift"}`)
+		defer resp.Body.Close()
+		var h dto.Hospital
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+		assert.Equal(t, "allow", h.OffShiftAssignment)
+		var employees []dto.Employee
+		for _, name := range []string{"shift-day", "shift-night"} {
+			resp = do("POST", fmt.Sprintf("/api/hospitals/%d/employees", h.ID), fmt.Sprintf(`{"username": %q}`, name))
+			defer resp.Body.Close()
+			var e dto.Employee
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+			employees = append(employees, e)
+		}
+		day, night := employees[0], employees[1]
+
+		now := time.Now().UTC()
+		shiftsPath := fmt.Sprintf("/api/hospitals/%d/shifts", h.ID)
+		shiftBody := func(eid int64, start, end time.Time, ward string) string {
+			return fmt.Sprintf(`{"employeeId": %d, "startsAt": %q, "endsAt": %q, "ward": %q}`,
+				eid, start.Format(time.RFC3339), end.Format(time.RFC3339), ward)
+		}
+		resp = do("POST", shiftsPath, shiftBody(day.ID, now.Add(-time.Hour), now.Add(7*time.Hour), "icu"))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var shift dto.Shift
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&shift))
+		assert.Equal(t, "icu", shift.Ward)
+		resp = do("POST", shiftsPath, shiftBody(night.ID, now.Add(7*time.Hour), now.Add(15*time.Hour), "icu"))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = do("POST", shiftsPath, shiftBody(day.ID, now, now.Add(-time.Hour), ""))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("POST", shiftsPath, shiftBody(day.ID, now.Add(6*time.Hour), now.Add(8*time.Hour), ""))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = do("GET", shiftsPath+"/current?ward=icu", "")
+		defer resp.Body.Close()
+		var current dto.ShiftList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&current))
+		if assert.Equal(t, uint(1), current.Total) {
+			assert.Equal(t, day.ID, current.Items[0].EmployeeID)
+		}
+		resp = do("GET", shiftsPath+"?at="+now.Add(8*time.Hour).Format(time.RFC3339), "")
+		defer resp.Body.Close()
+		var later dto.ShiftList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&later))
+		if assert.Equal(t, uint(1), later.Total) {
+			assert.Equal(t, night.ID, later.Items[0].EmployeeID)
+		}
+		resp = do("GET", shiftsPath+"?at=now", "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		shiftPath := fmt.Sprintf("/api/shifts/%d", shift.ID)
+		resp = do("GET", shiftPath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		etag := resp.Header.Get("ETag")
+		update := func() *http.Response {
+			req, err := http.NewRequest("PUT", server.URL+shiftPath, bytes.NewReader([]byte(shiftBody(day.ID, now.Add(-time.Hour), now.Add(6*time.Hour), "er"))))
+			assert.NoError(t, err)
+			req.Header.Set("If-Match", etag)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+		resp = update()
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+		resp = update()
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/tasks", h.ID), `{"title": "t", "priority": "URGENT", "status": "OPEN"}`)
+		defer resp.Body.Close()
+		var task dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		assignPath := fmt.Sprintf("/api/tasks/%d/assign", task.ID)
+
+		resp = do("PUT", fmt.Sprintf("/api/hospitals/%d", h.ID), `{"name": "shift", "offShiftAssignment": "warn"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("POST", assignPath, fmt.Sprintf(`{"ownerId": %d}`, night.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var assigned dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&assigned))
+		assert.Equal(t, night.ID, assigned.OwnerID)
+		assert.Len(t, assigned.Warnings, 1)
+
+		resp = do("PUT", fmt.Sprintf("/api/hospitals/%d", h.ID), `{"name": "shift", "offShiftAssignment": "reject"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("POST", assignPath, fmt.Sprintf(`{"ownerId": %d}`, night.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		resp = do("POST", assignPath, fmt.Sprintf(`{"ownerId": %d}`, day.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("PUT", fmt.Sprintf("/api/hospitals/%d", h.ID), `{"name": "shift", "offShiftAssignment": "sometimes"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = do("DELETE", shiftPath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("GET", shiftPath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("TaskTemplates", func(t *testing.T) {
+		do := func(method, path, body string) *http.Response {
+			req, err := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+			assert.NoError(t, err)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		resp := do("POST", "/api/hospitals", `{"name": "template"}`)
+		defer resp.Body.Close()
+		var h dto.Hospital
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/labels", h.ID), `{"name": "post-op", "color": "#ff0000"}`)
+		defer resp.Body.Close()
+		var label dto.Label
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&label))
+
+		templatesPath := fmt.Sprintf("/api/hospitals/%d/task-templates", h.ID)
+		resp = do("POST", templatesPath, `{"title": "post-op check", "priority": "SOON"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("POST", templatesPath, fmt.Sprintf(`{"title": "post-op check", "description": "after the surgery", "priority": "HIGHT", "labelIds": [%d], "checklist": ["vitals", "wound"]}`, label.ID))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var template dto.TaskTemplate
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&template))
+		assert.Equal(t, []string{"vitals", "wound"}, template.Checklist)
+
+		resp = do("GET", templatesPath, "")
+		defer resp.Body.Close()
+		var templates dto.TaskTemplateList
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&templates))
+		assert.Equal(t, uint(1), templates.Total)
+
+		// The fields given override those of the template.
+		tasksPath := fmt.Sprintf("/api/hospitals/%d/tasks", h.ID)
+		resp = do("POST", fmt.Sprintf("%s?fromTemplate=%d", tasksPath, template.ID), `{"priority": "URGENT"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var task dto.Task
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&task))
+		assert.Equal(t, "post-op check", task.Title)
+		assert.Equal(t, "after the surgery", task.Description)
+		assert.Equal(t, "URGENT", task.Priority)
+		assert.Equal(t, "OPEN", task.Status)
+		assert.Len(t, task.Labels, 1)
+		if assert.NotNil(t, task.Subtasks) {
+			assert.Equal(t, uint(2), task.Subtasks.Total)
+		}
+
+		// The overrides go through the same checks as a task.
+		resp = do("POST", fmt.Sprintf("%s?fromTemplate=%d", tasksPath, template.ID), `{"priority": "SOON"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("POST", tasksPath+"?fromTemplate=x", `{}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = do("POST", fmt.Sprintf("%s?fromTemplate=%d", tasksPath, template.ID+100), `{}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = do("POST", fmt.Sprintf("/api/hospitals/%d/tasks?fromTemplate=%d", hospital.ID, template.ID), `{}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		templatePath := fmt.Sprintf("/api/task-templates/%d", template.ID)
+		resp = do("PUT", templatePath, `{"title": "post-op round", "priority": "LOW"}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("GET", templatePath, "")
+		defer resp.Body.Close()
+		var got dto.TaskTemplate
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, "post-op round", got.Title)
+		assert.Empty(t, got.Checklist)
+		assert.NotEmpty(t, resp.Header.Get("ETag"))
+
+		resp = do("DELETE", templatePath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = do("GET", templatePath, "")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("DeleteAndRestoreTask", func(t *testing.T) {
+		path := fmt.Sprintf("%s/api/tasks/%d", server.URL, taskB.ID)
+		listPath := fmt.Sprintf("%s/api/hospitals/%d/tasks", server.URL, hospital.ID)
+
+		req, err := http.NewRequest("DELETE", path, nil)
+		assert.NoError(t, err)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = client.Get(path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var list dto.TaskList
+		resp, err = client.Get(listPath)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+
+		// Only the admins can list the deleted tasks.
+		resp, err = client.Get(listPath + "?includeDeleted=true")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		listAsAdmin := func(query string) *http.Response {
+			req, err := http.NewRequest("GET", listPath+query, nil)
+			require.NoError(t, err)
+			req.Header.Set("X-Employee-ID", fmt.Sprint(testAdmin))
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			return resp
+		}
+		resp = listAsAdmin("?includeDeleted=true")
+		defer resp.Body.Close()
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), list.Total)
+
+		resp = listAsAdmin("?includeDeleted=maybe")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, err = client.Post(path+"/restore", "application/json", nil)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = client.Get(path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
 }
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"github.com/liuerfire/boxpractice/internal/services"
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+// attachmentField is the multipart field holding the uploaded file.
+const attachmentField = "file"
+
+// maxFilenameLength is the size of the filename column, in characters.
+const maxFilenameLength = 255
+
+// maxUploadOverhead is how much the multipart encoding may add to the size
+// of the uploaded file.
+const maxUploadOverhead = 1 << 20
+
+func (api *API) handleListAttachments(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	attachments, err := api.attachmentService.ListAttachments(r.Context(), id, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, attachments)
+}
+
+// handleCreateAttachment streams the file of the multipart request to the
+// attachment service, without buffering it in memory or on disk.
+func (api *API) handleCreateAttachment(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAttachmentSize+maxUploadOverhead)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			renderBadRequestErr(w, fmt.Errorf("missing %s field", attachmentField))
+			return
+		}
+		if err != nil {
+			renderUploadError(w, err)
+			return
+		}
+		if part.FormName() != attachmentField {
+			part.Close()
+			continue
+		}
+		filename, err := sanitizeFilename(part.FileName())
+		if err != nil {
+			renderBadRequestErr(w, err)
+			return
+		}
+		req := dto.Attachment{
+			TaskID:     id,
+			UploaderID: services.ActorFrom(r.Context()),
+			Filename:   filename,
+		}
+		attachment, err := api.attachmentService.CreateAttachment(r.Context(), &req, part)
+		if err != nil {
+			renderUploadError(w, err)
+			return
+		}
+		renderJSON(w, http.StatusCreated, attachment)
+		return
+	}
+}
+
+func (api *API) handleDownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	id, aid, err := parseAttachmentIDs(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	attachment, content, err := api.attachmentService.OpenAttachment(r.Context(), id, aid)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	defer content.Close()
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, content); err != nil {
+		api.logger.Error(err, "failed to send attachment", "id", aid)
+	}
+}
+
+func (api *API) handleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, aid, err := parseAttachmentIDs(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.attachmentService.DeleteAttachment(r.Context(), id, aid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// parseAttachmentIDs returns the ids of the task and of the attachment.
+func parseAttachmentIDs(r *http.Request) (int64, int64, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	aid, err := strconv.ParseInt(vars["aid"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, aid, nil
+}
+
+// sanitizeFilename drops any directory from the name of the uploaded file,
+// which is only kept to be given back on download.
+func sanitizeFilename(name string) (string, error) {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" || !utf8.ValidString(name) {
+		return "", errors.New("invalid filename")
+	}
+	if utf8.RuneCountInString(name) > maxFilenameLength {
+		return "", errors.New("filename too long")
+	}
+	return name, nil
+}
+
+// renderUploadError renders err, which is ErrTooLarge if the request body
+// exceeds its limit.
+func renderUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		err = &services.ServiceError{Code: services.ErrTooLarge, Msg: fmt.Sprintf("the attachment exceeds %d bytes", services.MaxAttachmentSize)}
+	}
+	renderSvcError(w, err)
+}
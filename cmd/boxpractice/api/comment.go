@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+// maxCommentLength is the size of the body column, in characters.
+const maxCommentLength = 2000
+
+func (api *API) handleListComments(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	comments, err := api.commentService.ListComments(r.Context(), id, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, comments)
+}
+
+func (api *API) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	actor, err := parseActor(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.Comment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateComment(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.TaskID = id
+	req.AuthorID = actor
+	comment, err := api.commentService.CreateComment(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusCreated, comment)
+}
+
+func (api *API) handleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	actor, err := parseActor(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.Comment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateComment(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.ID = id
+	comment, err := api.commentService.UpdateComment(r.Context(), &req, actor)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, comment)
+}
+
+func (api *API) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	actor, err := parseActor(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.commentService.DeleteComment(r.Context(), id, actor); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func validateComment(c *dto.Comment) error {
+	if c.Body == "" {
+		return errors.New("invalid body")
+	}
+	if utf8.RuneCountInString(c.Body) > maxCommentLength {
+		return errors.New("body too long")
+	}
+	return nil
+}
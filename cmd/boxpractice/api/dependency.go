@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type setTaskParentReq struct {
+	ParentID int64 `json:"parentId"`
+}
+
+func (api *API) handleSetTaskParent(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req setTaskParentReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if req.ParentID < 0 {
+		renderBadRequestErr(w, errors.New("invalid parent id"))
+		return
+	}
+	task, err := api.taskService.SetTaskParent(r.Context(), id, req.ParentID)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, task.Version)
+	renderJSON(w, http.StatusOK, task)
+}
+
+func (api *API) handleUnsetTaskParent(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if _, err := api.taskService.SetTaskParent(r.Context(), id, 0); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleListSubtasks(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	tasks, err := api.taskService.ListSubtasks(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, tasks)
+}
+
+func (api *API) handleListTaskBlockers(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	tasks, err := api.taskService.ListTaskBlockers(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, tasks)
+}
+
+func (api *API) handleAddTaskBlocker(w http.ResponseWriter, r *http.Request) {
+	id, bid, err := parseTaskBlockerIDs(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.taskService.AddTaskBlocker(r.Context(), id, bid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleRemoveTaskBlocker(w http.ResponseWriter, r *http.Request) {
+	id, bid, err := parseTaskBlockerIDs(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.taskService.RemoveTaskBlocker(r.Context(), id, bid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// parseTaskBlockerIDs returns the ids of the task and of its blocker.
+func parseTaskBlockerIDs(r *http.Request) (int64, int64, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	bid, err := strconv.ParseInt(vars["bid"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, bid, nil
+}
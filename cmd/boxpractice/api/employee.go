@@ -18,8 +18,12 @@ func (api *API) handleListEmployees(w http.ResponseWriter, r *http.Request) {
 		renderBadRequestErr(w, err)
 		return
 	}
-	page, limit := parsePaginationParams(r.URL.Query().Get("page"), r.URL.Query().Get("limit"))
-	employeeList, err := api.employeeService.ListEmployees(r.Context(), hid, page-1, limit)
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	employeeList, err := api.employeeService.ListEmployees(r.Context(), hid, opts)
 	if err != nil {
 		renderSvcError(w, err)
 		return
@@ -71,3 +75,34 @@ func (api *API) handleGetEmployee(w http.ResponseWriter, r *http.Request) {
 	}
 	renderJSON(w, http.StatusOK, employee)
 }
+
+func (api *API) handleDeleteEmployee(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	opts, err := parseDeleteOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.employeeService.DeleteEmployee(r.Context(), id, opts); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleRestoreEmployee(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.employeeService.RestoreEmployee(r.Context(), id); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
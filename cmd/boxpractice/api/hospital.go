@@ -3,8 +3,10 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -12,8 +14,12 @@ import (
 )
 
 func (api *API) handleListHospitals(w http.ResponseWriter, r *http.Request) {
-	page, limit := parsePaginationParams(r.URL.Query().Get("page"), r.URL.Query().Get("limit"))
-	h, err := api.hospitalService.ListHospitals(r.Context(), page-1, limit)
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	h, err := api.hospitalService.ListHospitals(r.Context(), opts)
 	if err != nil {
 		renderSvcError(w, err)
 		return
@@ -31,6 +37,10 @@ func (api *API) handleCreateHospital(w http.ResponseWriter, r *http.Request) {
 		renderBadRequestErr(w, errors.New("name is null"))
 		return
 	}
+	if err := validateTimezone(req.Timezone); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
 	hospital, err := api.hospitalService.CreateHospital(r.Context(), &req)
 	if err != nil {
 		renderSvcError(w, err)
@@ -51,6 +61,7 @@ func (api *API) handleGetHospital(w http.ResponseWriter, r *http.Request) {
 		renderSvcError(w, err)
 		return
 	}
+	setETag(w, hospital.Version)
 	renderJSON(w, http.StatusOK, hospital)
 }
 
@@ -70,6 +81,15 @@ func (api *API) handleUpdateHospital(w http.ResponseWriter, r *http.Request) {
 		renderBadRequestErr(w, errors.New("name is null"))
 		return
 	}
+	if err := validateTimezone(req.Timezone); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
 	hospital, err := api.hospitalService.GetHospital(r.Context(), hid)
 	if err != nil {
 		renderSvcError(w, err)
@@ -77,8 +97,61 @@ func (api *API) handleUpdateHospital(w http.ResponseWriter, r *http.Request) {
 	}
 	hospital.Name = req.Name
 	hospital.DisplayName = req.DisplayName
+	if req.Timezone != "" {
+		hospital.Timezone = req.Timezone
+	}
+	if req.AssignmentStrategy != "" {
+		hospital.AssignmentStrategy = req.AssignmentStrategy
+	}
+	hospital.AutoAssign = req.AutoAssign
+	if req.OffShiftAssignment != "" {
+		hospital.OffShiftAssignment = req.OffShiftAssignment
+	}
+	hospital.Version = version
 	if err := api.hospitalService.UpdateHospital(r.Context(), hospital); err != nil {
 		renderSvcError(w, err)
 		return
 	}
 }
+
+func (api *API) handleDeleteHospital(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	opts, err := parseDeleteOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.hospitalService.DeleteHospital(r.Context(), hid, opts); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleRestoreHospital(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.hospitalService.RestoreHospital(r.Context(), hid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// validateTimezone checks that tz is the IANA name of a time zone, if set.
+func validateTimezone(tz string) error {
+	if tz == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+		return fmt.Errorf("invalid timezone: %s", tz)
+	}
+	return nil
+}
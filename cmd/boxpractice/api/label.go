@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+// maxLabelNameLength is the size of the name column, in characters.
+const maxLabelNameLength = 64
+
+var labelColorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func (api *API) handleListLabels(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if _, err := api.hospitalService.GetHospital(r.Context(), hid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	labels, err := api.labelService.ListLabels(r.Context(), hid, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, labels)
+}
+
+func (api *API) handleCreateLabel(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.Label
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateLabel(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.HospitalID = hid
+	label, err := api.labelService.CreateLabel(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusCreated, label)
+}
+
+func (api *API) handleGetLabel(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	label, err := api.labelService.GetLabel(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, label)
+}
+
+func (api *API) handleUpdateLabel(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.Label
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateLabel(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.ID = id
+	label, err := api.labelService.UpdateLabel(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, label)
+}
+
+func (api *API) handleDeleteLabel(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.labelService.DeleteLabel(r.Context(), id); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleLabelTask(w http.ResponseWriter, r *http.Request) {
+	id, lid, err := parseTaskLabelIDs(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.labelService.LabelTask(r.Context(), id, lid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleUnlabelTask(w http.ResponseWriter, r *http.Request) {
+	id, lid, err := parseTaskLabelIDs(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.labelService.UnlabelTask(r.Context(), id, lid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// parseTaskLabelIDs returns the ids of the task and of the label.
+func parseTaskLabelIDs(r *http.Request) (int64, int64, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	lid, err := strconv.ParseInt(vars["lid"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, lid, nil
+}
+
+// validateLabel checks the label, whose colour is lowercased.
+func validateLabel(l *dto.Label) error {
+	l.Name = strings.TrimSpace(l.Name)
+	if l.Name == "" {
+		return errors.New("invalid name")
+	}
+	if utf8.RuneCountInString(l.Name) > maxLabelNameLength {
+		return errors.New("name too long")
+	}
+	if !labelColorRegexp.MatchString(l.Color) {
+		return errors.New("invalid color, expecting #rrggbb")
+	}
+	l.Color = strings.ToLower(l.Color)
+	return nil
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+func (api *API) handleListRecurrences(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if _, err := api.hospitalService.GetHospital(r.Context(), hid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	recurrences, err := api.recurrenceService.ListTaskRecurrences(r.Context(), hid, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, recurrences)
+}
+
+func (api *API) handleCreateRecurrence(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.TaskRecurrence
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateRecurrence(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.HospitalID = hid
+	recurrence, err := api.recurrenceService.CreateTaskRecurrence(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusCreated, recurrence)
+}
+
+func (api *API) handleGetRecurrence(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	recurrence, err := api.recurrenceService.GetTaskRecurrence(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, recurrence.Version)
+	renderJSON(w, http.StatusOK, recurrence)
+}
+
+func (api *API) handleUpdateRecurrence(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.TaskRecurrence
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateRecurrence(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	req.ID = id
+	req.Version = version
+	recurrence, err := api.recurrenceService.UpdateTaskRecurrence(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, recurrence.Version)
+	renderJSON(w, http.StatusOK, recurrence)
+}
+
+func (api *API) handleDeleteRecurrence(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.recurrenceService.DeleteTaskRecurrence(r.Context(), id); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// validateRecurrence checks the template of the tasks and the owners of the
+// recurrence. The schedule is parsed by the service.
+func validateRecurrence(r *dto.TaskRecurrence) error {
+	if r.Title == "" {
+		return errors.New("invalid title")
+	}
+	if !isValidPriority(r.Priority) {
+		return errors.New("invalid priority")
+	}
+	if r.Schedule == "" {
+		return errors.New("invalid schedule")
+	}
+	if r.DueIn < 0 {
+		return errors.New("invalid dueIn")
+	}
+	if len(r.OwnerIDs) == 0 {
+		return errors.New("no owner")
+	}
+	for _, oid := range r.OwnerIDs {
+		if oid <= 0 {
+			return errors.New("invalid owner id")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+// handleListShifts lists the shifts of a hospital, narrowed by the
+// employeeId, ward and at params.
+func (api *API) handleListShifts(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	filter, err := parseShiftFilter(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	api.listShifts(w, r, hid, filter, opts)
+}
+
+// handleListCurrentShifts lists the shifts of a hospital going on now, or
+// at the time given by the at param, which tells who is on shift.
+func (api *API) handleListCurrentShifts(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	filter, err := parseShiftFilter(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if filter.At.IsZero() {
+		filter.At = time.Now()
+	}
+	api.listShifts(w, r, hid, filter, opts)
+}
+
+func (api *API) listShifts(w http.ResponseWriter, r *http.Request, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) {
+	if _, err := api.hospitalService.GetHospital(r.Context(), hid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	shifts, err := api.shiftService.ListShifts(r.Context(), hid, filter, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, shifts)
+}
+
+func (api *API) handleCreateShift(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.Shift
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateShift(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.HospitalID = hid
+	shift, err := api.shiftService.CreateShift(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusCreated, shift)
+}
+
+func (api *API) handleGetShift(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	shift, err := api.shiftService.GetShift(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, shift.Version)
+	renderJSON(w, http.StatusOK, shift)
+}
+
+func (api *API) handleUpdateShift(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.Shift
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateShift(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	req.ID = id
+	req.Version = version
+	shift, err := api.shiftService.UpdateShift(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, shift.Version)
+	renderJSON(w, http.StatusOK, shift)
+}
+
+func (api *API) handleDeleteShift(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.shiftService.DeleteShift(r.Context(), id); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// validateShift checks the employee, the times and the ward of the shift.
+// The overlaps with the other shifts are checked by the service.
+func validateShift(sh *dto.Shift) error {
+	if sh.EmployeeID <= 0 {
+		return errors.New("invalid employee id")
+	}
+	if sh.StartsAt.IsZero() || sh.EndsAt.IsZero() {
+		return errors.New("invalid startsAt or endsAt")
+	}
+	if !sh.EndsAt.After(sh.StartsAt) {
+		return errors.New("endsAt isn't after startsAt")
+	}
+	if len(sh.Ward) > 100 {
+		return errors.New("invalid ward")
+	}
+	return nil
+}
+
+func parseShiftFilter(r *http.Request) (dto.ShiftFilter, error) {
+	var filter dto.ShiftFilter
+	q := r.URL.Query()
+	if v := q.Get("employeeId"); v != "" {
+		eid, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid employeeId: %s", v)
+		}
+		filter.EmployeeID = eid
+	}
+	filter.Ward = q.Get("ward")
+	if v := q.Get("at"); v != "" {
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid at: %s", v)
+		}
+		filter.At = at
+	}
+	return filter, nil
+}
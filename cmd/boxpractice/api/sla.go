@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+func (api *API) handleListTaskSLAs(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if _, err := api.hospitalService.GetHospital(r.Context(), hid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	slas, err := api.slaService.ListTaskSLAs(r.Context(), hid)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, slas)
+}
+
+// handleSetTaskSLA creates or replaces the SLA of the hospital for the
+// priority in the path.
+func (api *API) handleSetTaskSLA(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hid, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.TaskSLA
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.HospitalID = hid
+	req.Priority = vars["priority"]
+	if err := validateTaskSLA(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	sla, err := api.slaService.SetTaskSLA(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, sla)
+}
+
+func (api *API) handleDeleteTaskSLA(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	hid, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if !isValidPriority(vars["priority"]) {
+		renderBadRequestErr(w, errors.New("invalid priority"))
+		return
+	}
+	if err := api.slaService.DeleteTaskSLA(r.Context(), hid, vars["priority"]); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleListTaskEscalations(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	escalations, err := api.slaService.ListTaskEscalations(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, escalations)
+}
+
+// validateTaskSLA checks the priority, the time and the escalation chain of
+// the SLA. The escalation is checked by the service.
+func validateTaskSLA(sla *dto.TaskSLA) error {
+	if !isValidPriority(sla.Priority) {
+		return errors.New("invalid priority")
+	}
+	if sla.RespondWithin <= 0 {
+		return errors.New("invalid respondWithin")
+	}
+	for _, eid := range sla.EscalationChain {
+		if eid <= 0 {
+			return errors.New("invalid employee id in the escalation chain")
+		}
+	}
+	return nil
+}
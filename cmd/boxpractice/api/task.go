@@ -3,18 +3,29 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
-	"github.com/liuerfire/boxpractice/internal/services"
 	"github.com/liuerfire/boxpractice/pkg/dto"
 	"github.com/liuerfire/boxpractice/pkg/models"
 )
 
 func (api *API) handleListHospitalTasks(w http.ResponseWriter, r *http.Request) {
-	page, limit := parsePaginationParams(r.URL.Query().Get("page"), r.URL.Query().Get("limit"))
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	filter, err := parseTaskFilter(r, &opts)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
 	hidStr := mux.Vars(r)["id"]
 	hid, err := strconv.ParseInt(hidStr, 10, 64)
 	if err != nil {
@@ -26,7 +37,7 @@ func (api *API) handleListHospitalTasks(w http.ResponseWriter, r *http.Request)
 		renderSvcError(w, err)
 		return
 	}
-	taskList, err := api.taskService.ListTasksByHospital(r.Context(), hid, page-1, limit)
+	taskList, err := api.taskService.ListTasksByHospital(r.Context(), hid, filter, opts)
 	if err != nil {
 		renderSvcError(w, err)
 		return
@@ -34,20 +45,32 @@ func (api *API) handleListHospitalTasks(w http.ResponseWriter, r *http.Request)
 	renderJSON(w, http.StatusOK, taskList)
 }
 
-func (api *API) handleListEmployeeTasks(w http.ResponseWriter, r *http.Request) {
-	page, limit := parsePaginationParams(r.URL.Query().Get("page"), r.URL.Query().Get("limit"))
-	idStr := mux.Vars(r)["id"]
-	oid, err := strconv.ParseInt(idStr, 10, 64)
+// handleListOverdueTasks lists the tasks of the hospital flagged by the
+// overdue sweeper. It takes the same params as handleListHospitalTasks.
+func (api *API) handleListOverdueTasks(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
 	if err != nil {
 		renderBadRequestErr(w, err)
 		return
 	}
-	_, err = api.employeeService.GetEmployee(r.Context(), oid)
+	filter, err := parseTaskFilter(r, &opts)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	filter.Overdue = true
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	_, err = api.hospitalService.GetHospital(r.Context(), hid)
 	if err != nil {
 		renderSvcError(w, err)
 		return
 	}
-	taskList, err := api.taskService.ListTasksByOwner(r.Context(), oid, page-1, limit)
+	taskList, err := api.taskService.ListTasksByHospital(r.Context(), hid, filter, opts)
 	if err != nil {
 		renderSvcError(w, err)
 		return
@@ -55,36 +78,126 @@ func (api *API) handleListEmployeeTasks(w http.ResponseWriter, r *http.Request)
 	renderJSON(w, http.StatusOK, taskList)
 }
 
-func (api *API) handleCreateTask(w http.ResponseWriter, r *http.Request) {
+// handleListUnassignedTasks lists the tasks in the pool of the hospital,
+// which have no owner. It takes the same params as handleListHospitalTasks.
+func (api *API) handleListUnassignedTasks(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	filter, err := parseTaskFilter(r, &opts)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	filter.Unassigned = true
 	hidStr := mux.Vars(r)["id"]
 	hid, err := strconv.ParseInt(hidStr, 10, 64)
 	if err != nil {
 		renderBadRequestErr(w, err)
 		return
 	}
-	var req dto.Task
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	_, err = api.hospitalService.GetHospital(r.Context(), hid)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	taskList, err := api.taskService.ListTasksByHospital(r.Context(), hid, filter, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, taskList)
+}
+
+func (api *API) handleListEmployeeTasks(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
 		renderBadRequestErr(w, err)
 		return
 	}
-	if err = validateTask(&req); err != nil {
+	filter, err := parseTaskFilter(r, &opts)
+	if err != nil {
 		renderBadRequestErr(w, err)
 		return
 	}
-	_, err = api.hospitalService.GetHospital(r.Context(), hid)
+	idStr := mux.Vars(r)["id"]
+	oid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	_, err = api.employeeService.GetEmployee(r.Context(), oid)
 	if err != nil {
 		renderSvcError(w, err)
 		return
 	}
-	owner, err := api.employeeService.GetEmployee(r.Context(), req.OwnerID)
+	taskList, err := api.taskService.ListTasksByOwner(r.Context(), oid, filter, opts)
 	if err != nil {
 		renderSvcError(w, err)
 		return
 	}
-	if owner.HospitalID != hid {
-		renderSvcError(w, &services.ServiceError{Code: services.ErrPermissionDenied, Msg: "forbidden"})
+	renderJSON(w, http.StatusOK, taskList)
+}
+
+// ownerRef is the owner of a task being created: either the id of an
+// employee, or "auto" to have one picked by the assignment strategy of the
+// hospital.
+type ownerRef struct {
+	ID   int64
+	Auto bool
+}
+
+func (o *ownerRef) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "auto" {
+			return fmt.Errorf("invalid owner id: %s", s)
+		}
+		o.Auto = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.ID)
+}
+
+type createTaskReq struct {
+	dto.Task
+	OwnerID ownerRef `json:"ownerId"`
+}
+
+func (api *API) handleCreateTask(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var body createTaskReq
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		renderBadRequestErr(w, err)
 		return
 	}
+	req := body.Task
+	req.OwnerID, req.AutoAssign = body.OwnerID.ID, body.OwnerID.Auto
+	if v := r.URL.Query().Get("fromTemplate"); v != "" {
+		tid, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			renderBadRequestErr(w, fmt.Errorf("invalid fromTemplate: %s", v))
+			return
+		}
+		template, err := api.templateService.GetTaskTemplate(r.Context(), tid)
+		if err != nil {
+			renderSvcError(w, err)
+			return
+		}
+		overrideTaskTemplate(&req, template)
+	}
+	if err = validateTask(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.HospitalID = hid
 	// The initial status
 	req.Status = models.TaskStatusOpen
 	task, err := api.taskService.CreateTask(r.Context(), &req)
@@ -95,6 +208,22 @@ func (api *API) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	renderJSON(w, http.StatusCreated, task)
 }
 
+func (api *API) handleGetTask(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	task, err := api.taskService.GetTask(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, task.Version)
+	renderJSON(w, http.StatusOK, task)
+}
+
 func (api *API) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -111,6 +240,11 @@ func (api *API) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		renderBadRequestErr(w, err)
 		return
 	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
 	task, err := api.taskService.GetTask(r.Context(), id)
 	if err != nil {
 		renderSvcError(w, err)
@@ -120,12 +254,127 @@ func (api *API) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	task.Description = req.Description
 	task.Priority = req.Priority
 	task.Status = req.Status
+	task.DueAt = req.DueAt
+	task.Version = version
 	if err := api.taskService.UpdateTask(r.Context(), task); err != nil {
 		renderSvcError(w, err)
 		return
 	}
 }
 
+func (api *API) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.taskService.DeleteTask(r.Context(), id); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleRestoreTask(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.taskService.RestoreTask(r.Context(), id); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// handleTransitionTask returns the handler of the action moving a task to
+// the status to.
+func (api *API) handleTransitionTask(to string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idStr := mux.Vars(r)["id"]
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			renderBadRequestErr(w, err)
+			return
+		}
+		version, err := parseIfMatch(r)
+		if err != nil {
+			renderSvcError(w, err)
+			return
+		}
+		task, err := api.taskService.TransitionTask(r.Context(), id, to, version)
+		if err != nil {
+			renderSvcError(w, err)
+			return
+		}
+		setETag(w, task.Version)
+		renderJSON(w, http.StatusOK, task)
+	}
+}
+
+func (api *API) handleListTaskTransitions(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	transitions, err := api.taskService.ListTaskTransitions(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, transitions)
+}
+
+func (api *API) handleListTaskHistory(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	history, err := api.taskService.ListTaskHistory(r.Context(), id, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, history)
+}
+
+func (api *API) handleRevertTask(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	toStr := r.URL.Query().Get("to")
+	to, err := strconv.ParseInt(toStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, fmt.Errorf("invalid to: %s", toStr))
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	task, err := api.taskService.RevertTask(r.Context(), id, to, version)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, task.Version)
+	renderJSON(w, http.StatusOK, task)
+}
+
 type assignTaskReq struct {
 	OwnerID int64 `json:"ownerId"`
 }
@@ -142,34 +391,163 @@ func (api *API) handleAssignTask(w http.ResponseWriter, r *http.Request) {
 		renderBadRequestErr(w, err)
 		return
 	}
-	task, err := api.taskService.GetTask(r.Context(), id)
+	task, err := api.taskService.AssignTask(r.Context(), id, req.OwnerID)
 	if err != nil {
 		renderSvcError(w, err)
 		return
 	}
-	employee, err := api.employeeService.GetEmployee(r.Context(), req.OwnerID)
+	setETag(w, task.Version)
+	renderJSON(w, http.StatusOK, task)
+}
+
+// handleBulkUpdateTasks applies an action to the tasks given by id in the
+// body or, if there are none, to the tasks selected by the filter params of
+// handleListHospitalTasks.
+func (api *API) handleBulkUpdateTasks(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.TaskBulk
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if req.Filter, err = parseTaskFilter(r, &dto.ListOptions{}); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	// Without ids nor filter, the action would apply to every task of the
+	// hospital.
+	if len(req.TaskIDs) == 0 && req.Filter.IsEmpty() {
+		renderBadRequestErr(w, errors.New("no task selected"))
+		return
+	}
+	if err := validateTaskBulk(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	report, err := api.taskService.BulkUpdateTasks(r.Context(), hid, &req)
 	if err != nil {
 		renderSvcError(w, err)
 		return
 	}
-	if task.HospitalID != employee.HospitalID {
-		renderSvcError(w, &services.ServiceError{Code: services.ErrPermissionDenied, Msg: "forbidden"})
+	renderJSON(w, http.StatusOK, report)
+}
+
+// handleClaimTask gives a task of the pool to the employee making the
+// request.
+func (api *API) handleClaimTask(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
 		return
 	}
-	task.OwnerID = employee.ID
-	if err := api.taskService.UpdateTask(r.Context(), task); err != nil {
+	oid, err := parseActor(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	task, err := api.taskService.ClaimTask(r.Context(), id, oid)
+	if err != nil {
 		renderSvcError(w, err)
 		return
 	}
+	setETag(w, task.Version)
+	renderJSON(w, http.StatusOK, task)
+}
+
+// parseTaskFilter parses the filter params of the task lists, and the sort
+// param into opts. status and priority are comma-separated lists, and sort is
+// a comma-separated list of fields, each of them descending if prefixed with
+// a "-".
+func parseTaskFilter(r *http.Request, opts *dto.ListOptions) (dto.TaskFilter, error) {
+	q := r.URL.Query()
+	var filter dto.TaskFilter
+	if v := q.Get("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+		for _, s := range filter.Statuses {
+			if !isValidStatus(s) {
+				return filter, fmt.Errorf("invalid status: %s", s)
+			}
+		}
+	}
+	if v := q.Get("priority"); v != "" {
+		filter.Priorities = strings.Split(v, ",")
+		for _, p := range filter.Priorities {
+			if !isValidPriority(p) {
+				return filter, fmt.Errorf("invalid priority: %s", p)
+			}
+		}
+	}
+	if v := q.Get("ownerId"); v != "" {
+		oid, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid ownerId: %s", v)
+		}
+		filter.OwnerID = oid
+	}
+	for _, param := range []struct {
+		name string
+		t    *time.Time
+	}{
+		{"createdAfter", &filter.CreatedAfter},
+		{"createdBefore", &filter.CreatedBefore},
+		{"dueAfter", &filter.DueAfter},
+		{"dueBefore", &filter.DueBefore},
+	} {
+		if v := q.Get(param.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s: %s", param.name, v)
+			}
+			*param.t = t
+		}
+	}
+	if v := q.Get("breached"); v != "" {
+		breached, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid breached: %s", v)
+		}
+		filter.Breached = breached
+	}
+	if v := q.Get("label"); v != "" {
+		for _, idStr := range strings.Split(v, ",") {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid label: %s", idStr)
+			}
+			filter.LabelIDs = append(filter.LabelIDs, id)
+		}
+	}
+	switch v := q.Get("labelMatch"); v {
+	case "", dto.LabelMatchAny, dto.LabelMatchAll:
+		filter.LabelMatch = v
+	default:
+		return filter, fmt.Errorf("invalid labelMatch: %s", v)
+	}
+	if v := q.Get("sort"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			f := dto.SortField{Name: strings.TrimPrefix(name, "-"), Desc: strings.HasPrefix(name, "-")}
+			opts.Sort = append(opts.Sort, f)
+		}
+	}
+	return filter, nil
 }
 
 func validateTask(t *dto.Task) error {
-	if t.OwnerID <= 0 {
+	if t.OwnerID < 0 {
 		return errors.New("invalid owner id")
 	}
 	if t.Title == "" {
 		return errors.New("invalid title")
 	}
+	if t.ParentID < 0 {
+		return errors.New("invalid parent id")
+	}
 	if !isValidPriority(t.Priority) {
 		return errors.New("invalid priority")
 	}
@@ -179,6 +557,37 @@ func validateTask(t *dto.Task) error {
 	return nil
 }
 
+// validateTaskBulk checks the task ids and the argument of the action of a
+// bulk update.
+func validateTaskBulk(b *dto.TaskBulk) error {
+	for _, id := range b.TaskIDs {
+		if id <= 0 {
+			return fmt.Errorf("invalid task id: %d", id)
+		}
+	}
+	switch b.Action {
+	case dto.BulkActionAssign:
+		if b.OwnerID <= 0 {
+			return errors.New("invalid owner id")
+		}
+	case dto.BulkActionSetStatus:
+		if !isValidStatus(b.Status) {
+			return errors.New("invalid status")
+		}
+	case dto.BulkActionSetPriority:
+		if !isValidPriority(b.Priority) {
+			return errors.New("invalid priority")
+		}
+	case dto.BulkActionAddLabel:
+		if b.LabelID <= 0 {
+			return errors.New("invalid label id")
+		}
+	default:
+		return fmt.Errorf("invalid action: %s", b.Action)
+	}
+	return nil
+}
+
 func isValidPriority(p string) bool {
 	for _, elem := range []string{models.TaskPriorityUrgent, models.TaskPriorityHight, models.TaskPriorityLow} {
 		if elem == p {
@@ -189,7 +598,7 @@ func isValidPriority(p string) bool {
 }
 
 func isValidStatus(s string) bool {
-	for _, elem := range []string{models.TaskStatusOpen, models.TaskStatusFAILED, models.TaskStatusCOMPLETED} {
+	for _, elem := range models.TaskStatuses {
 		if elem == s {
 			return true
 		}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func (api *API) handleListTaskTemplates(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if _, err := api.hospitalService.GetHospital(r.Context(), hid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	templates, err := api.templateService.ListTaskTemplates(r.Context(), hid, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, templates)
+}
+
+func (api *API) handleCreateTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	hidStr := mux.Vars(r)["id"]
+	hid, err := strconv.ParseInt(hidStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.TaskTemplate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateTaskTemplate(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	req.HospitalID = hid
+	template, err := api.templateService.CreateTaskTemplate(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusCreated, template)
+}
+
+func (api *API) handleGetTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	template, err := api.templateService.GetTaskTemplate(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, template.Version)
+	renderJSON(w, http.StatusOK, template)
+}
+
+func (api *API) handleUpdateTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	var req dto.TaskTemplate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := validateTaskTemplate(&req); err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	req.ID = id
+	req.Version = version
+	template, err := api.templateService.UpdateTaskTemplate(r.Context(), &req)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	setETag(w, template.Version)
+	renderJSON(w, http.StatusOK, template)
+}
+
+func (api *API) handleDeleteTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.templateService.DeleteTaskTemplate(r.Context(), id); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// validateTaskTemplate checks the fields, the labels and the checklist of
+// the template. The hospital of the labels is checked by the service.
+func validateTaskTemplate(t *dto.TaskTemplate) error {
+	if t.Title == "" {
+		return errors.New("invalid title")
+	}
+	if !isValidPriority(t.Priority) {
+		return errors.New("invalid priority")
+	}
+	for _, lid := range t.LabelIDs {
+		if lid <= 0 {
+			return errors.New("invalid label id")
+		}
+	}
+	for _, item := range t.Checklist {
+		if item == "" || len(item) > 100 {
+			return errors.New("invalid checklist item")
+		}
+	}
+	return nil
+}
+
+// overrideTaskTemplate fills the fields of the task t left empty with those
+// of the template, so the fields given override the template.
+func overrideTaskTemplate(t *dto.Task, template *dto.TaskTemplate) {
+	if t.Title == "" {
+		t.Title = template.Title
+	}
+	if t.Description == "" {
+		t.Description = template.Description
+	}
+	if t.Priority == "" {
+		t.Priority = template.Priority
+	}
+	if t.Status == "" {
+		t.Status = models.TaskStatusOpen
+	}
+	t.TemplateID = template.ID
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type watchTaskReq struct {
+	EmployeeID int64 `json:"employeeId"`
+}
+
+func (api *API) handleListTaskWatchers(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	watchers, err := api.taskService.ListTaskWatchers(r.Context(), id)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, watchers)
+}
+
+func (api *API) handleWatchTask(w http.ResponseWriter, r *http.Request) {
+	id, eid, err := parseWatchTaskReq(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.taskService.WatchTask(r.Context(), id, eid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+func (api *API) handleUnwatchTask(w http.ResponseWriter, r *http.Request) {
+	id, eid, err := parseWatchTaskReq(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if err := api.taskService.UnwatchTask(r.Context(), id, eid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+}
+
+// parseWatchTaskReq returns the ids of the task and of the watcher given in
+// the body.
+func parseWatchTaskReq(r *http.Request) (int64, int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	var req watchTaskReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return 0, 0, err
+	}
+	if req.EmployeeID <= 0 {
+		return 0, 0, errors.New("invalid employee id")
+	}
+	return id, req.EmployeeID, nil
+}
+
+func (api *API) handleListWatchedTasks(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	filter, err := parseTaskFilter(r, &opts)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	idStr := mux.Vars(r)["id"]
+	eid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if _, err := api.employeeService.GetEmployee(r.Context(), eid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	taskList, err := api.taskService.ListWatchedTasks(r.Context(), eid, filter, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, taskList)
+}
+
+func (api *API) handleListTaskEvents(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	idStr := mux.Vars(r)["id"]
+	eid, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		renderBadRequestErr(w, err)
+		return
+	}
+	if _, err := api.employeeService.GetEmployee(r.Context(), eid); err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	events, err := api.taskService.ListTaskEvents(r.Context(), eid, opts)
+	if err != nil {
+		renderSvcError(w, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, events)
+}
@@ -10,10 +10,23 @@ import (
 	"github.com/google/wire"
 
 	"github.com/liuerfire/boxpractice/internal/services"
+	"github.com/liuerfire/boxpractice/pkg/blob"
 	"github.com/liuerfire/boxpractice/pkg/store"
 )
 
-func InitAPIHandler(ctx context.Context, logger logr.Logger, sqlStore *store.SQLStore) (*API, error) {
-	wire.Build(ProvideAPI, services.ProvideHospitalService, services.ProvideEmployeeService, services.ProvideTaskService)
+func InitAPIHandler(ctx context.Context, logger logr.Logger, s store.Store, blobs blob.Store, admins Admins) (*API, error) {
+	wire.Build(
+		ProvideAPI,
+		services.ProvideHospitalService,
+		services.ProvideEmployeeService,
+		services.ProvideTaskService,
+		services.ProvideCommentService,
+		services.ProvideAttachmentService,
+		services.ProvideLabelService,
+		services.ProvideRecurrenceService,
+		services.ProvideSLAService,
+		services.ProvideShiftService,
+		services.ProvideTemplateService,
+	)
 	return &API{}, nil
 }
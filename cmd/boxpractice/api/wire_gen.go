@@ -12,15 +12,23 @@ import (
 	"github.com/go-logr/logr"
 
 	"github.com/liuerfire/boxpractice/internal/services"
+	"github.com/liuerfire/boxpractice/pkg/blob"
 	"github.com/liuerfire/boxpractice/pkg/store"
 )
 
 // Injectors from wire.go:
 
-func InitAPIHandler(ctx context.Context, logger logr.Logger, sqlStore *store.SQLStore) (*API, error) {
-	hospitalService := services.ProvideHospitalService(logger, sqlStore)
-	employeeService := services.ProvideEmployeeService(logger, sqlStore)
-	taskService := services.ProvideTaskService(logger, sqlStore)
-	api := ProvideAPI(logger, hospitalService, employeeService, taskService)
+func InitAPIHandler(ctx context.Context, logger logr.Logger, s store.Store, blobs blob.Store, admins Admins) (*API, error) {
+	hospitalService := services.ProvideHospitalService(logger, s)
+	employeeService := services.ProvideEmployeeService(logger, s)
+	taskService := services.ProvideTaskService(logger, s)
+	commentService := services.ProvideCommentService(logger, s)
+	attachmentService := services.ProvideAttachmentService(logger, s, blobs)
+	labelService := services.ProvideLabelService(logger, s)
+	recurrenceService := services.ProvideRecurrenceService(logger, s)
+	slaService := services.ProvideSLAService(logger, s)
+	shiftService := services.ProvideShiftService(logger, s)
+	templateService := services.ProvideTemplateService(logger, s)
+	api := ProvideAPI(logger, hospitalService, employeeService, taskService, commentService, attachmentService, labelService, recurrenceService, slaService, shiftService, templateService, admins)
 	return api, nil
 }
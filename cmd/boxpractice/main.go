@@ -3,15 +3,22 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 
 	apiPkg "github.com/liuerfire/boxpractice/cmd/boxpractice/api"
+	"github.com/liuerfire/boxpractice/database/migrations"
+	"github.com/liuerfire/boxpractice/internal/services"
+	"github.com/liuerfire/boxpractice/pkg/blob"
 	"github.com/liuerfire/boxpractice/pkg/httphandlers"
 	"github.com/liuerfire/boxpractice/pkg/log"
 	"github.com/liuerfire/boxpractice/pkg/store"
@@ -20,6 +27,16 @@ import (
 var (
 	addr      = flag.String("addr", ":8080", "The addr to listen")
 	verbosity = flag.Int("v", 0, "Number for the log level verbosity")
+
+	migrateOnStart = flag.Bool("migrate-on-start", false, "Apply the pending database migrations before starting the server")
+
+	attachmentsDir = flag.String("attachments-dir", "attachments", "The directory where the attachments of the tasks are stored")
+
+	admins = flag.String("admins", "", "The comma-separated ids of the employees allowed to list the soft-deleted items")
+
+	overdueSweepInterval = flag.Duration("overdue-sweep-interval", time.Minute, "How often to flag the overdue tasks")
+	scheduleInterval     = flag.Duration("schedule-interval", time.Minute, "How often to create the tasks of the due recurrences")
+	slaInterval          = flag.Duration("sla-interval", time.Minute, "How often to escalate the tasks breaching their SLA")
 )
 
 func main() {
@@ -37,12 +54,45 @@ func main() {
 
 	ctx := context.Background()
 
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(ctx, sqlStore, flag.Args()[1:]); err != nil {
+			setupLogger.Error(err, "failed to migrate")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *migrateOnStart {
+		migrator, err := store.NewMigrator(sqlStore, migrations.FS)
+		if err != nil {
+			setupLogger.Error(err, "failed to load migrations")
+			os.Exit(1)
+		}
+		setupLogger.Info("apply migrations")
+		if err := migrator.Up(ctx); err != nil {
+			setupLogger.Error(err, "failed to migrate")
+			os.Exit(1)
+		}
+	}
+
 	router := mux.NewRouter()
 	router.HandleFunc("/-/health", func(w http.ResponseWriter, _ *http.Request) {
 		w.Write([]byte("OK"))
 	})
 
-	api, err := apiPkg.InitAPIHandler(ctx, logger, sqlStore)
+	blobStore, err := blob.NewFSStore(*attachmentsDir)
+	if err != nil {
+		setupLogger.Error(err, "failed to open the attachments dir")
+		os.Exit(1)
+	}
+
+	adminIDs, err := parseAdmins(*admins)
+	if err != nil {
+		setupLogger.Error(err, "invalid admins")
+		os.Exit(1)
+	}
+
+	api, err := apiPkg.InitAPIHandler(ctx, logger, sqlStore, blobStore, adminIDs)
 	if err != nil {
 		setupLogger.Error(err, "failed to connect")
 		os.Exit(1)
@@ -62,12 +112,34 @@ func main() {
 
 	go func() {
 		setupLogger.Info("start server")
-		if err := server.ListenAndServe(); err != nil {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			setupLogger.Error(err, "failed to start server")
 			os.Exit(1)
 		}
 	}()
 
+	// The background workers run until workerCtx is cancelled at shutdown.
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	sweeper := services.ProvideOverdueSweeper(logger, sqlStore)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		sweeper.Run(workerCtx, *overdueSweepInterval)
+	}()
+	scheduler := services.ProvideTaskScheduler(logger, sqlStore)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		scheduler.Run(workerCtx, *scheduleInterval)
+	}()
+	evaluator := services.ProvideSLAEvaluator(logger, sqlStore)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		evaluator.Run(workerCtx, *slaInterval)
+	}()
+
 	stopCh := make(chan os.Signal, 1)
 	signal.Notify(stopCh, os.Interrupt, syscall.SIGTERM)
 	<-stopCh
@@ -75,4 +147,22 @@ func main() {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 	server.Shutdown(ctx)
+	stopWorkers()
+	workers.Wait()
+}
+
+// parseAdmins parses the comma-separated ids of the admins.
+func parseAdmins(s string) (apiPkg.Admins, error) {
+	var admins apiPkg.Admins
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid admin id: %s", v)
+		}
+		admins = append(admins, id)
+	}
+	return admins, nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/liuerfire/boxpractice/database/migrations"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+const migrateUsage = `usage: boxpractice migrate <command>
+
+commands:
+  up               apply all the pending migrations
+  down [N|-all]    roll back the last N migrations (default 1), or all of them
+  status           print the current schema version and the pending migrations
+  force VERSION    set the schema version and clear the dirty flag`
+
+var errMigrateUsage = errors.New("invalid migrate command")
+
+func runMigrate(ctx context.Context, sqlStore *store.SQLStore, args []string) error {
+	migrator, err := store.NewMigrator(sqlStore, migrations.FS)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return migrateUsageErr()
+	}
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if args[1] == "-all" {
+				n = 0
+			} else if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations: %s", args[1])
+			}
+		}
+		return migrator.Down(ctx, n)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d (dirty: %t)\n", status.Version, status.Dirty)
+		for _, m := range status.Applied {
+			fmt.Printf("  applied  %06d_%s\n", m.Version, m.Name)
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("  pending  %06d_%s\n", m.Version, m.Name)
+		}
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return migrateUsageErr()
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		return migrator.Force(ctx, uint(version))
+	}
+	return migrateUsageErr()
+}
+
+func migrateUsageErr() error {
+	fmt.Fprintln(os.Stderr, migrateUsage)
+	return errMigrateUsage
+}
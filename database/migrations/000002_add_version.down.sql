@@ -0,0 +1,2 @@
+ALTER TABLE `hospital` DROP COLUMN `version`;
+ALTER TABLE `task` DROP COLUMN `version`;
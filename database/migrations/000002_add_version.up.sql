@@ -0,0 +1,2 @@
+ALTER TABLE `hospital` ADD COLUMN `version` bigint NOT NULL DEFAULT 1 COMMENT 'Bumped on every update, exposed as the ETag' AFTER `display_name`;
+ALTER TABLE `task` ADD COLUMN `version` bigint NOT NULL DEFAULT 1 COMMENT 'Bumped on every update, exposed as the ETag' AFTER `status`;
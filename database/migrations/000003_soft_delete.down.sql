@@ -0,0 +1,3 @@
+ALTER TABLE `hospital` DROP COLUMN `deleted_at`;
+ALTER TABLE `employee` DROP COLUMN `deleted_at`;
+ALTER TABLE `task` DROP COLUMN `deleted_at`;
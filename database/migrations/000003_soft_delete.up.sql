@@ -0,0 +1,3 @@
+ALTER TABLE `hospital` ADD COLUMN `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the hospital is soft-deleted' AFTER `updated_at`;
+ALTER TABLE `employee` ADD COLUMN `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the employee is soft-deleted' AFTER `updated_at`;
+ALTER TABLE `task` ADD COLUMN `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the task is soft-deleted' AFTER `updated_at`;
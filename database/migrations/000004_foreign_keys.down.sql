@@ -0,0 +1,5 @@
+ALTER TABLE `task` DROP FOREIGN KEY `fk_task_owner`;
+ALTER TABLE `task` DROP FOREIGN KEY `fk_task_hospital`;
+ALTER TABLE `employee` DROP FOREIGN KEY `fk_employee_hospital`;
+-- This fails while some tasks have no owner.
+ALTER TABLE `task` MODIFY `owner_id` bigint NOT NULL;
@@ -0,0 +1,15 @@
+-- Clean up the rows the foreign keys would reject first, without losing
+-- any. The employees and tasks of a hospital which doesn't exist anymore are
+-- soft-deleted, and the hospital is recreated soft-deleted under its former
+-- id, named deleted-<id>, so that an admin can still restore them. The tasks
+-- whose owner doesn't exist anymore are kept without an owner.
+ALTER TABLE `task` MODIFY `owner_id` bigint DEFAULT NULL COMMENT 'The owner of the task, NULL if it has none';
+UPDATE `employee` SET `deleted_at` = COALESCE(`deleted_at`, CURRENT_TIMESTAMP) WHERE `hospital_id` NOT IN (SELECT `id` FROM `hospital`);
+UPDATE `task` SET `deleted_at` = COALESCE(`deleted_at`, CURRENT_TIMESTAMP) WHERE `hospital_id` NOT IN (SELECT `id` FROM `hospital`);
+INSERT INTO `hospital` (`id`, `name`, `deleted_at`)
+  SELECT `hospital_id`, CONCAT('deleted-', `hospital_id`), CURRENT_TIMESTAMP FROM (SELECT `hospital_id` FROM `employee` UNION SELECT `hospital_id` FROM `task`) AS `orphan`
+  WHERE `hospital_id` NOT IN (SELECT `id` FROM `hospital`);
+UPDATE `task` SET `owner_id` = NULL WHERE `owner_id` NOT IN (SELECT `id` FROM `employee`);
+ALTER TABLE `employee` ADD CONSTRAINT `fk_employee_hospital` FOREIGN KEY (`hospital_id`) REFERENCES `hospital` (`id`);
+ALTER TABLE `task` ADD CONSTRAINT `fk_task_hospital` FOREIGN KEY (`hospital_id`) REFERENCES `hospital` (`id`);
+ALTER TABLE `task` ADD CONSTRAINT `fk_task_owner` FOREIGN KEY (`owner_id`) REFERENCES `employee` (`id`);
@@ -0,0 +1,10 @@
+CREATE TABLE `task_transition` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `task_id` bigint NOT NULL,
+  `from_status` varchar(50) NOT NULL DEFAULT '' COMMENT 'The status before the transition, empty when the task was created',
+  `to_status` varchar(50) NOT NULL COMMENT 'The status after the transition',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_tid` (`task_id`),
+  CONSTRAINT `fk_task_transition_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
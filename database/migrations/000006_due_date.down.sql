@@ -0,0 +1,3 @@
+ALTER TABLE `task` DROP KEY `idx_hid_due`;
+ALTER TABLE `task` DROP COLUMN `overdue`;
+ALTER TABLE `task` DROP COLUMN `due_at`;
@@ -0,0 +1,3 @@
+ALTER TABLE `task` ADD COLUMN `due_at` timestamp NULL DEFAULT NULL COMMENT 'When the task has to be closed by' AFTER `status`;
+ALTER TABLE `task` ADD COLUMN `overdue` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'Set by the sweeper while the task is open past its due date' AFTER `due_at`;
+ALTER TABLE `task` ADD KEY `idx_hid_due` (`hospital_id`, `due_at`);
@@ -0,0 +1,14 @@
+CREATE TABLE `task_comment` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `task_id` bigint NOT NULL,
+  `author_id` bigint NOT NULL COMMENT 'The employee who wrote the comment',
+  `body` varchar(2000) NOT NULL COMMENT 'The comment text',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the comment is soft-deleted',
+  PRIMARY KEY (`id`),
+  KEY `idx_tid` (`task_id`),
+  KEY `idx_aid` (`author_id`),
+  CONSTRAINT `fk_task_comment_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`),
+  CONSTRAINT `fk_task_comment_author` FOREIGN KEY (`author_id`) REFERENCES `employee` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+CREATE TABLE `task_change` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `task_id` bigint NOT NULL,
+  `field` varchar(50) NOT NULL COMMENT 'The changed field of the task',
+  `old_value` varchar(500) NOT NULL DEFAULT '' COMMENT 'The value before the change, empty when the task was created',
+  `new_value` varchar(500) NOT NULL DEFAULT '' COMMENT 'The value after the change',
+  `actor_id` bigint NULL DEFAULT NULL COMMENT 'The employee who made the change, if known',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_tid` (`task_id`),
+  CONSTRAINT `fk_task_change_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`),
+  CONSTRAINT `fk_task_change_actor` FOREIGN KEY (`actor_id`) REFERENCES `employee` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
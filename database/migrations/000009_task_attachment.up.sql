@@ -0,0 +1,17 @@
+CREATE TABLE `task_attachment` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `task_id` bigint NOT NULL,
+  `uploader_id` bigint DEFAULT NULL COMMENT 'The employee who uploaded the file',
+  `filename` varchar(255) NOT NULL COMMENT 'The name of the uploaded file',
+  `content_type` varchar(100) NOT NULL,
+  `size` bigint NOT NULL COMMENT 'The size of the file in bytes',
+  `blob_key` varchar(255) NOT NULL COMMENT 'The key of the content in the blob store',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the attachment is soft-deleted',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_blob_key` (`blob_key`),
+  KEY `idx_tid` (`task_id`),
+  CONSTRAINT `fk_task_attachment_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`),
+  CONSTRAINT `fk_task_attachment_uploader` FOREIGN KEY (`uploader_id`) REFERENCES `employee` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
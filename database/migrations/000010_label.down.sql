@@ -0,0 +1,2 @@
+DROP TABLE `task_label`;
+DROP TABLE `label`;
@@ -0,0 +1,20 @@
+CREATE TABLE `label` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `hospital_id` bigint NOT NULL,
+  `name` varchar(64) NOT NULL COMMENT 'The name of the label, unique in the hospital',
+  `color` char(7) NOT NULL COMMENT 'The colour of the label, as #rrggbb',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_hid_name` (`hospital_id`, `name`),
+  CONSTRAINT `fk_label_hospital` FOREIGN KEY (`hospital_id`) REFERENCES `hospital` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE `task_label` (
+  `task_id` bigint NOT NULL,
+  `label_id` bigint NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`task_id`, `label_id`),
+  KEY `idx_lid` (`label_id`),
+  CONSTRAINT `fk_task_label_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`),
+  CONSTRAINT `fk_task_label_label` FOREIGN KEY (`label_id`) REFERENCES `label` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
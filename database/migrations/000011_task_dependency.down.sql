@@ -0,0 +1,4 @@
+DROP TABLE `task_dependency`;
+ALTER TABLE `task` DROP FOREIGN KEY `fk_task_parent`;
+ALTER TABLE `task` DROP KEY `idx_pid`;
+ALTER TABLE `task` DROP COLUMN `parent_id`;
@@ -0,0 +1,12 @@
+ALTER TABLE `task` ADD COLUMN `parent_id` bigint DEFAULT NULL COMMENT 'The task this one is a subtask of' AFTER `owner_id`;
+ALTER TABLE `task` ADD KEY `idx_pid` (`parent_id`);
+ALTER TABLE `task` ADD CONSTRAINT `fk_task_parent` FOREIGN KEY (`parent_id`) REFERENCES `task` (`id`);
+CREATE TABLE `task_dependency` (
+  `task_id` bigint NOT NULL COMMENT 'The blocked task',
+  `blocker_id` bigint NOT NULL COMMENT 'The task which has to be closed first',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`task_id`, `blocker_id`),
+  KEY `idx_bid` (`blocker_id`),
+  CONSTRAINT `fk_task_dependency_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`),
+  CONSTRAINT `fk_task_dependency_blocker` FOREIGN KEY (`blocker_id`) REFERENCES `task` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
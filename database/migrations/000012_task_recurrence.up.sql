@@ -0,0 +1,33 @@
+ALTER TABLE `hospital` ADD COLUMN `timezone` varchar(64) NOT NULL DEFAULT 'UTC' COMMENT 'The IANA time zone the schedules of the hospital are in' AFTER `display_name`;
+CREATE TABLE `task_recurrence` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `hospital_id` bigint NOT NULL,
+  `title` varchar(100) NOT NULL COMMENT 'The title of the created tasks',
+  `description` varchar(500) NOT NULL COMMENT 'The description of the created tasks',
+  `priority` varchar(50) NOT NULL COMMENT 'The priority of the created tasks',
+  `due_in` bigint NOT NULL DEFAULT 0 COMMENT 'Seconds from an occurrence to the due date of its task, 0 for none',
+  `schedule` varchar(128) NOT NULL COMMENT 'The cron expression of the occurrences, in the time zone of the hospital',
+  `next_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'The next occurrence to create a task for',
+  `occurrences` bigint NOT NULL DEFAULT 0 COMMENT 'The number of tasks created so far, which picks the owner in the rotation',
+  `version` bigint NOT NULL DEFAULT 1 COMMENT 'Bumped on every update, exposed as the ETag',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the recurrence is soft-deleted',
+  PRIMARY KEY (`id`),
+  KEY `idx_hid` (`hospital_id`),
+  KEY `idx_next_at` (`next_at`),
+  CONSTRAINT `fk_task_recurrence_hospital` FOREIGN KEY (`hospital_id`) REFERENCES `hospital` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE `task_recurrence_owner` (
+  `recurrence_id` bigint NOT NULL,
+  `position` int NOT NULL COMMENT 'The rank of the employee in the rotation',
+  `employee_id` bigint NOT NULL,
+  PRIMARY KEY (`recurrence_id`, `position`),
+  KEY `idx_eid` (`employee_id`),
+  CONSTRAINT `fk_task_recurrence_owner_recurrence` FOREIGN KEY (`recurrence_id`) REFERENCES `task_recurrence` (`id`),
+  CONSTRAINT `fk_task_recurrence_owner_employee` FOREIGN KEY (`employee_id`) REFERENCES `employee` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+ALTER TABLE `task` ADD COLUMN `recurrence_id` bigint DEFAULT NULL COMMENT 'The recurrence which created the task' AFTER `parent_id`;
+ALTER TABLE `task` ADD COLUMN `occurrence_at` timestamp NULL DEFAULT NULL COMMENT 'The occurrence of the recurrence the task was created for' AFTER `recurrence_id`;
+ALTER TABLE `task` ADD UNIQUE KEY `uniq_rid_occurrence` (`recurrence_id`, `occurrence_at`);
+ALTER TABLE `task` ADD CONSTRAINT `fk_task_recurrence` FOREIGN KEY (`recurrence_id`) REFERENCES `task_recurrence` (`id`);
@@ -0,0 +1 @@
+ALTER TABLE `task` MODIFY `owner_id` bigint DEFAULT NULL COMMENT 'The owner of the task, NULL if it has none';
@@ -0,0 +1 @@
+ALTER TABLE `task` MODIFY `owner_id` bigint DEFAULT NULL COMMENT 'The owner of the task, NULL while it is in the pool of its hospital';
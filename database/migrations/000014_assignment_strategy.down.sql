@@ -0,0 +1,4 @@
+ALTER TABLE `hospital` DROP FOREIGN KEY `fk_hospital_last_assignee`;
+ALTER TABLE `hospital` DROP COLUMN `last_assignee_id`;
+ALTER TABLE `hospital` DROP COLUMN `auto_assign`;
+ALTER TABLE `hospital` DROP COLUMN `assignment_strategy`;
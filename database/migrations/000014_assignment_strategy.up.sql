@@ -0,0 +1,4 @@
+ALTER TABLE `hospital` ADD COLUMN `assignment_strategy` varchar(32) NOT NULL DEFAULT 'roundRobin' COMMENT 'The strategy picking the owners of the tasks assigned automatically' AFTER `timezone`;
+ALTER TABLE `hospital` ADD COLUMN `auto_assign` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'Whether the tasks created without owner are assigned automatically rather than pooled' AFTER `assignment_strategy`;
+ALTER TABLE `hospital` ADD COLUMN `last_assignee_id` bigint DEFAULT NULL COMMENT 'The employee last picked by the round-robin strategy' AFTER `auto_assign`;
+ALTER TABLE `hospital` ADD CONSTRAINT `fk_hospital_last_assignee` FOREIGN KEY (`last_assignee_id`) REFERENCES `employee` (`id`);
@@ -0,0 +1,4 @@
+ALTER TABLE `task` DROP COLUMN `breached`;
+DROP TABLE `task_escalation`;
+DROP TABLE `task_sla_chain`;
+DROP TABLE `task_sla`;
@@ -0,0 +1,34 @@
+CREATE TABLE `task_sla` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `hospital_id` bigint NOT NULL,
+  `priority` varchar(50) NOT NULL COMMENT 'The priority of the tasks the SLA applies to',
+  `respond_within` bigint NOT NULL COMMENT 'Seconds a task may stay OPEN since it was last moved or escalated',
+  `escalation` varchar(32) NOT NULL COMMENT 'What is done to the tasks breaching the SLA: none, bumpPriority or reassign',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uniq_hid_priority` (`hospital_id`, `priority`),
+  CONSTRAINT `fk_task_sla_hospital` FOREIGN KEY (`hospital_id`) REFERENCES `hospital` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE `task_sla_chain` (
+  `sla_id` bigint NOT NULL,
+  `position` int NOT NULL COMMENT 'The rank of the employee in the escalation chain',
+  `employee_id` bigint NOT NULL,
+  PRIMARY KEY (`sla_id`, `position`),
+  KEY `idx_eid` (`employee_id`),
+  CONSTRAINT `fk_task_sla_chain_sla` FOREIGN KEY (`sla_id`) REFERENCES `task_sla` (`id`),
+  CONSTRAINT `fk_task_sla_chain_employee` FOREIGN KEY (`employee_id`) REFERENCES `employee` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE `task_escalation` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `task_id` bigint NOT NULL,
+  `level` bigint NOT NULL COMMENT 'The number of times the task was escalated, this one included',
+  `action` varchar(32) NOT NULL COMMENT 'What was done to the task: none, bumpPriority or reassign',
+  `old_value` varchar(64) NOT NULL COMMENT 'The priority or the owner before the escalation',
+  `new_value` varchar(64) NOT NULL COMMENT 'The priority or the owner after the escalation',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_tid_created_at` (`task_id`, `created_at`),
+  CONSTRAINT `fk_task_escalation_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+ALTER TABLE `task` ADD COLUMN `breached` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'Set by the SLA evaluator while the task is OPEN past its SLA' AFTER `overdue`;
@@ -0,0 +1,19 @@
+CREATE TABLE `task_watcher` (
+  `task_id` bigint NOT NULL,
+  `employee_id` bigint NOT NULL COMMENT 'The employee following the task',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`task_id`, `employee_id`),
+  KEY `idx_eid` (`employee_id`),
+  CONSTRAINT `fk_task_watcher_task` FOREIGN KEY (`task_id`) REFERENCES `task` (`id`),
+  CONSTRAINT `fk_task_watcher_employee` FOREIGN KEY (`employee_id`) REFERENCES `employee` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE `task_event` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `employee_id` bigint NOT NULL COMMENT 'The owner or watcher the change is sent to',
+  `change_id` bigint NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_eid` (`employee_id`),
+  CONSTRAINT `fk_task_event_employee` FOREIGN KEY (`employee_id`) REFERENCES `employee` (`id`),
+  CONSTRAINT `fk_task_event_change` FOREIGN KEY (`change_id`) REFERENCES `task_change` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
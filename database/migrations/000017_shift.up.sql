@@ -0,0 +1,18 @@
+ALTER TABLE `hospital` ADD COLUMN `off_shift_assignment` varchar(16) NOT NULL DEFAULT 'allow' COMMENT 'What is done to the assignments to employees off shift: allow, warn or reject' AFTER `last_assignee_id`;
+CREATE TABLE `shift` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `hospital_id` bigint NOT NULL,
+  `employee_id` bigint NOT NULL COMMENT 'The employee on duty',
+  `starts_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'The start of the shift, included',
+  `ends_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'The end of the shift, excluded',
+  `ward` varchar(100) NOT NULL DEFAULT '' COMMENT 'The ward the employee works in during the shift',
+  `version` bigint NOT NULL DEFAULT 1 COMMENT 'Bumped on every update, exposed as the ETag',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the shift is soft-deleted',
+  PRIMARY KEY (`id`),
+  KEY `idx_hid_starts_at` (`hospital_id`, `starts_at`),
+  KEY `idx_eid_starts_at` (`employee_id`, `starts_at`),
+  CONSTRAINT `fk_shift_hospital` FOREIGN KEY (`hospital_id`) REFERENCES `hospital` (`id`),
+  CONSTRAINT `fk_shift_employee` FOREIGN KEY (`employee_id`) REFERENCES `employee` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,29 @@
+CREATE TABLE `task_template` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'The primary key',
+  `hospital_id` bigint NOT NULL,
+  `title` varchar(100) NOT NULL COMMENT 'The title of the created tasks',
+  `description` varchar(500) NOT NULL COMMENT 'The description of the created tasks',
+  `priority` varchar(50) NOT NULL COMMENT 'The priority of the created tasks',
+  `version` bigint NOT NULL DEFAULT 1 COMMENT 'Bumped on every update, exposed as the ETag',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` timestamp NULL DEFAULT NULL COMMENT 'Set when the template is soft-deleted',
+  PRIMARY KEY (`id`),
+  KEY `idx_hid` (`hospital_id`),
+  CONSTRAINT `fk_task_template_hospital` FOREIGN KEY (`hospital_id`) REFERENCES `hospital` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE `task_template_label` (
+  `template_id` bigint NOT NULL,
+  `label_id` bigint NOT NULL,
+  PRIMARY KEY (`template_id`, `label_id`),
+  KEY `idx_lid` (`label_id`),
+  CONSTRAINT `fk_task_template_label_template` FOREIGN KEY (`template_id`) REFERENCES `task_template` (`id`),
+  CONSTRAINT `fk_task_template_label_label` FOREIGN KEY (`label_id`) REFERENCES `label` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+CREATE TABLE `task_template_item` (
+  `template_id` bigint NOT NULL,
+  `position` int NOT NULL COMMENT 'The rank of the item in the checklist',
+  `title` varchar(100) NOT NULL COMMENT 'The title of the subtask created for the item',
+  PRIMARY KEY (`template_id`, `position`),
+  CONSTRAINT `fk_task_template_item_template` FOREIGN KEY (`template_id`) REFERENCES `task_template` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations so that the binary can apply
+// them without any external tool. The MySQL migrations live at the top level,
+// the other dialects have their own sub-directory.
+package migrations
+
+import "embed"
+
+//go:embed *.sql sqlite/*.sql postgres/*.sql
+var FS embed.FS
@@ -0,0 +1,3 @@
+DROP TABLE hospital;
+DROP TABLE employee;
+DROP TABLE task;
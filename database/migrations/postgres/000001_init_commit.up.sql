@@ -0,0 +1,40 @@
+CREATE TABLE hospital (
+  id bigserial PRIMARY KEY,
+  name varchar(200) NOT NULL,
+  display_name varchar(200) NOT NULL DEFAULT '',
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT hospital_uidx_name UNIQUE (name)
+);
+COMMENT ON COLUMN hospital.name IS 'The hospital name';
+COMMENT ON COLUMN hospital.display_name IS 'The display name';
+
+CREATE TABLE employee (
+  id bigserial PRIMARY KEY,
+  hospital_id bigint NOT NULL,
+  username varchar(50) NOT NULL,
+  first_name varchar(100) NOT NULL DEFAULT '',
+  last_name varchar(100) NOT NULL DEFAULT '',
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT employee_uidx_name UNIQUE (username)
+);
+CREATE INDEX employee_idx_hid ON employee (hospital_id);
+
+CREATE TABLE task (
+  id bigserial PRIMARY KEY,
+  hospital_id bigint NOT NULL,
+  owner_id bigint NOT NULL,
+  title varchar(100) NOT NULL,
+  description varchar(500) NOT NULL,
+  priority varchar(50) NOT NULL,
+  status varchar(50) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_idx_hid ON task (hospital_id);
+CREATE INDEX task_idx_oid ON task (owner_id);
+COMMENT ON COLUMN task.title IS 'The task title';
+COMMENT ON COLUMN task.description IS 'The task description';
+COMMENT ON COLUMN task.priority IS 'The task priority. Could be one of urgent, hight, low';
+COMMENT ON COLUMN task.status IS 'The task status. Could be one of open, failed, completed';
@@ -0,0 +1,6 @@
+ALTER TABLE hospital ADD COLUMN deleted_at timestamptz NULL;
+ALTER TABLE employee ADD COLUMN deleted_at timestamptz NULL;
+ALTER TABLE task ADD COLUMN deleted_at timestamptz NULL;
+COMMENT ON COLUMN hospital.deleted_at IS 'Set when the hospital is soft-deleted';
+COMMENT ON COLUMN employee.deleted_at IS 'Set when the employee is soft-deleted';
+COMMENT ON COLUMN task.deleted_at IS 'Set when the task is soft-deleted';
@@ -0,0 +1,6 @@
+ALTER TABLE task DROP CONSTRAINT fk_task_owner;
+ALTER TABLE task DROP CONSTRAINT fk_task_hospital;
+ALTER TABLE employee DROP CONSTRAINT fk_employee_hospital;
+COMMENT ON COLUMN task.owner_id IS NULL;
+-- This fails while some tasks have no owner.
+ALTER TABLE task ALTER COLUMN owner_id SET NOT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE task_transition (
+  id bigserial PRIMARY KEY,
+  task_id bigint NOT NULL,
+  from_status varchar(50) NOT NULL DEFAULT '',
+  to_status varchar(50) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT fk_task_transition_task FOREIGN KEY (task_id) REFERENCES task (id)
+);
+CREATE INDEX task_transition_idx_tid ON task_transition (task_id);
+COMMENT ON COLUMN task_transition.from_status IS 'The status before the transition, empty when the task was created';
+COMMENT ON COLUMN task_transition.to_status IS 'The status after the transition';
@@ -0,0 +1,5 @@
+ALTER TABLE task ADD COLUMN due_at timestamptz NULL;
+ALTER TABLE task ADD COLUMN overdue boolean NOT NULL DEFAULT false;
+CREATE INDEX task_idx_hid_due ON task (hospital_id, due_at);
+COMMENT ON COLUMN task.due_at IS 'When the task has to be closed by';
+COMMENT ON COLUMN task.overdue IS 'Set by the sweeper while the task is open past its due date';
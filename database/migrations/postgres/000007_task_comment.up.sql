@@ -0,0 +1,16 @@
+CREATE TABLE task_comment (
+  id bigserial PRIMARY KEY,
+  task_id bigint NOT NULL,
+  author_id bigint NOT NULL,
+  body varchar(2000) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamptz NULL,
+  CONSTRAINT fk_task_comment_task FOREIGN KEY (task_id) REFERENCES task (id),
+  CONSTRAINT fk_task_comment_author FOREIGN KEY (author_id) REFERENCES employee (id)
+);
+CREATE INDEX task_comment_idx_tid ON task_comment (task_id);
+CREATE INDEX task_comment_idx_aid ON task_comment (author_id);
+COMMENT ON COLUMN task_comment.author_id IS 'The employee who wrote the comment';
+COMMENT ON COLUMN task_comment.body IS 'The comment text';
+COMMENT ON COLUMN task_comment.deleted_at IS 'Set when the comment is soft-deleted';
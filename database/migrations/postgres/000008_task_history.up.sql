@@ -0,0 +1,16 @@
+CREATE TABLE task_change (
+  id bigserial PRIMARY KEY,
+  task_id bigint NOT NULL,
+  field varchar(50) NOT NULL,
+  old_value varchar(500) NOT NULL DEFAULT '',
+  new_value varchar(500) NOT NULL DEFAULT '',
+  actor_id bigint NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT fk_task_change_task FOREIGN KEY (task_id) REFERENCES task (id),
+  CONSTRAINT fk_task_change_actor FOREIGN KEY (actor_id) REFERENCES employee (id)
+);
+CREATE INDEX task_change_idx_tid ON task_change (task_id);
+COMMENT ON COLUMN task_change.field IS 'The changed field of the task';
+COMMENT ON COLUMN task_change.old_value IS 'The value before the change, empty when the task was created';
+COMMENT ON COLUMN task_change.new_value IS 'The value after the change';
+COMMENT ON COLUMN task_change.actor_id IS 'The employee who made the change, if known';
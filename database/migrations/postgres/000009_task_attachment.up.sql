@@ -0,0 +1,21 @@
+CREATE TABLE task_attachment (
+  id bigserial PRIMARY KEY,
+  task_id bigint NOT NULL,
+  uploader_id bigint NULL,
+  filename varchar(255) NOT NULL,
+  content_type varchar(100) NOT NULL,
+  size bigint NOT NULL,
+  blob_key varchar(255) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamptz NULL,
+  CONSTRAINT uniq_task_attachment_blob_key UNIQUE (blob_key),
+  CONSTRAINT fk_task_attachment_task FOREIGN KEY (task_id) REFERENCES task (id),
+  CONSTRAINT fk_task_attachment_uploader FOREIGN KEY (uploader_id) REFERENCES employee (id)
+);
+CREATE INDEX task_attachment_idx_tid ON task_attachment (task_id);
+COMMENT ON COLUMN task_attachment.uploader_id IS 'The employee who uploaded the file';
+COMMENT ON COLUMN task_attachment.filename IS 'The name of the uploaded file';
+COMMENT ON COLUMN task_attachment.size IS 'The size of the file in bytes';
+COMMENT ON COLUMN task_attachment.blob_key IS 'The key of the content in the blob store';
+COMMENT ON COLUMN task_attachment.deleted_at IS 'Set when the attachment is soft-deleted';
@@ -0,0 +1,21 @@
+CREATE TABLE label (
+  id bigserial PRIMARY KEY,
+  hospital_id bigint NOT NULL,
+  name varchar(64) NOT NULL,
+  color char(7) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uniq_label_hid_name UNIQUE (hospital_id, name),
+  CONSTRAINT fk_label_hospital FOREIGN KEY (hospital_id) REFERENCES hospital (id)
+);
+COMMENT ON COLUMN label.name IS 'The name of the label, unique in the hospital';
+COMMENT ON COLUMN label.color IS 'The colour of the label, as #rrggbb';
+CREATE TABLE task_label (
+  task_id bigint NOT NULL,
+  label_id bigint NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, label_id),
+  CONSTRAINT fk_task_label_task FOREIGN KEY (task_id) REFERENCES task (id),
+  CONSTRAINT fk_task_label_label FOREIGN KEY (label_id) REFERENCES label (id)
+);
+CREATE INDEX task_label_idx_lid ON task_label (label_id);
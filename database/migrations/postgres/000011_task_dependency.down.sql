@@ -0,0 +1,2 @@
+DROP TABLE task_dependency;
+ALTER TABLE task DROP COLUMN parent_id;
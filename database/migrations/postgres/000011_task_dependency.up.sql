@@ -0,0 +1,15 @@
+ALTER TABLE task ADD COLUMN parent_id bigint NULL;
+ALTER TABLE task ADD CONSTRAINT fk_task_parent FOREIGN KEY (parent_id) REFERENCES task (id);
+CREATE INDEX task_idx_pid ON task (parent_id);
+COMMENT ON COLUMN task.parent_id IS 'The task this one is a subtask of';
+CREATE TABLE task_dependency (
+  task_id bigint NOT NULL,
+  blocker_id bigint NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, blocker_id),
+  CONSTRAINT fk_task_dependency_task FOREIGN KEY (task_id) REFERENCES task (id),
+  CONSTRAINT fk_task_dependency_blocker FOREIGN KEY (blocker_id) REFERENCES task (id)
+);
+CREATE INDEX task_dependency_idx_bid ON task_dependency (blocker_id);
+COMMENT ON COLUMN task_dependency.task_id IS 'The blocked task';
+COMMENT ON COLUMN task_dependency.blocker_id IS 'The task which has to be closed first';
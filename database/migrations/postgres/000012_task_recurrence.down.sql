@@ -0,0 +1,5 @@
+ALTER TABLE task DROP COLUMN occurrence_at;
+ALTER TABLE task DROP COLUMN recurrence_id;
+DROP TABLE task_recurrence_owner;
+DROP TABLE task_recurrence;
+ALTER TABLE hospital DROP COLUMN timezone;
@@ -0,0 +1,44 @@
+ALTER TABLE hospital ADD COLUMN timezone varchar(64) NOT NULL DEFAULT 'UTC';
+COMMENT ON COLUMN hospital.timezone IS 'The IANA time zone the schedules of the hospital are in';
+CREATE TABLE task_recurrence (
+  id bigserial PRIMARY KEY,
+  hospital_id bigint NOT NULL,
+  title varchar(100) NOT NULL,
+  description varchar(500) NOT NULL,
+  priority varchar(50) NOT NULL,
+  due_in bigint NOT NULL DEFAULT 0,
+  schedule varchar(128) NOT NULL,
+  next_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  occurrences bigint NOT NULL DEFAULT 0,
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamptz NULL,
+  CONSTRAINT fk_task_recurrence_hospital FOREIGN KEY (hospital_id) REFERENCES hospital (id)
+);
+CREATE INDEX task_recurrence_idx_hid ON task_recurrence (hospital_id);
+CREATE INDEX task_recurrence_idx_next_at ON task_recurrence (next_at);
+COMMENT ON COLUMN task_recurrence.title IS 'The title of the created tasks';
+COMMENT ON COLUMN task_recurrence.description IS 'The description of the created tasks';
+COMMENT ON COLUMN task_recurrence.priority IS 'The priority of the created tasks';
+COMMENT ON COLUMN task_recurrence.due_in IS 'Seconds from an occurrence to the due date of its task, 0 for none';
+COMMENT ON COLUMN task_recurrence.schedule IS 'The cron expression of the occurrences, in the time zone of the hospital';
+COMMENT ON COLUMN task_recurrence.next_at IS 'The next occurrence to create a task for';
+COMMENT ON COLUMN task_recurrence.occurrences IS 'The number of tasks created so far, which picks the owner in the rotation';
+COMMENT ON COLUMN task_recurrence.deleted_at IS 'Set when the recurrence is soft-deleted';
+CREATE TABLE task_recurrence_owner (
+  recurrence_id bigint NOT NULL,
+  position int NOT NULL,
+  employee_id bigint NOT NULL,
+  PRIMARY KEY (recurrence_id, position),
+  CONSTRAINT fk_task_recurrence_owner_recurrence FOREIGN KEY (recurrence_id) REFERENCES task_recurrence (id),
+  CONSTRAINT fk_task_recurrence_owner_employee FOREIGN KEY (employee_id) REFERENCES employee (id)
+);
+CREATE INDEX task_recurrence_owner_idx_eid ON task_recurrence_owner (employee_id);
+COMMENT ON COLUMN task_recurrence_owner.position IS 'The rank of the employee in the rotation';
+ALTER TABLE task ADD COLUMN recurrence_id bigint NULL;
+ALTER TABLE task ADD COLUMN occurrence_at timestamptz NULL;
+ALTER TABLE task ADD CONSTRAINT fk_task_recurrence FOREIGN KEY (recurrence_id) REFERENCES task_recurrence (id);
+ALTER TABLE task ADD CONSTRAINT uniq_task_rid_occurrence UNIQUE (recurrence_id, occurrence_at);
+COMMENT ON COLUMN task.recurrence_id IS 'The recurrence which created the task';
+COMMENT ON COLUMN task.occurrence_at IS 'The occurrence of the recurrence the task was created for';
@@ -0,0 +1 @@
+COMMENT ON COLUMN task.owner_id IS 'The owner of the task, NULL if it has none';
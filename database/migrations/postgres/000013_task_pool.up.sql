@@ -0,0 +1 @@
+COMMENT ON COLUMN task.owner_id IS 'The owner of the task, NULL while it is in the pool of its hospital';
@@ -0,0 +1,3 @@
+ALTER TABLE hospital DROP COLUMN last_assignee_id;
+ALTER TABLE hospital DROP COLUMN auto_assign;
+ALTER TABLE hospital DROP COLUMN assignment_strategy;
@@ -0,0 +1,7 @@
+ALTER TABLE hospital ADD COLUMN assignment_strategy varchar(32) NOT NULL DEFAULT 'roundRobin';
+ALTER TABLE hospital ADD COLUMN auto_assign boolean NOT NULL DEFAULT false;
+ALTER TABLE hospital ADD COLUMN last_assignee_id bigint NULL;
+ALTER TABLE hospital ADD CONSTRAINT fk_hospital_last_assignee FOREIGN KEY (last_assignee_id) REFERENCES employee (id);
+COMMENT ON COLUMN hospital.assignment_strategy IS 'The strategy picking the owners of the tasks assigned automatically';
+COMMENT ON COLUMN hospital.auto_assign IS 'Whether the tasks created without owner are assigned automatically rather than pooled';
+COMMENT ON COLUMN hospital.last_assignee_id IS 'The employee last picked by the round-robin strategy';
@@ -0,0 +1,41 @@
+CREATE TABLE task_sla (
+  id bigserial PRIMARY KEY,
+  hospital_id bigint NOT NULL,
+  priority varchar(50) NOT NULL,
+  respond_within bigint NOT NULL,
+  escalation varchar(32) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uniq_task_sla_hid_priority UNIQUE (hospital_id, priority),
+  CONSTRAINT fk_task_sla_hospital FOREIGN KEY (hospital_id) REFERENCES hospital (id)
+);
+COMMENT ON COLUMN task_sla.priority IS 'The priority of the tasks the SLA applies to';
+COMMENT ON COLUMN task_sla.respond_within IS 'Seconds a task may stay OPEN since it was last moved or escalated';
+COMMENT ON COLUMN task_sla.escalation IS 'What is done to the tasks breaching the SLA: none, bumpPriority or reassign';
+CREATE TABLE task_sla_chain (
+  sla_id bigint NOT NULL,
+  position int NOT NULL,
+  employee_id bigint NOT NULL,
+  PRIMARY KEY (sla_id, position),
+  CONSTRAINT fk_task_sla_chain_sla FOREIGN KEY (sla_id) REFERENCES task_sla (id),
+  CONSTRAINT fk_task_sla_chain_employee FOREIGN KEY (employee_id) REFERENCES employee (id)
+);
+CREATE INDEX task_sla_chain_idx_eid ON task_sla_chain (employee_id);
+COMMENT ON COLUMN task_sla_chain.position IS 'The rank of the employee in the escalation chain';
+CREATE TABLE task_escalation (
+  id bigserial PRIMARY KEY,
+  task_id bigint NOT NULL,
+  level bigint NOT NULL,
+  action varchar(32) NOT NULL,
+  old_value varchar(64) NOT NULL,
+  new_value varchar(64) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT fk_task_escalation_task FOREIGN KEY (task_id) REFERENCES task (id)
+);
+CREATE INDEX task_escalation_idx_tid_created_at ON task_escalation (task_id, created_at);
+COMMENT ON COLUMN task_escalation.level IS 'The number of times the task was escalated, this one included';
+COMMENT ON COLUMN task_escalation.action IS 'What was done to the task: none, bumpPriority or reassign';
+COMMENT ON COLUMN task_escalation.old_value IS 'The priority or the owner before the escalation';
+COMMENT ON COLUMN task_escalation.new_value IS 'The priority or the owner after the escalation';
+ALTER TABLE task ADD COLUMN breached boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN task.breached IS 'Set by the SLA evaluator while the task is OPEN past its SLA';
@@ -0,0 +1,20 @@
+CREATE TABLE task_watcher (
+  task_id bigint NOT NULL,
+  employee_id bigint NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, employee_id),
+  CONSTRAINT fk_task_watcher_task FOREIGN KEY (task_id) REFERENCES task (id),
+  CONSTRAINT fk_task_watcher_employee FOREIGN KEY (employee_id) REFERENCES employee (id)
+);
+CREATE INDEX task_watcher_idx_eid ON task_watcher (employee_id);
+COMMENT ON COLUMN task_watcher.employee_id IS 'The employee following the task';
+CREATE TABLE task_event (
+  id bigserial PRIMARY KEY,
+  employee_id bigint NOT NULL,
+  change_id bigint NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT fk_task_event_employee FOREIGN KEY (employee_id) REFERENCES employee (id),
+  CONSTRAINT fk_task_event_change FOREIGN KEY (change_id) REFERENCES task_change (id)
+);
+CREATE INDEX task_event_idx_eid ON task_event (employee_id);
+COMMENT ON COLUMN task_event.employee_id IS 'The owner or watcher the change is sent to';
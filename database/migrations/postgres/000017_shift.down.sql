@@ -0,0 +1,2 @@
+DROP TABLE shift;
+ALTER TABLE hospital DROP COLUMN off_shift_assignment;
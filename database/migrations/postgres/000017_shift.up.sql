@@ -0,0 +1,23 @@
+ALTER TABLE hospital ADD COLUMN off_shift_assignment varchar(16) NOT NULL DEFAULT 'allow';
+COMMENT ON COLUMN hospital.off_shift_assignment IS 'What is done to the assignments to employees off shift: allow, warn or reject';
+CREATE TABLE shift (
+  id bigserial PRIMARY KEY,
+  hospital_id bigint NOT NULL,
+  employee_id bigint NOT NULL,
+  starts_at timestamptz NOT NULL,
+  ends_at timestamptz NOT NULL,
+  ward varchar(100) NOT NULL DEFAULT '',
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamptz NULL,
+  CONSTRAINT fk_shift_hospital FOREIGN KEY (hospital_id) REFERENCES hospital (id),
+  CONSTRAINT fk_shift_employee FOREIGN KEY (employee_id) REFERENCES employee (id)
+);
+CREATE INDEX shift_idx_hid_starts_at ON shift (hospital_id, starts_at);
+CREATE INDEX shift_idx_eid_starts_at ON shift (employee_id, starts_at);
+COMMENT ON COLUMN shift.employee_id IS 'The employee on duty';
+COMMENT ON COLUMN shift.starts_at IS 'The start of the shift, included';
+COMMENT ON COLUMN shift.ends_at IS 'The end of the shift, excluded';
+COMMENT ON COLUMN shift.ward IS 'The ward the employee works in during the shift';
+COMMENT ON COLUMN shift.deleted_at IS 'Set when the shift is soft-deleted';
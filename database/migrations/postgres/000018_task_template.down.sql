@@ -0,0 +1,3 @@
+DROP TABLE task_template_item;
+DROP TABLE task_template_label;
+DROP TABLE task_template;
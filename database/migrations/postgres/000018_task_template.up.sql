@@ -0,0 +1,34 @@
+CREATE TABLE task_template (
+  id bigserial PRIMARY KEY,
+  hospital_id bigint NOT NULL,
+  title varchar(100) NOT NULL,
+  description varchar(500) NOT NULL,
+  priority varchar(50) NOT NULL,
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamptz NULL,
+  CONSTRAINT fk_task_template_hospital FOREIGN KEY (hospital_id) REFERENCES hospital (id)
+);
+CREATE INDEX task_template_idx_hid ON task_template (hospital_id);
+COMMENT ON COLUMN task_template.title IS 'The title of the created tasks';
+COMMENT ON COLUMN task_template.description IS 'The description of the created tasks';
+COMMENT ON COLUMN task_template.priority IS 'The priority of the created tasks';
+COMMENT ON COLUMN task_template.deleted_at IS 'Set when the template is soft-deleted';
+CREATE TABLE task_template_label (
+  template_id bigint NOT NULL,
+  label_id bigint NOT NULL,
+  PRIMARY KEY (template_id, label_id),
+  CONSTRAINT fk_task_template_label_template FOREIGN KEY (template_id) REFERENCES task_template (id),
+  CONSTRAINT fk_task_template_label_label FOREIGN KEY (label_id) REFERENCES label (id)
+);
+CREATE INDEX task_template_label_idx_lid ON task_template_label (label_id);
+CREATE TABLE task_template_item (
+  template_id bigint NOT NULL,
+  position int NOT NULL,
+  title varchar(100) NOT NULL,
+  PRIMARY KEY (template_id, position),
+  CONSTRAINT fk_task_template_item_template FOREIGN KEY (template_id) REFERENCES task_template (id)
+);
+COMMENT ON COLUMN task_template_item.position IS 'The rank of the item in the checklist';
+COMMENT ON COLUMN task_template_item.title IS 'The title of the subtask created for the item';
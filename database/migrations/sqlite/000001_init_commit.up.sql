@@ -0,0 +1,34 @@
+CREATE TABLE hospital (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name varchar(200) NOT NULL, -- The hospital name
+  display_name varchar(200) NOT NULL DEFAULT '', -- The display name
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX hospital_uidx_name ON hospital (name);
+
+CREATE TABLE employee (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL,
+  username varchar(50) NOT NULL,
+  first_name varchar(100) NOT NULL DEFAULT '',
+  last_name varchar(100) NOT NULL DEFAULT '',
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX employee_uidx_name ON employee (username);
+CREATE INDEX employee_idx_hid ON employee (hospital_id);
+
+CREATE TABLE task (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL,
+  owner_id bigint NOT NULL,
+  title varchar(100) NOT NULL, -- The task title
+  description varchar(500) NOT NULL, -- The task description
+  priority varchar(50) NOT NULL, -- The task priority. Could be one of urgent, hight, low
+  status varchar(50) NOT NULL, -- The task status. Could be one of open, failed, completed
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_idx_hid ON task (hospital_id);
+CREATE INDEX task_idx_oid ON task (owner_id);
@@ -0,0 +1,3 @@
+ALTER TABLE hospital ADD COLUMN deleted_at timestamp NULL;
+ALTER TABLE employee ADD COLUMN deleted_at timestamp NULL;
+ALTER TABLE task ADD COLUMN deleted_at timestamp NULL;
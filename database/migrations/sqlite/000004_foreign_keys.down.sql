@@ -0,0 +1,39 @@
+-- Rebuild the tables without the foreign keys. task goes first, as it is
+-- the one referencing employee. The rebuild fails while some tasks have no
+-- owner.
+CREATE TABLE task_new (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL,
+  owner_id bigint NOT NULL,
+  title varchar(100) NOT NULL, -- The task title
+  description varchar(500) NOT NULL, -- The task description
+  priority varchar(50) NOT NULL, -- The task priority. Could be one of urgent, hight, low
+  status varchar(50) NOT NULL, -- The task status. Could be one of open, failed, completed
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+INSERT INTO task_new (id, hospital_id, owner_id, title, description, priority, status, version, created_at, updated_at, deleted_at)
+  SELECT id, hospital_id, owner_id, title, description, priority, status, version, created_at, updated_at, deleted_at FROM task;
+DROP TABLE task;
+ALTER TABLE task_new RENAME TO task;
+CREATE INDEX task_idx_hid ON task (hospital_id);
+CREATE INDEX task_idx_oid ON task (owner_id);
+
+CREATE TABLE employee_new (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL,
+  username varchar(50) NOT NULL,
+  first_name varchar(100) NOT NULL DEFAULT '',
+  last_name varchar(100) NOT NULL DEFAULT '',
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+INSERT INTO employee_new (id, hospital_id, username, first_name, last_name, created_at, updated_at, deleted_at)
+  SELECT id, hospital_id, username, first_name, last_name, created_at, updated_at, deleted_at FROM employee;
+DROP TABLE employee;
+ALTER TABLE employee_new RENAME TO employee;
+CREATE UNIQUE INDEX employee_uidx_name ON employee (username);
+CREATE INDEX employee_idx_hid ON employee (hospital_id);
@@ -0,0 +1,49 @@
+-- Clean up the rows the foreign keys would reject first, without losing
+-- any. The employees and tasks of a hospital which doesn't exist anymore are
+-- soft-deleted, and the hospital is recreated soft-deleted under its former
+-- id, named deleted-<id>, so that an admin can still restore them. The tasks
+-- whose owner doesn't exist anymore are kept without an owner.
+UPDATE employee SET deleted_at = COALESCE(deleted_at, CURRENT_TIMESTAMP) WHERE hospital_id NOT IN (SELECT id FROM hospital);
+UPDATE task SET deleted_at = COALESCE(deleted_at, CURRENT_TIMESTAMP) WHERE hospital_id NOT IN (SELECT id FROM hospital);
+INSERT INTO hospital (id, name, deleted_at)
+  SELECT hospital_id, 'deleted-' || hospital_id, CURRENT_TIMESTAMP FROM (SELECT hospital_id FROM employee UNION SELECT hospital_id FROM task) AS orphan
+  WHERE hospital_id NOT IN (SELECT id FROM hospital);
+
+-- SQLite can't add a foreign key to an existing table, so the tables are
+-- rebuilt. employee goes first, as task is the one referencing it.
+CREATE TABLE employee_new (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL REFERENCES hospital (id),
+  username varchar(50) NOT NULL,
+  first_name varchar(100) NOT NULL DEFAULT '',
+  last_name varchar(100) NOT NULL DEFAULT '',
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+INSERT INTO employee_new (id, hospital_id, username, first_name, last_name, created_at, updated_at, deleted_at)
+  SELECT id, hospital_id, username, first_name, last_name, created_at, updated_at, deleted_at FROM employee;
+DROP TABLE employee;
+ALTER TABLE employee_new RENAME TO employee;
+CREATE UNIQUE INDEX employee_uidx_name ON employee (username);
+CREATE INDEX employee_idx_hid ON employee (hospital_id);
+
+CREATE TABLE task_new (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL REFERENCES hospital (id),
+  owner_id bigint NULL REFERENCES employee (id), -- NULL if the task has no owner
+  title varchar(100) NOT NULL, -- The task title
+  description varchar(500) NOT NULL, -- The task description
+  priority varchar(50) NOT NULL, -- The task priority. Could be one of urgent, hight, low
+  status varchar(50) NOT NULL, -- The task status. Could be one of open, failed, completed
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+INSERT INTO task_new (id, hospital_id, owner_id, title, description, priority, status, version, created_at, updated_at, deleted_at)
+  SELECT id, hospital_id, CASE WHEN owner_id IN (SELECT id FROM employee) THEN owner_id END, title, description, priority, status, version, created_at, updated_at, deleted_at FROM task;
+DROP TABLE task;
+ALTER TABLE task_new RENAME TO task;
+CREATE INDEX task_idx_hid ON task (hospital_id);
+CREATE INDEX task_idx_oid ON task (owner_id);
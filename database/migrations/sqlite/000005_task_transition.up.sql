@@ -0,0 +1,8 @@
+CREATE TABLE task_transition (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  task_id bigint NOT NULL REFERENCES task (id),
+  from_status varchar(50) NOT NULL DEFAULT '', -- The status before the transition, empty when the task was created
+  to_status varchar(50) NOT NULL, -- The status after the transition
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_transition_idx_tid ON task_transition (task_id);
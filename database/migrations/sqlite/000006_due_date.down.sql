@@ -0,0 +1,3 @@
+DROP INDEX task_idx_hid_due;
+ALTER TABLE task DROP COLUMN overdue;
+ALTER TABLE task DROP COLUMN due_at;
@@ -0,0 +1,3 @@
+ALTER TABLE task ADD COLUMN due_at timestamp NULL;
+ALTER TABLE task ADD COLUMN overdue boolean NOT NULL DEFAULT 0;
+CREATE INDEX task_idx_hid_due ON task (hospital_id, due_at);
@@ -0,0 +1,11 @@
+CREATE TABLE task_comment (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  task_id bigint NOT NULL REFERENCES task (id),
+  author_id bigint NOT NULL REFERENCES employee (id), -- The employee who wrote the comment
+  body varchar(2000) NOT NULL, -- The comment text
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+CREATE INDEX task_comment_idx_tid ON task_comment (task_id);
+CREATE INDEX task_comment_idx_aid ON task_comment (author_id);
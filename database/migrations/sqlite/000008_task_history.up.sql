@@ -0,0 +1,10 @@
+CREATE TABLE task_change (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  task_id bigint NOT NULL REFERENCES task (id),
+  field varchar(50) NOT NULL, -- The changed field of the task
+  old_value varchar(500) NOT NULL DEFAULT '', -- The value before the change, empty when the task was created
+  new_value varchar(500) NOT NULL DEFAULT '', -- The value after the change
+  actor_id bigint NULL REFERENCES employee (id), -- The employee who made the change, if known
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_change_idx_tid ON task_change (task_id);
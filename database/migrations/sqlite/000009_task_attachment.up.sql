@@ -0,0 +1,13 @@
+CREATE TABLE task_attachment (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  task_id bigint NOT NULL REFERENCES task (id),
+  uploader_id bigint NULL REFERENCES employee (id), -- The employee who uploaded the file
+  filename varchar(255) NOT NULL, -- The name of the uploaded file
+  content_type varchar(100) NOT NULL,
+  size bigint NOT NULL, -- The size of the file in bytes
+  blob_key varchar(255) NOT NULL UNIQUE, -- The key of the content in the blob store
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+CREATE INDEX task_attachment_idx_tid ON task_attachment (task_id);
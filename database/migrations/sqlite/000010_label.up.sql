@@ -0,0 +1,16 @@
+CREATE TABLE label (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL REFERENCES hospital (id),
+  name varchar(64) NOT NULL, -- The name of the label, unique in the hospital
+  color char(7) NOT NULL, -- The colour of the label, as #rrggbb
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (hospital_id, name)
+);
+CREATE TABLE task_label (
+  task_id bigint NOT NULL REFERENCES task (id),
+  label_id bigint NOT NULL REFERENCES label (id),
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, label_id)
+);
+CREATE INDEX task_label_idx_lid ON task_label (label_id);
@@ -0,0 +1,3 @@
+DROP TABLE task_dependency;
+DROP INDEX task_idx_pid;
+ALTER TABLE task DROP COLUMN parent_id;
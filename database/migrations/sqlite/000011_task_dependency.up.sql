@@ -0,0 +1,9 @@
+ALTER TABLE task ADD COLUMN parent_id bigint NULL REFERENCES task (id);
+CREATE INDEX task_idx_pid ON task (parent_id);
+CREATE TABLE task_dependency (
+  task_id bigint NOT NULL REFERENCES task (id), -- The blocked task
+  blocker_id bigint NOT NULL REFERENCES task (id), -- The task which has to be closed first
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, blocker_id)
+);
+CREATE INDEX task_dependency_idx_bid ON task_dependency (blocker_id);
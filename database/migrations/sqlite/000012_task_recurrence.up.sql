@@ -0,0 +1,28 @@
+ALTER TABLE hospital ADD COLUMN timezone varchar(64) NOT NULL DEFAULT 'UTC';
+CREATE TABLE task_recurrence (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL REFERENCES hospital (id),
+  title varchar(100) NOT NULL, -- The title of the created tasks
+  description varchar(500) NOT NULL, -- The description of the created tasks
+  priority varchar(50) NOT NULL, -- The priority of the created tasks
+  due_in bigint NOT NULL DEFAULT 0, -- Seconds from an occurrence to the due date of its task, 0 for none
+  schedule varchar(128) NOT NULL, -- The cron expression of the occurrences, in the time zone of the hospital
+  next_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, -- The next occurrence to create a task for
+  occurrences bigint NOT NULL DEFAULT 0, -- The number of tasks created so far, which picks the owner in the rotation
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+CREATE INDEX task_recurrence_idx_hid ON task_recurrence (hospital_id);
+CREATE INDEX task_recurrence_idx_next_at ON task_recurrence (next_at);
+CREATE TABLE task_recurrence_owner (
+  recurrence_id bigint NOT NULL REFERENCES task_recurrence (id),
+  position int NOT NULL, -- The rank of the employee in the rotation
+  employee_id bigint NOT NULL REFERENCES employee (id),
+  PRIMARY KEY (recurrence_id, position)
+);
+CREATE INDEX task_recurrence_owner_idx_eid ON task_recurrence_owner (employee_id);
+ALTER TABLE task ADD COLUMN recurrence_id bigint NULL REFERENCES task_recurrence (id);
+ALTER TABLE task ADD COLUMN occurrence_at timestamp NULL;
+CREATE UNIQUE INDEX task_uniq_rid_occurrence ON task (recurrence_id, occurrence_at);
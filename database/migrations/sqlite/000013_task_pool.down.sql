@@ -0,0 +1 @@
+-- task.owner_id stays nullable, see 000004_foreign_keys.
@@ -0,0 +1,3 @@
+-- task.owner_id is nullable since 000004_foreign_keys, a NULL owner now also
+-- means the task is in the pool of its hospital. SQLite has no column
+-- comments, so there is nothing to change.
@@ -0,0 +1,3 @@
+ALTER TABLE hospital ADD COLUMN assignment_strategy varchar(32) NOT NULL DEFAULT 'roundRobin';
+ALTER TABLE hospital ADD COLUMN auto_assign boolean NOT NULL DEFAULT 0;
+ALTER TABLE hospital ADD COLUMN last_assignee_id bigint NULL REFERENCES employee (id);
@@ -0,0 +1,28 @@
+CREATE TABLE task_sla (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL REFERENCES hospital (id),
+  priority varchar(50) NOT NULL, -- The priority of the tasks the SLA applies to
+  respond_within bigint NOT NULL, -- Seconds a task may stay OPEN since it was last moved or escalated
+  escalation varchar(32) NOT NULL, -- What is done to the tasks breaching the SLA: none, bumpPriority or reassign
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX task_sla_uniq_hid_priority ON task_sla (hospital_id, priority);
+CREATE TABLE task_sla_chain (
+  sla_id bigint NOT NULL REFERENCES task_sla (id),
+  position int NOT NULL, -- The rank of the employee in the escalation chain
+  employee_id bigint NOT NULL REFERENCES employee (id),
+  PRIMARY KEY (sla_id, position)
+);
+CREATE INDEX task_sla_chain_idx_eid ON task_sla_chain (employee_id);
+CREATE TABLE task_escalation (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  task_id bigint NOT NULL REFERENCES task (id),
+  level bigint NOT NULL, -- The number of times the task was escalated, this one included
+  action varchar(32) NOT NULL, -- What was done to the task: none, bumpPriority or reassign
+  old_value varchar(64) NOT NULL, -- The priority or the owner before the escalation
+  new_value varchar(64) NOT NULL, -- The priority or the owner after the escalation
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_escalation_idx_tid_created_at ON task_escalation (task_id, created_at);
+ALTER TABLE task ADD COLUMN breached boolean NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+CREATE TABLE task_watcher (
+  task_id bigint NOT NULL REFERENCES task (id),
+  employee_id bigint NOT NULL REFERENCES employee (id), -- The employee following the task
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, employee_id)
+);
+CREATE INDEX task_watcher_idx_eid ON task_watcher (employee_id);
+CREATE TABLE task_event (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  employee_id bigint NOT NULL REFERENCES employee (id), -- The owner or watcher the change is sent to
+  change_id bigint NOT NULL REFERENCES task_change (id),
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_event_idx_eid ON task_event (employee_id);
@@ -0,0 +1,15 @@
+ALTER TABLE hospital ADD COLUMN off_shift_assignment varchar(16) NOT NULL DEFAULT 'allow';
+CREATE TABLE shift (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL REFERENCES hospital (id),
+  employee_id bigint NOT NULL REFERENCES employee (id), -- The employee on duty
+  starts_at timestamp NOT NULL, -- The start of the shift, included
+  ends_at timestamp NOT NULL, -- The end of the shift, excluded
+  ward varchar(100) NOT NULL DEFAULT '', -- The ward the employee works in during the shift
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+CREATE INDEX shift_idx_hid_starts_at ON shift (hospital_id, starts_at);
+CREATE INDEX shift_idx_eid_starts_at ON shift (employee_id, starts_at);
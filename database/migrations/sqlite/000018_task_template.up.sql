@@ -0,0 +1,24 @@
+CREATE TABLE task_template (
+  id integer PRIMARY KEY AUTOINCREMENT, -- The primary key
+  hospital_id bigint NOT NULL REFERENCES hospital (id),
+  title varchar(100) NOT NULL, -- The title of the created tasks
+  description varchar(500) NOT NULL, -- The description of the created tasks
+  priority varchar(50) NOT NULL, -- The priority of the created tasks
+  version bigint NOT NULL DEFAULT 1,
+  created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at timestamp NULL
+);
+CREATE INDEX task_template_idx_hid ON task_template (hospital_id);
+CREATE TABLE task_template_label (
+  template_id bigint NOT NULL REFERENCES task_template (id),
+  label_id bigint NOT NULL REFERENCES label (id),
+  PRIMARY KEY (template_id, label_id)
+);
+CREATE INDEX task_template_label_idx_lid ON task_template_label (label_id);
+CREATE TABLE task_template_item (
+  template_id bigint NOT NULL REFERENCES task_template (id),
+  position int NOT NULL, -- The rank of the item in the checklist
+  title varchar(100) NOT NULL, -- The title of the subtask created for the item
+  PRIMARY KEY (template_id, position)
+);
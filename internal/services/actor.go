@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+)
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the id of the employee making the
+// request, which the services record along with the changes they make.
+func WithActor(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, actorKey{}, id)
+}
+
+// ActorFrom returns the id of the employee carried by ctx, 0 if unknown.
+func ActorFrom(ctx context.Context) int64 {
+	id, _ := ctx.Value(actorKey{}).(int64)
+	return id
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// AssignmentStrategy picks the owner of a task assigned automatically.
+type AssignmentStrategy interface {
+	// Pick returns the employee, among the candidates, to assign the task t
+	// of the hospital to. The candidates are the employees of the hospital
+	// ordered by id, there is at least one.
+	Pick(ctx context.Context, tx store.Store, hospital *models.Hospital, t *dto.Task, candidates []*models.Employee) (int64, error)
+}
+
+var (
+	strategiesMu         sync.RWMutex
+	assignmentStrategies = map[string]AssignmentStrategy{
+		models.AssignmentRoundRobin:    roundRobin{},
+		models.AssignmentLeastOpen:     leastOpen{},
+		models.AssignmentPriorityAware: priorityAware{},
+	}
+)
+
+// RegisterAssignmentStrategy makes the strategy s available to the hospitals
+// under name, replacing the strategy registered under it if any.
+func RegisterAssignmentStrategy(name string, s AssignmentStrategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	assignmentStrategies[name] = s
+}
+
+func getAssignmentStrategy(name string) (AssignmentStrategy, error) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	s, ok := assignmentStrategies[name]
+	if !ok {
+		return nil, &ServiceError{ErrBadArgument, fmt.Sprintf("invalid assignment strategy: %s", name)}
+	}
+	return s, nil
+}
+
+// assignTask picks the owner of the task t with the strategy of the
+// hospital. It returns 0 if the hospital has no employee.
+func assignTask(ctx context.Context, tx store.Store, hospital *models.Hospital, t *dto.Task) (int64, error) {
+	strategy, err := getAssignmentStrategy(hospital.AssignmentStrategy)
+	if err != nil {
+		return 0, err
+	}
+	candidates, err := tx.FindEmployees(ctx, hospital.ID, dto.ListOptions{Limit: math.MaxInt32})
+	if err != nil {
+		return 0, err
+	}
+	if candidates, err = onShift(ctx, tx, hospital, candidates, time.Now()); err != nil {
+		return 0, err
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	oid, err := strategy.Pick(ctx, tx, hospital, t, candidates)
+	if err != nil {
+		return 0, err
+	}
+	// The strategies are pluggable, so their pick is checked like the owner
+	// given by the client.
+	if err := checkOwner(ctx, tx, hospital.ID, oid); err != nil {
+		return 0, err
+	}
+	return oid, nil
+}
+
+// roundRobin picks the employees in turn, by id.
+type roundRobin struct{}
+
+func (roundRobin) Pick(ctx context.Context, tx store.Store, hospital *models.Hospital, t *dto.Task, candidates []*models.Employee) (int64, error) {
+	oid := candidates[0].ID
+	if hospital.LastAssigneeID != nil {
+		for _, e := range candidates {
+			if e.ID > *hospital.LastAssigneeID {
+				oid = e.ID
+				break
+			}
+		}
+	}
+	if _, err := tx.SetLastAssignee(ctx, hospital.ID, oid); err != nil {
+		return 0, err
+	}
+	return oid, nil
+}
+
+// leastOpen picks the employee with the fewest open tasks, the lowest id
+// breaking the ties.
+type leastOpen struct{}
+
+func (leastOpen) Pick(ctx context.Context, tx store.Store, hospital *models.Hospital, t *dto.Task, candidates []*models.Employee) (int64, error) {
+	counts, err := tx.CountOpenTasksPerOwner(ctx, hospital.ID)
+	if err != nil {
+		return 0, err
+	}
+	open := make(map[int64]uint)
+	for _, c := range counts {
+		open[c.OwnerID] += c.Count
+	}
+	oid := candidates[0].ID
+	for _, e := range candidates[1:] {
+		if open[e.ID] < open[oid] {
+			oid = e.ID
+		}
+	}
+	return oid, nil
+}
+
+// priorityAware picks the employee with the fewest open tasks at least as
+// urgent as the task, so the urgent tasks are spread first. The ties are
+// broken by the load of the open tasks, weighted by their priority rank,
+// then by the lowest id.
+type priorityAware struct{}
+
+func (priorityAware) Pick(ctx context.Context, tx store.Store, hospital *models.Hospital, t *dto.Task, candidates []*models.Employee) (int64, error) {
+	counts, err := tx.CountOpenTasksPerOwner(ctx, hospital.ID)
+	if err != nil {
+		return 0, err
+	}
+	rank := models.TaskPriorityRank(t.Priority)
+	urgent := make(map[int64]uint)
+	load := make(map[int64]uint)
+	for _, c := range counts {
+		r := models.TaskPriorityRank(c.Priority)
+		if r >= rank {
+			urgent[c.OwnerID] += c.Count
+		}
+		load[c.OwnerID] += uint(r) * c.Count
+	}
+	oid := candidates[0].ID
+	for _, e := range candidates[1:] {
+		if urgent[e.ID] < urgent[oid] || (urgent[e.ID] == urgent[oid] && load[e.ID] < load[oid]) {
+			oid = e.ID
+		}
+	}
+	return oid, nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/blob"
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// MaxAttachmentSize is the size limit of an attachment, in bytes.
+const MaxAttachmentSize = 10 << 20
+
+// AttachmentContentTypes are the accepted types of attachment. The type is
+// sniffed from the content, whatever the client claims it is.
+var AttachmentContentTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+}
+
+type AttachmentService struct {
+	logger logr.Logger
+	store  store.Store
+	blobs  blob.Store
+}
+
+func ProvideAttachmentService(logger logr.Logger, s store.Store, blobs blob.Store) *AttachmentService {
+	return &AttachmentService{
+		logger: logger.WithName("attachmentService"),
+		store:  s,
+		blobs:  blobs,
+	}
+}
+
+// CreateAttachment attaches the content read from r to the task a.TaskID.
+// The uploader, if known, has to be an employee of the hospital of the task.
+// The content is written to the blob store before the metadata, and removed
+// again if the metadata can't be saved.
+func (as *AttachmentService) CreateAttachment(ctx context.Context, a *dto.Attachment, r io.Reader) (*dto.Attachment, error) {
+	task, err := getTask(ctx, as.store, a.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UploaderID != 0 {
+		if err := checkOwner(ctx, as.store, task.HospitalID, a.UploaderID); err != nil {
+			return nil, err
+		}
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	a.ContentType = http.DetectContentType(head)
+	if !isAttachmentContentType(a.ContentType) {
+		return nil, &ServiceError{ErrUnsupportedMediaType, fmt.Sprintf("unsupported content type: %s", a.ContentType)}
+	}
+
+	a.BlobKey, err = newBlobKey(a.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	// Read one byte past the limit to tell a file of exactly the limit from
+	// a larger one.
+	content := io.LimitReader(io.MultiReader(bytes.NewReader(head), r), MaxAttachmentSize+1)
+	a.Size, err = as.blobs.Put(ctx, a.BlobKey, content)
+	if err != nil {
+		return nil, err
+	}
+	if a.Size > MaxAttachmentSize {
+		as.deleteBlob(ctx, a.BlobKey)
+		return nil, &ServiceError{ErrTooLarge, fmt.Sprintf("the attachment exceeds %d bytes", MaxAttachmentSize)}
+	}
+
+	attachment, err := as.store.CreateAttachment(ctx, a)
+	if err != nil {
+		as.deleteBlob(ctx, a.BlobKey)
+		if store.IsErrForeignKeyViolation(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid task id: %d", a.TaskID)}
+		}
+		return nil, err
+	}
+	return newAttachmentDTO(attachment), nil
+}
+
+// ListAttachments lists the attachments of the task taskID, oldest first.
+func (as *AttachmentService) ListAttachments(ctx context.Context, taskID int64, opts dto.ListOptions) (*dto.AttachmentList, error) {
+	if _, err := getTask(ctx, as.store, taskID); err != nil {
+		return nil, err
+	}
+	total, err := as.store.CountAttachments(ctx, taskID, opts)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := as.store.FindAttachments(ctx, taskID, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	attachments, next := nextPage(attachments, opts, func(a *models.Attachment) string { return dto.EncodeCursor(a.ID, nil) })
+	items := make([]*dto.Attachment, len(attachments))
+	for i := range attachments {
+		items[i] = newAttachmentDTO(attachments[i])
+	}
+	return &dto.AttachmentList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}, nil
+}
+
+// OpenAttachment returns the attachment id of the task taskID along with a
+// reader of its content, which the caller has to close.
+func (as *AttachmentService) OpenAttachment(ctx context.Context, taskID, id int64) (*dto.Attachment, io.ReadCloser, error) {
+	attachment, err := getAttachment(ctx, as.store, taskID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := as.blobs.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		if err == blob.ErrNotFound {
+			as.logger.Error(err, "missing attachment content", "id", id, "key", attachment.BlobKey)
+			return nil, nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+		}
+		return nil, nil, err
+	}
+	return newAttachmentDTO(attachment), rc, nil
+}
+
+// DeleteAttachment soft-deletes the attachment id of the task taskID. Its
+// content is kept in the blob store like the row is kept in the database.
+func (as *AttachmentService) DeleteAttachment(ctx context.Context, taskID, id int64) error {
+	return as.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := getAttachment(ctx, tx, taskID, id); err != nil {
+			return err
+		}
+		_, err := tx.DeleteAttachment(ctx, id)
+		return err
+	})
+}
+
+// deleteBlob removes a blob which has no metadata, logging the failure
+// since there is nothing more to do about it.
+func (as *AttachmentService) deleteBlob(ctx context.Context, key string) {
+	if err := as.blobs.Delete(ctx, key); err != nil {
+		as.logger.Error(err, "failed to delete blob", "key", key)
+	}
+}
+
+// getAttachment returns the attachment id, which has to belong to the task
+// taskID.
+func getAttachment(ctx context.Context, s store.AttachmentStore, taskID, id int64) (*models.Attachment, error) {
+	attachment, err := s.GetAttachment(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+		}
+		return nil, err
+	}
+	if attachment.TaskID != taskID {
+		return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+	}
+	return attachment, nil
+}
+
+// newBlobKey returns a random key under the prefix of the task taskID, so
+// the key never depends on what the client sent.
+func newBlobKey(taskID int64) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
+
+func isAttachmentContentType(contentType string) bool {
+	for _, t := range AttachmentContentTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func newAttachmentDTO(a *models.Attachment) *dto.Attachment {
+	attachment := &dto.Attachment{
+		ID:          a.ID,
+		TaskID:      a.TaskID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		BlobKey:     a.BlobKey,
+		CreatedAt:   a.CreatedAt,
+	}
+	if a.UploaderID != nil {
+		attachment.UploaderID = *a.UploaderID
+	}
+	return attachment
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// maxBulkTasks is the most tasks a bulk action can change at once.
+const maxBulkTasks = 500
+
+// errBulkRollback undoes the changes of a bulk action which is a dry run or
+// which failed on some task.
+var errBulkRollback = errors.New("bulk action rolled back")
+
+// BulkUpdateTasks applies the action of b to the tasks of the hospital hid
+// in one transaction. Each task is checked like it is by the action on a
+// single task, and the report tells how it went for each of them. The
+// changes are only applied if none of the tasks failed and b isn't a dry
+// run.
+func (ts *TaskService) BulkUpdateTasks(ctx context.Context, hid int64, b *dto.TaskBulk) (*dto.TaskBulkReport, error) {
+	var report *dto.TaskBulkReport
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		report = &dto.TaskBulkReport{DryRun: b.DryRun, Items: []*dto.TaskBulkResult{}}
+		hospital, err := tx.GetHospital(ctx, hid)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", hid)}
+			}
+			return err
+		}
+		var label *models.Label
+		var warning string
+		switch b.Action {
+		case dto.BulkActionAssign:
+			if err := checkOwner(ctx, tx, hid, b.OwnerID); err != nil {
+				return err
+			}
+			if warning, err = checkOnShift(ctx, tx, hospital, b.OwnerID, time.Now()); err != nil {
+				return err
+			}
+		case dto.BulkActionAddLabel:
+			var err error
+			if label, err = getLabel(ctx, tx, b.LabelID); err != nil {
+				return err
+			}
+			if label.HospitalID != hid {
+				return &ServiceError{ErrPermissionDenied, "the label belongs to another hospital"}
+			}
+		case dto.BulkActionSetStatus, dto.BulkActionSetPriority:
+		default:
+			return &ServiceError{ErrBadArgument, fmt.Sprintf("invalid action: %s", b.Action)}
+		}
+		ids, err := bulkTaskIDs(ctx, tx, hid, b)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			result := &dto.TaskBulkResult{TaskID: id}
+			result.Task, err = bulkUpdateTask(ctx, tx, hid, id, b, label)
+			if err != nil {
+				var svcErr *ServiceError
+				if !errors.As(err, &svcErr) {
+					return err
+				}
+				result.Error, result.Msg = string(svcErr.Code), svcErr.Msg
+				report.Failed++
+			} else if warning != "" {
+				result.Task.Warnings = []string{warning}
+			}
+			report.Items = append(report.Items, result)
+		}
+		if b.DryRun || report.Failed > 0 {
+			return errBulkRollback
+		}
+		report.Applied = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkRollback) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// bulkTaskIDs returns the ids of the tasks selected by b, without
+// duplicates.
+func bulkTaskIDs(ctx context.Context, tx store.Store, hid int64, b *dto.TaskBulk) ([]int64, error) {
+	var ids []int64
+	if len(b.TaskIDs) > 0 {
+		seen := make(map[int64]bool)
+		for _, id := range b.TaskIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	} else {
+		tasks, err := tx.FindTasksByHospital(ctx, hid, b.Filter, dto.ListOptions{Limit: maxBulkTasks + 1})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) > maxBulkTasks {
+		return nil, &ServiceError{ErrBadArgument, fmt.Sprintf("more than %d tasks", maxBulkTasks)}
+	}
+	return ids, nil
+}
+
+// bulkUpdateTask applies the action of b to the task id, which has to
+// belong to the hospital hid, and returns the changed task. label is the
+// label of BulkActionAddLabel.
+func bulkUpdateTask(ctx context.Context, tx store.Store, hid, id int64, b *dto.TaskBulk, label *models.Label) (*dto.Task, error) {
+	task, err := getTask(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if task.HospitalID != hid {
+		return nil, &ServiceError{ErrPermissionDenied, "the task belongs to another hospital"}
+	}
+	t := newTaskDTO(task)
+	switch b.Action {
+	case dto.BulkActionAssign:
+		t.OwnerID = b.OwnerID
+	case dto.BulkActionSetStatus:
+		t.Status = b.Status
+	case dto.BulkActionSetPriority:
+		t.Priority = b.Priority
+	case dto.BulkActionAddLabel:
+		if err := labelTask(ctx, tx, task, label); err != nil {
+			return nil, err
+		}
+	}
+	if b.Action != dto.BulkActionAddLabel {
+		if err := updateTask(ctx, tx, t); err != nil {
+			return nil, err
+		}
+		if task, err = tx.GetTask(ctx, id); err != nil {
+			return nil, err
+		}
+		t = newTaskDTO(task)
+	}
+	if err := setTaskLabels(ctx, tx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+type CommentService struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideCommentService(logger logr.Logger, s store.Store) *CommentService {
+	return &CommentService{
+		logger: logger.WithName("commentService"),
+		store:  s,
+	}
+}
+
+// CreateComment leaves the comment c on the task c.TaskID. The author has to
+// be an employee of the hospital of the task.
+func (cs *CommentService) CreateComment(ctx context.Context, c *dto.Comment) (*dto.Comment, error) {
+	var comment *models.Comment
+	err := cs.store.WithTx(ctx, func(tx store.Store) error {
+		task, err := tx.GetTask(ctx, c.TaskID)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid task id: %d", c.TaskID)}
+			}
+			return err
+		}
+		if err := checkOwner(ctx, tx, task.HospitalID, c.AuthorID); err != nil {
+			return err
+		}
+		comment, err = tx.CreateComment(ctx, c)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newCommentDTO(comment), nil
+}
+
+// ListComments lists the comments of the task taskID, oldest first.
+func (cs *CommentService) ListComments(ctx context.Context, taskID int64, opts dto.ListOptions) (*dto.CommentList, error) {
+	if _, err := cs.store.GetTask(ctx, taskID); err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid task id: %d", taskID)}
+		}
+		return nil, err
+	}
+	total, err := cs.store.CountComments(ctx, taskID, opts)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := cs.store.FindComments(ctx, taskID, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	comments, next := nextPage(comments, opts, func(c *models.Comment) string { return dto.EncodeCursor(c.ID, nil) })
+	items := make([]*dto.Comment, len(comments))
+	for i := range comments {
+		items[i] = newCommentDTO(comments[i])
+	}
+	return &dto.CommentList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}, nil
+}
+
+// UpdateComment replaces the body of the comment c.ID. Only its author, the
+// employee actor, can edit it.
+func (cs *CommentService) UpdateComment(ctx context.Context, c *dto.Comment, actor int64) (*dto.Comment, error) {
+	var comment *models.Comment
+	err := cs.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if comment, err = getAuthoredComment(ctx, tx, c.ID, actor); err != nil {
+			return err
+		}
+		if _, err := tx.UpdateComment(ctx, c); err != nil {
+			return err
+		}
+		comment, err = tx.GetComment(ctx, c.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newCommentDTO(comment), nil
+}
+
+// DeleteComment soft-deletes the comment id. Only its author, the employee
+// actor, can delete it.
+func (cs *CommentService) DeleteComment(ctx context.Context, id, actor int64) error {
+	return cs.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := getAuthoredComment(ctx, tx, id, actor); err != nil {
+			return err
+		}
+		_, err := tx.DeleteComment(ctx, id)
+		return err
+	})
+}
+
+// getAuthoredComment returns the comment id, which has to be written by the
+// employee actor.
+func getAuthoredComment(ctx context.Context, s store.CommentStore, id, actor int64) (*models.Comment, error) {
+	comment, err := s.GetComment(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+		}
+		return nil, err
+	}
+	if comment.AuthorID != actor {
+		return nil, &ServiceError{ErrPermissionDenied, "only the author can change a comment"}
+	}
+	return comment, nil
+}
+
+func newCommentDTO(c *models.Comment) *dto.Comment {
+	return &dto.Comment{
+		ID:        c.ID,
+		TaskID:    c.TaskID,
+		AuthorID:  c.AuthorID,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// SetTaskParent makes the task id a subtask of the task parentID, or a top
+// level task if parentID is 0, and returns the updated task.
+func (ts *TaskService) SetTaskParent(ctx context.Context, id, parentID int64) (*dto.Task, error) {
+	var task *dto.Task
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		t, err := getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if parentID != 0 {
+			if err := checkParent(ctx, tx, t.HospitalID, id, parentID); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.SetTaskParent(ctx, id, parentID); err != nil {
+			return err
+		}
+		if t, err = tx.GetTask(ctx, id); err != nil {
+			return err
+		}
+		task = newTaskDTO(t)
+		return setTaskLabels(ctx, tx, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// ListSubtasks returns the subtasks of the task id, oldest first.
+func (ts *TaskService) ListSubtasks(ctx context.Context, id int64) (*dto.TaskList, error) {
+	if _, err := getTask(ctx, ts.store, id); err != nil {
+		return nil, err
+	}
+	tasks, err := ts.store.FindSubtasks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ts.newFullTaskList(ctx, tasks)
+}
+
+// checkParent checks that the task parentID can be the parent of the task
+// id of the hospital hid, id being 0 for a task to be created. The parent
+// has to be in the same hospital, and can't be one of the subtasks of the
+// task.
+func checkParent(ctx context.Context, s store.TaskStore, hid, id, parentID int64) error {
+	parent, err := s.GetTask(ctx, parentID)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid parent id: %d", parentID)}
+		}
+		return err
+	}
+	if parent.HospitalID != hid {
+		return &ServiceError{ErrPermissionDenied, "the parent belongs to another hospital"}
+	}
+	seen := make(map[int64]bool)
+	for ancestor := parentID; ancestor != 0 && !seen[ancestor]; {
+		if ancestor == id {
+			return &ServiceError{ErrConflict, "a task can't be a subtask of itself"}
+		}
+		seen[ancestor] = true
+		if ancestor, err = s.GetTaskParentID(ctx, ancestor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTaskBlocker records that the task id can't be completed until the task
+// blockerID is closed. Both have to be in the same hospital, and the blocker
+// can't be blocked by the task, even indirectly. Adding a blocker twice does
+// nothing.
+func (ts *TaskService) AddTaskBlocker(ctx context.Context, id, blockerID int64) error {
+	if id == blockerID {
+		return &ServiceError{ErrBadArgument, "a task can't block itself"}
+	}
+	return ts.store.WithTx(ctx, func(tx store.Store) error {
+		task, err := getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		blocker, err := getTask(ctx, tx, blockerID)
+		if err != nil {
+			return err
+		}
+		if blocker.HospitalID != task.HospitalID {
+			return &ServiceError{ErrPermissionDenied, "the blocker belongs to another hospital"}
+		}
+		ids, err := tx.FindTaskBlockerIDs(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, b := range ids {
+			if b == blockerID {
+				return nil
+			}
+		}
+		blocked, err := isBlockedBy(ctx, tx, blockerID, id)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return &ServiceError{ErrConflict, fmt.Sprintf("task %d is already blocked by task %d", blockerID, id)}
+		}
+		return tx.CreateTaskDependency(ctx, id, blockerID)
+	})
+}
+
+// isBlockedBy reports whether the task id is blocked by the task blockerID,
+// directly or through other blockers.
+func isBlockedBy(ctx context.Context, s store.TaskStore, id, blockerID int64) (bool, error) {
+	seen := map[int64]bool{id: true}
+	queue := []int64{id}
+	for len(queue) > 0 {
+		ids, err := s.FindTaskBlockerIDs(ctx, queue[0])
+		if err != nil {
+			return false, err
+		}
+		queue = queue[1:]
+		for _, b := range ids {
+			if b == blockerID {
+				return true, nil
+			}
+			if !seen[b] {
+				seen[b] = true
+				queue = append(queue, b)
+			}
+		}
+	}
+	return false, nil
+}
+
+// RemoveTaskBlocker undoes AddTaskBlocker.
+func (ts *TaskService) RemoveTaskBlocker(ctx context.Context, id, blockerID int64) error {
+	if _, err := getTask(ctx, ts.store, id); err != nil {
+		return err
+	}
+	r, err := ts.store.DeleteTaskDependency(ctx, id, blockerID)
+	if err != nil {
+		return err
+	}
+	if r == 0 {
+		return &ServiceError{ErrResourceNotFound, fmt.Sprintf("the task isn't blocked by task %d", blockerID)}
+	}
+	return nil
+}
+
+// ListTaskBlockers returns the tasks blocking the task id, closed or not.
+func (ts *TaskService) ListTaskBlockers(ctx context.Context, id int64) (*dto.TaskList, error) {
+	if _, err := getTask(ctx, ts.store, id); err != nil {
+		return nil, err
+	}
+	tasks, err := ts.store.FindTaskBlockers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ts.newFullTaskList(ctx, tasks)
+}
+
+// checkBlockers checks that the task id has no open blocker, before it is
+// completed.
+func checkBlockers(ctx context.Context, s store.TaskStore, id int64) error {
+	n, err := s.CountOpenBlockers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &ServiceError{ErrConflict, fmt.Sprintf("the task has %d open blockers", n)}
+	}
+	return nil
+}
+
+// newFullTaskList returns the unpaginated list of the tasks with their
+// labels.
+func (ts *TaskService) newFullTaskList(ctx context.Context, tasks []*models.Task) (*dto.TaskList, error) {
+	items := make([]*dto.Task, len(tasks))
+	for i := range tasks {
+		items[i] = newTaskDTO(tasks[i])
+	}
+	if err := setTaskLabels(ctx, ts.store, items...); err != nil {
+		return nil, err
+	}
+	return &dto.TaskList{Total: uint(len(items)), Items: items}, nil
+}
@@ -3,78 +3,185 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"github.com/go-logr/logr"
 
 	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
 	"github.com/liuerfire/boxpractice/pkg/store"
 )
 
 type EmployeeService struct {
-	logger   logr.Logger
-	sqlStore *store.SQLStore
+	logger logr.Logger
+	store  store.Store
 }
 
-func ProvideEmployeeService(logger logr.Logger, sqlStore *store.SQLStore) *EmployeeService {
+func ProvideEmployeeService(logger logr.Logger, s store.Store) *EmployeeService {
 	return &EmployeeService{
-		logger:   logger.WithName("employeeService"),
-		sqlStore: sqlStore,
+		logger: logger.WithName("employeeService"),
+		store:  s,
 	}
 }
 
 func (es *EmployeeService) CreateEmployee(ctx context.Context, e *dto.Employee) (*dto.Employee, error) {
-	employee, err := es.sqlStore.CreateEmployee(ctx, e)
+	employee, err := es.store.CreateEmployee(ctx, e)
 	if err != nil {
 		if store.IsErrDuplicateEntry(err) {
 			return nil, &ServiceError{ErrAlreadyExists, fmt.Sprintf("username exists: %s", e.Username)}
 		}
+		if store.IsErrForeignKeyViolation(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid hospital id: %d", e.HospitalID)}
+		}
 		return nil, err
 	}
-	return &dto.Employee{
-		ID:         employee.ID,
-		HospitalID: employee.HospitalID,
-		Username:   employee.Username,
-		FirstName:  employee.FirstName,
-		LastName:   employee.LastName,
-		CreatedAt:  employee.CreatedAt,
-	}, nil
+	return newEmployeeDTO(employee), nil
 }
 
-func (es *EmployeeService) ListEmployees(ctx context.Context, id int64, page, limit uint) (*dto.EmployeeList, error) {
-	total, err := es.sqlStore.CountEmployees(ctx, id)
+func (es *EmployeeService) ListEmployees(ctx context.Context, id int64, opts dto.ListOptions) (*dto.EmployeeList, error) {
+	total, err := es.store.CountEmployees(ctx, id, opts)
 	if err != nil {
 		return nil, err
 	}
-	employees, err := es.sqlStore.FindEmployees(ctx, id, page, limit)
+	employees, err := es.store.FindEmployees(ctx, id, pageOptions(opts))
 	if err != nil {
 		return nil, err
 	}
+	employees, next := nextPage(employees, opts, func(e *models.Employee) string { return dto.EncodeCursor(e.ID, nil) })
 	items := make([]*dto.Employee, len(employees))
 	for i := range employees {
-		employee := employees[i]
-		items[i] = &dto.Employee{
-			ID:         employee.ID,
-			HospitalID: employee.HospitalID,
-			Username:   employee.Username,
-			FirstName:  employee.FirstName,
-			LastName:   employee.LastName,
-			CreatedAt:  employee.CreatedAt,
-		}
+		items[i] = newEmployeeDTO(employees[i])
 	}
 	return &dto.EmployeeList{
-		Total: total,
-		Items: items,
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
 	}, nil
 }
 
 func (es *EmployeeService) GetEmployee(ctx context.Context, id int64) (*dto.Employee, error) {
-	employee, err := es.sqlStore.GetEmployee(ctx, id)
+	employee, err := es.store.GetEmployee(ctx, id)
 	if err != nil {
 		if store.IsErrNotFound(err) {
 			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
 		}
 		return nil, err
 	}
+	return newEmployeeDTO(employee), nil
+}
+
+// DeleteEmployee soft-deletes the employee. If they still have open tasks,
+// DeleteRestrict fails with ErrConflict, DeleteReassign gives the tasks to
+// the employee opts.ReassignTo of the same hospital, subject to its
+// off-shift policy like any assignment, and DeleteCascade
+// deletes all their tasks along, at the same time as the employee. The
+// reassignments and deletions are recorded in the history of the tasks like
+// any other change.
+func (es *EmployeeService) DeleteEmployee(ctx context.Context, id int64, opts dto.DeleteOptions) error {
+	return es.store.WithTx(ctx, func(tx store.Store) error {
+		employee, err := tx.GetEmployee(ctx, id)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+			}
+			return err
+		}
+		switch opts.Policy {
+		case "", dto.DeleteRestrict:
+			n, err := tx.CountOpenTasksByOwner(ctx, id)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return &ServiceError{ErrConflict, fmt.Sprintf("employee has %d open tasks", n)}
+			}
+		case dto.DeleteReassign:
+			if opts.ReassignTo == id {
+				return &ServiceError{ErrBadArgument, fmt.Sprintf("invalid reassign id: %d", opts.ReassignTo)}
+			}
+			if err := checkOwner(ctx, tx, employee.HospitalID, opts.ReassignTo); err != nil {
+				return err
+			}
+			tasks, err := tx.FindTasksByOwner(ctx, id, dto.TaskFilter{}, dto.ListOptions{Limit: math.MaxInt32})
+			if err != nil {
+				return err
+			}
+			for _, task := range tasks {
+				if models.IsTaskClosed(task.Status) {
+					continue
+				}
+				t := newTaskDTO(task)
+				t.OwnerID = opts.ReassignTo
+				if err := checkAssignee(ctx, tx, t); err != nil {
+					return err
+				}
+				if err := updateTask(ctx, tx, t); err != nil {
+					return err
+				}
+			}
+		case dto.DeleteCascade:
+			// Their tasks are deleted once they are, see below.
+		default:
+			return &ServiceError{ErrBadArgument, fmt.Sprintf("invalid policy: %s", opts.Policy)}
+		}
+		if _, err := tx.DeleteEmployee(ctx, id); err != nil {
+			return err
+		}
+		if opts.Policy != dto.DeleteCascade {
+			return nil
+		}
+		deleted, err := tx.GetDeletedEmployee(ctx, id)
+		if err != nil {
+			return err
+		}
+		tasks, err := tx.FindTasksByOwner(ctx, id, dto.TaskFilter{}, dto.ListOptions{Limit: math.MaxInt32})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.DeleteTasksByOwner(ctx, id, *deleted.DeletedAt); err != nil {
+			return err
+		}
+		return recordTasksDeleted(ctx, tx, tasks)
+	})
+}
+
+// RestoreEmployee undoes DeleteEmployee, restoring along the tasks
+// DeleteCascade deleted with them. The employees of a deleted hospital can't
+// be restored before it. Restoring an employee who isn't deleted does
+// nothing.
+func (es *EmployeeService) RestoreEmployee(ctx context.Context, id int64) error {
+	return es.store.WithTx(ctx, func(tx store.Store) error {
+		deleted, err := tx.GetDeletedEmployee(ctx, id)
+		if err != nil {
+			if !store.IsErrNotFound(err) {
+				return err
+			}
+			if _, err := tx.GetEmployee(ctx, id); err != nil {
+				if store.IsErrNotFound(err) {
+					return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+				}
+				return err
+			}
+			return nil
+		}
+		if _, err := tx.GetHospital(ctx, deleted.HospitalID); err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrConflict, fmt.Sprintf("the hospital is deleted: %d", deleted.HospitalID)}
+			}
+			return err
+		}
+		if _, err := tx.RestoreEmployee(ctx, id); err != nil {
+			return err
+		}
+		tasks, err := tx.FindTasksByOwner(ctx, id, dto.TaskFilter{}, dto.ListOptions{Limit: math.MaxInt32, IncludeDeleted: true})
+		if err != nil {
+			return err
+		}
+		return restoreTasksDeletedAt(ctx, tx, tasks, *deleted.DeletedAt)
+	})
+}
+
+func newEmployeeDTO(employee *models.Employee) *dto.Employee {
 	return &dto.Employee{
 		ID:         employee.ID,
 		HospitalID: employee.HospitalID,
@@ -82,5 +189,6 @@ func (es *EmployeeService) GetEmployee(ctx context.Context, id int64) (*dto.Empl
 		FirstName:  employee.FirstName,
 		LastName:   employee.LastName,
 		CreatedAt:  employee.CreatedAt,
-	}, nil
+		DeletedAt:  employee.DeletedAt,
+	}
 }
@@ -17,7 +17,21 @@ const (
 	ErrResourceNotFound ErrCode = "ResourceNotFound"
 	ErrAlreadyExists    ErrCode = "ResourceAlreadyExists"
 	ErrPermissionDenied ErrCode = "PermissionDenied"
-	ErrInternalError    ErrCode = "InternalError"
+	// ErrPreconditionFailed means the resource was modified since the
+	// version the client based its change on.
+	ErrPreconditionFailed ErrCode = "PreconditionFailed"
+	// ErrConflict means the request can't be done in the current state of
+	// the resource.
+	ErrConflict ErrCode = "Conflict"
+	// ErrInvalidTransition means the task can't move to the requested
+	// status from its current one.
+	ErrInvalidTransition ErrCode = "InvalidTransition"
+	// ErrTooLarge means the uploaded content exceeds the size limit.
+	ErrTooLarge ErrCode = "TooLarge"
+	// ErrUnsupportedMediaType means the type of the uploaded content isn't
+	// accepted.
+	ErrUnsupportedMediaType ErrCode = "UnsupportedMediaType"
+	ErrInternalError        ErrCode = "InternalError"
 )
 
 type ServiceError struct {
@@ -38,8 +52,14 @@ func (e ErrCode) StatusCode() int {
 		return http.StatusNotFound
 	case ErrPermissionDenied:
 		return http.StatusForbidden
-	case ErrAlreadyExists:
+	case ErrAlreadyExists, ErrConflict, ErrInvalidTransition:
 		return http.StatusConflict
+	case ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case ErrTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
 	}
 	return http.StatusInternalServerError
 }
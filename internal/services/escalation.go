@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// escalationBatchSize is the number of tasks escalated per SLA by one run of
+// the evaluator. The other breaching ones wait for the next run.
+const escalationBatchSize = 100
+
+// SLAEvaluator periodically escalates the tasks which stay OPEN past the SLA
+// of their priority: each of them is flagged as breached, escalated as the
+// SLA says, and the escalation is recorded. A task is escalated again every
+// time it stays OPEN for another RespondWithin.
+//
+// Like the TaskScheduler, several evaluators can run against the same
+// database: each task is escalated in a transaction which checks again
+// that it is still breaching.
+type SLAEvaluator struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideSLAEvaluator(logger logr.Logger, s store.Store) *SLAEvaluator {
+	return &SLAEvaluator{
+		logger: logger.WithName("slaEvaluator"),
+		store:  s,
+	}
+}
+
+// Run evaluates every interval until ctx is done. A failed run is logged and
+// retried at the next tick.
+func (ev *SLAEvaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := ev.Evaluate(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			ev.logger.Error(err, "failed to evaluate the SLAs")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate unflags the tasks which left OPEN, and escalates the ones
+// breaching their SLA at now. A task which fails is logged and doesn't stop
+// the other ones.
+func (ev *SLAEvaluator) Evaluate(ctx context.Context, now time.Time) error {
+	if _, err := ev.store.ClearTaskBreaches(ctx); err != nil {
+		return err
+	}
+	slas, err := ev.store.FindAllTaskSLAs(ctx)
+	if err != nil {
+		return err
+	}
+	ids := make([]int64, len(slas))
+	for i := range slas {
+		ids[i] = slas[i].ID
+	}
+	chains, err := ev.store.FindTaskSLAChains(ctx, ids)
+	if err != nil {
+		return err
+	}
+	var escalated int
+	for _, sla := range slas {
+		since := now.Add(-time.Duration(sla.RespondWithin) * time.Second)
+		taskIDs, err := ev.store.FindBreachingTaskIDs(ctx, sla, since, escalationBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, id := range taskIDs {
+			err := ev.store.WithTx(ctx, func(tx store.Store) error {
+				return escalateTask(ctx, tx, sla, chains[sla.ID], id, now)
+			})
+			if err == nil {
+				escalated++
+			} else if !errors.Is(err, errNotBreaching) {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				ev.logger.Error(err, "failed to escalate the task", "id", id)
+			}
+		}
+	}
+	if escalated > 0 {
+		ev.logger.V(1).Info("escalated tasks", "count", escalated)
+	}
+	return nil
+}
+
+// errNotBreaching aborts the escalation of a task which was moved, escalated
+// or reprioritised since it was found breaching.
+var errNotBreaching = errors.New("task not breaching")
+
+// escalateTask escalates the task id, breaching the sla at now, along the
+// chain of the sla.
+func escalateTask(ctx context.Context, tx store.Store, sla *models.TaskSLA, chain []int64, id int64, now time.Time) error {
+	task, err := tx.GetTask(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return errNotBreaching
+		}
+		return err
+	}
+	if task.Status != models.TaskStatusOpen || task.Priority != sla.Priority {
+		return errNotBreaching
+	}
+	since := now.Add(-time.Duration(sla.RespondWithin) * time.Second)
+	transitions, err := tx.FindTaskTransitions(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n := len(transitions); n > 0 && transitions[n-1].CreatedAt.After(since) {
+		return errNotBreaching
+	}
+	escalations, err := tx.FindTaskEscalations(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n := len(escalations); n > 0 && escalations[n-1].CreatedAt.After(since) {
+		return errNotBreaching
+	}
+
+	e := &dto.TaskEscalation{
+		TaskID:    id,
+		Level:     int64(len(escalations)) + 1,
+		Action:    models.EscalationNone,
+		CreatedAt: now,
+	}
+	t := newTaskDTO(task)
+	switch sla.Escalation {
+	case models.EscalationBumpPriority:
+		if p := raisePriority(task.Priority); p != "" {
+			e.Action, e.OldValue, e.NewValue = sla.Escalation, task.Priority, p
+			t.Priority = p
+		}
+	case models.EscalationReassign:
+		oid, err := nextEscalatee(ctx, tx, t.HospitalID, t.OwnerID, chain)
+		if err != nil {
+			return err
+		}
+		if oid != 0 {
+			e.Action, e.OldValue, e.NewValue = sla.Escalation, taskValues(t)[models.TaskFieldOwnerID], strconv.FormatInt(oid, 10)
+			t.OwnerID = oid
+		}
+	}
+	if e.Action != models.EscalationNone {
+		if err := updateTask(ctx, tx, t); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.MarkTaskBreached(ctx, id); err != nil {
+		return err
+	}
+	_, err = tx.CreateTaskEscalation(ctx, e)
+	return err
+}
+
+// raisePriority returns the priority above p, or "" if p is the highest.
+func raisePriority(p string) string {
+	switch p {
+	case models.TaskPriorityLow:
+		return models.TaskPriorityHight
+	case models.TaskPriorityHight:
+		return models.TaskPriorityUrgent
+	}
+	return ""
+}
+
+// nextEscalatee returns the employee of the chain after the owner oid, or
+// the first one if the owner isn't part of it. The employees who left the
+// hospital hid are skipped. It returns 0 at the end of the chain.
+func nextEscalatee(ctx context.Context, tx store.Store, hid, oid int64, chain []int64) (int64, error) {
+	start := 0
+	for i, eid := range chain {
+		if eid == oid {
+			start = i + 1
+		}
+	}
+	for _, eid := range chain[start:] {
+		err := checkOwner(ctx, tx, hid, eid)
+		if err == nil {
+			return eid, nil
+		}
+		var svcErr *ServiceError
+		if !errors.As(err, &svcErr) {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
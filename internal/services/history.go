@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// taskFields are the fields of a task recorded in its history, in the order
+// their changes are recorded.
+var taskFields = []string{
+	models.TaskFieldOwnerID,
+	models.TaskFieldTitle,
+	models.TaskFieldDescription,
+	models.TaskFieldPriority,
+	models.TaskFieldStatus,
+	models.TaskFieldDueAt,
+}
+
+// taskValues returns the recorded fields of t written as text.
+func taskValues(t *dto.Task) map[string]string {
+	values := map[string]string{
+		models.TaskFieldOwnerID:     "",
+		models.TaskFieldTitle:       t.Title,
+		models.TaskFieldDescription: t.Description,
+		models.TaskFieldPriority:    t.Priority,
+		models.TaskFieldStatus:      t.Status,
+		models.TaskFieldDueAt:       "",
+	}
+	if t.OwnerID != 0 {
+		values[models.TaskFieldOwnerID] = strconv.FormatInt(t.OwnerID, 10)
+	}
+	if t.DueAt != nil {
+		values[models.TaskFieldDueAt] = t.DueAt.UTC().Format(time.RFC3339Nano)
+	}
+	return values
+}
+
+// setTaskValues is the reverse of taskValues.
+func setTaskValues(t *dto.Task, values map[string]string) error {
+	t.OwnerID = 0
+	if v := values[models.TaskFieldOwnerID]; v != "" {
+		oid, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid recorded owner id: %w", err)
+		}
+		t.OwnerID = oid
+	}
+	t.Title = values[models.TaskFieldTitle]
+	t.Description = values[models.TaskFieldDescription]
+	t.Priority = values[models.TaskFieldPriority]
+	t.Status = values[models.TaskFieldStatus]
+	t.DueAt = nil
+	if v := values[models.TaskFieldDueAt]; v != "" {
+		due, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("invalid recorded due date: %w", err)
+		}
+		t.DueAt = &due
+	}
+	return nil
+}
+
+// recordTaskChanges records the fields of the task taskID which differ
+// between before and after, made by the actor of ctx, and sends them to the
+// owners and watchers of the task.
+func recordTaskChanges(ctx context.Context, tx store.Store, taskID int64, before, after map[string]string) error {
+	var fields []string
+	for _, field := range taskFields {
+		if before[field] != after[field] {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	recipients, err := taskRecipients(ctx, tx, taskID, before, after)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		change, err := recordTaskChange(ctx, tx, taskID, field, before[field], after[field])
+		if err != nil {
+			return err
+		}
+		for _, eid := range recipients {
+			if err := tx.CreateTaskEvent(ctx, eid, change.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// taskRecipients returns the employees the changes of the task taskID from
+// before to after are sent to: its watchers and its owners before and after
+// the changes.
+func taskRecipients(ctx context.Context, tx store.Store, taskID int64, before, after map[string]string) ([]int64, error) {
+	ids, err := tx.FindTaskWatcherIDs(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range []string{before[models.TaskFieldOwnerID], after[models.TaskFieldOwnerID]} {
+		if v == "" {
+			continue
+		}
+		oid, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner id: %w", err)
+		}
+		if !containsID(ids, oid) {
+			ids = append(ids, oid)
+		}
+	}
+	return ids, nil
+}
+
+func recordTaskChange(ctx context.Context, tx store.Store, taskID int64, field, oldValue, newValue string) (*models.TaskChange, error) {
+	actor := ActorFrom(ctx)
+	change, err := tx.CreateTaskChange(ctx, &dto.TaskChange{
+		TaskID:   taskID,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+		ActorID:  actor,
+	})
+	if store.IsErrForeignKeyViolation(err) {
+		return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid actor id: %d", actor)}
+	}
+	return change, err
+}
+
+// ListTaskHistory lists the changes of the task id, oldest first.
+func (ts *TaskService) ListTaskHistory(ctx context.Context, id int64, opts dto.ListOptions) (*dto.TaskChangeList, error) {
+	if _, err := ts.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+	total, err := ts.store.CountTaskChanges(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := ts.store.FindTaskChanges(ctx, id, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	changes, next := nextPage(changes, opts, func(c *models.TaskChange) string { return dto.EncodeCursor(c.ID, nil) })
+	items := make([]*dto.TaskChange, len(changes))
+	for i := range changes {
+		items[i] = newTaskChangeDTO(changes[i])
+	}
+	return &dto.TaskChangeList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}, nil
+}
+
+// RevertTask sets the fields of the task id back to their values right after
+// the change historyID, and returns the updated task. The revert is recorded
+// as new changes, and goes through the same checks as UpdateTask: the owner
+// has to work in the hospital of the task, and the status has to be reachable
+// from the current one. version works like the one of UpdateTask.
+func (ts *TaskService) RevertTask(ctx context.Context, id, historyID, version int64) (*dto.Task, error) {
+	var task *dto.Task
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		t, err := tx.GetTask(ctx, id)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+			}
+			return err
+		}
+		change, err := tx.GetTaskChange(ctx, historyID)
+		if err != nil && !store.IsErrNotFound(err) {
+			return err
+		}
+		if err != nil || change.TaskID != id {
+			return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid history id: %d", historyID)}
+		}
+		// The value of a field right after the change is the old value of
+		// its next change, or its current value if it didn't change since.
+		later, err := tx.FindTaskChanges(ctx, id, dto.ListOptions{AfterID: historyID, Limit: math.MaxInt32})
+		if err != nil {
+			return err
+		}
+		task = newTaskDTO(t)
+		current := taskValues(task)
+		values := taskValues(task)
+		seen := make(map[string]bool)
+		for _, c := range later {
+			if _, ok := values[c.Field]; ok && !seen[c.Field] {
+				values[c.Field] = c.OldValue
+				seen[c.Field] = true
+			}
+		}
+		if err := setTaskValues(task, values); err != nil {
+			return err
+		}
+		if values[models.TaskFieldOwnerID] != current[models.TaskFieldOwnerID] && task.OwnerID != 0 {
+			if err := checkOwner(ctx, tx, task.HospitalID, task.OwnerID); err != nil {
+				return err
+			}
+		}
+		task.Version = version
+		if err := updateTask(ctx, tx, task); err != nil {
+			return err
+		}
+		t, err = tx.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		task = newTaskDTO(t)
+		return setTaskLabels(ctx, tx, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func newTaskChangeDTO(c *models.TaskChange) *dto.TaskChange {
+	change := &dto.TaskChange{
+		ID:        c.ID,
+		TaskID:    c.TaskID,
+		Field:     c.Field,
+		OldValue:  c.OldValue,
+		NewValue:  c.NewValue,
+		CreatedAt: c.CreatedAt,
+	}
+	if c.ActorID != nil {
+		change.ActorID = *c.ActorID
+	}
+	return change
+}
@@ -3,86 +3,224 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"github.com/go-logr/logr"
 
 	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
 	"github.com/liuerfire/boxpractice/pkg/store"
 )
 
 type HospitalService struct {
-	logger   logr.Logger
-	sqlStore *store.SQLStore
+	logger logr.Logger
+	store  store.Store
 }
 
-func ProvideHospitalService(logger logr.Logger, sqlStore *store.SQLStore) *HospitalService {
+func ProvideHospitalService(logger logr.Logger, s store.Store) *HospitalService {
 	return &HospitalService{
-		logger:   logger.WithName("hospitalService"),
-		sqlStore: sqlStore,
+		logger: logger.WithName("hospitalService"),
+		store:  s,
 	}
 }
 
+// CreateHospital creates the hospital, whose time zone is UTC, whose tasks
+// are assigned in turn and whose off-shift assignments are allowed unless
+// set.
 func (hs *HospitalService) CreateHospital(ctx context.Context, h *dto.Hospital) (*dto.Hospital, error) {
-	hospital, err := hs.sqlStore.CreateHospital(ctx, h)
+	if h.Timezone == "" {
+		h.Timezone = "UTC"
+	}
+	if h.AssignmentStrategy == "" {
+		h.AssignmentStrategy = models.AssignmentRoundRobin
+	}
+	if _, err := getAssignmentStrategy(h.AssignmentStrategy); err != nil {
+		return nil, err
+	}
+	if h.OffShiftAssignment == "" {
+		h.OffShiftAssignment = models.OffShiftAllow
+	}
+	if err := checkOffShiftAssignment(h.OffShiftAssignment); err != nil {
+		return nil, err
+	}
+	hospital, err := hs.store.CreateHospital(ctx, h)
 	if err != nil {
 		if store.IsErrDuplicateEntry(err) {
 			return nil, &ServiceError{ErrAlreadyExists, fmt.Sprintf("username exists: %s", h.Name)}
 		}
 		return nil, err
 	}
-	return &dto.Hospital{
-		ID:          hospital.ID,
-		Name:        hospital.Name,
-		DisplayName: hospital.DisplayName,
-		CreatedAt:   hospital.CreatedAt,
-	}, nil
+	return newHospitalDTO(hospital), nil
 }
 
-func (hs *HospitalService) ListHospitals(ctx context.Context, page, limit uint) (*dto.HospitalList, error) {
-	total, err := hs.sqlStore.CountHosptials(ctx)
+func (hs *HospitalService) ListHospitals(ctx context.Context, opts dto.ListOptions) (*dto.HospitalList, error) {
+	total, err := hs.store.CountHosptials(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
-	hospitals, err := hs.sqlStore.FindHospitals(ctx, page, limit)
+	hospitals, err := hs.store.FindHospitals(ctx, pageOptions(opts))
 	if err != nil {
 		return nil, err
 	}
+	hospitals, next := nextPage(hospitals, opts, func(h *models.Hospital) string { return dto.EncodeCursor(h.ID, nil) })
 	items := make([]*dto.Hospital, len(hospitals))
 	for i := range hospitals {
-		hospital := hospitals[i]
-		items[i] = &dto.Hospital{
-			ID:          hospital.ID,
-			Name:        hospital.Name,
-			DisplayName: hospital.DisplayName,
-			CreatedAt:   hospital.CreatedAt,
-		}
+		items[i] = newHospitalDTO(hospitals[i])
 	}
 	return &dto.HospitalList{
-		Total: total,
-		Items: items,
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
 	}, nil
 }
 
 func (hs *HospitalService) GetHospital(ctx context.Context, hid int64) (*dto.Hospital, error) {
-	hospital, err := hs.sqlStore.GetHospital(ctx, hid)
+	hospital, err := hs.store.GetHospital(ctx, hid)
 	if err != nil {
 		if store.IsErrNotFound(err) {
 			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", hid)}
 		}
 		return nil, err
 	}
-	return &dto.Hospital{
-		ID:          hospital.ID,
-		Name:        hospital.Name,
-		DisplayName: hospital.DisplayName,
-		CreatedAt:   hospital.CreatedAt,
-	}, nil
+	return newHospitalDTO(hospital), nil
 }
 
+// UpdateHospital updates the hospital, whose tasks are assigned in turn and
+// whose off-shift assignments are allowed unless set. If h.Version is set,
+// the update fails with ErrPreconditionFailed unless the hospital is still
+// at that version.
 func (hs *HospitalService) UpdateHospital(ctx context.Context, h *dto.Hospital) error {
-	r, err := hs.sqlStore.UpdateHospital(ctx, h)
+	if h.AssignmentStrategy == "" {
+		h.AssignmentStrategy = models.AssignmentRoundRobin
+	}
+	if _, err := getAssignmentStrategy(h.AssignmentStrategy); err != nil {
+		return err
+	}
+	if h.OffShiftAssignment == "" {
+		h.OffShiftAssignment = models.OffShiftAllow
+	}
+	if err := checkOffShiftAssignment(h.OffShiftAssignment); err != nil {
+		return err
+	}
+	r, err := hs.store.UpdateHospital(ctx, h)
+	if err != nil {
+		if store.IsErrDuplicateEntry(err) {
+			return &ServiceError{ErrAlreadyExists, fmt.Sprintf("name exists: %s", h.Name)}
+		}
+		return err
+	}
 	if r == 0 {
-		return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", h.ID)}
+		if _, err := hs.GetHospital(ctx, h.ID); err != nil {
+			return err
+		}
+		return &ServiceError{ErrPreconditionFailed, fmt.Sprintf("version mismatch: %d", h.Version)}
+	}
+	return nil
+}
+
+// DeleteHospital soft-deletes the hospital. If it still has open tasks,
+// DeleteRestrict fails with ErrConflict while DeleteCascade deletes all its
+// tasks and employees along, recording the deletion of each task. They are
+// deleted at the same time as the hospital, which is how RestoreHospital
+// tells them from the ones deleted before.
+func (hs *HospitalService) DeleteHospital(ctx context.Context, hid int64, opts dto.DeleteOptions) error {
+	return hs.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.GetHospital(ctx, hid); err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", hid)}
+			}
+			return err
+		}
+		switch opts.Policy {
+		case "", dto.DeleteRestrict:
+			n, err := tx.CountOpenTasksByHospital(ctx, hid)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return &ServiceError{ErrConflict, fmt.Sprintf("hospital has %d open tasks", n)}
+			}
+		case dto.DeleteCascade:
+			// Its tasks and employees are deleted once it is, see below.
+		default:
+			return &ServiceError{ErrBadArgument, fmt.Sprintf("invalid policy: %s", opts.Policy)}
+		}
+		if _, err := tx.DeleteHospital(ctx, hid); err != nil {
+			return err
+		}
+		if opts.Policy != dto.DeleteCascade {
+			return nil
+		}
+		deleted, err := tx.GetDeletedHospital(ctx, hid)
+		if err != nil {
+			return err
+		}
+		tasks, err := tx.FindTasksByHospital(ctx, hid, dto.TaskFilter{}, dto.ListOptions{Limit: math.MaxInt32})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.DeleteTasksByHospital(ctx, hid, *deleted.DeletedAt); err != nil {
+			return err
+		}
+		if err := recordTasksDeleted(ctx, tx, tasks); err != nil {
+			return err
+		}
+		_, err = tx.DeleteEmployeesByHospital(ctx, hid, *deleted.DeletedAt)
+		return err
+	})
+}
+
+// RestoreHospital undoes DeleteHospital, restoring along the employees and
+// tasks DeleteCascade deleted with it. The ones deleted before stay deleted.
+// Restoring a hospital which isn't deleted does nothing.
+func (hs *HospitalService) RestoreHospital(ctx context.Context, hid int64) error {
+	return hs.store.WithTx(ctx, func(tx store.Store) error {
+		deleted, err := tx.GetDeletedHospital(ctx, hid)
+		if err != nil {
+			if !store.IsErrNotFound(err) {
+				return err
+			}
+			if _, err := tx.GetHospital(ctx, hid); err != nil {
+				if store.IsErrNotFound(err) {
+					return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", hid)}
+				}
+				return err
+			}
+			return nil
+		}
+		if _, err := tx.RestoreHospital(ctx, hid); err != nil {
+			return err
+		}
+		employees, err := tx.FindEmployees(ctx, hid, dto.ListOptions{Limit: math.MaxInt32, IncludeDeleted: true})
+		if err != nil {
+			return err
+		}
+		for _, e := range employees {
+			if e.DeletedAt != nil && e.DeletedAt.Equal(*deleted.DeletedAt) {
+				if _, err := tx.RestoreEmployee(ctx, e.ID); err != nil {
+					return err
+				}
+			}
+		}
+		tasks, err := tx.FindTasksByHospital(ctx, hid, dto.TaskFilter{}, dto.ListOptions{Limit: math.MaxInt32, IncludeDeleted: true})
+		if err != nil {
+			return err
+		}
+		return restoreTasksDeletedAt(ctx, tx, tasks, *deleted.DeletedAt)
+	})
+}
+
+func newHospitalDTO(hospital *models.Hospital) *dto.Hospital {
+	return &dto.Hospital{
+		ID:                 hospital.ID,
+		Name:               hospital.Name,
+		DisplayName:        hospital.DisplayName,
+		Timezone:           hospital.Timezone,
+		AssignmentStrategy: hospital.AssignmentStrategy,
+		AutoAssign:         hospital.AutoAssign,
+		OffShiftAssignment: hospital.OffShiftAssignment,
+		Version:            hospital.Version,
+		CreatedAt:          hospital.CreatedAt,
+		DeletedAt:          hospital.DeletedAt,
 	}
-	return err
 }
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+type LabelService struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideLabelService(logger logr.Logger, s store.Store) *LabelService {
+	return &LabelService{
+		logger: logger.WithName("labelService"),
+		store:  s,
+	}
+}
+
+// CreateLabel creates a label in the hospital l.HospitalID. The names of the
+// labels are unique in a hospital.
+func (ls *LabelService) CreateLabel(ctx context.Context, l *dto.Label) (*dto.Label, error) {
+	if _, err := ls.store.GetHospital(ctx, l.HospitalID); err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", l.HospitalID)}
+		}
+		return nil, err
+	}
+	label, err := ls.store.CreateLabel(ctx, l)
+	if err != nil {
+		if store.IsErrDuplicateEntry(err) {
+			return nil, &ServiceError{ErrAlreadyExists, fmt.Sprintf("name exists: %s", l.Name)}
+		}
+		return nil, err
+	}
+	return newLabelDTO(label), nil
+}
+
+// ListLabels lists the labels of the hospital hid, oldest first.
+func (ls *LabelService) ListLabels(ctx context.Context, hid int64, opts dto.ListOptions) (*dto.LabelList, error) {
+	total, err := ls.store.CountLabels(ctx, hid)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := ls.store.FindLabels(ctx, hid, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	labels, next := nextPage(labels, opts, func(l *models.Label) string { return dto.EncodeCursor(l.ID, nil) })
+	items := make([]*dto.Label, len(labels))
+	for i := range labels {
+		items[i] = newLabelDTO(labels[i])
+	}
+	return &dto.LabelList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}, nil
+}
+
+func (ls *LabelService) GetLabel(ctx context.Context, id int64) (*dto.Label, error) {
+	label, err := getLabel(ctx, ls.store, id)
+	if err != nil {
+		return nil, err
+	}
+	return newLabelDTO(label), nil
+}
+
+// UpdateLabel renames or recolours the label l.ID.
+func (ls *LabelService) UpdateLabel(ctx context.Context, l *dto.Label) (*dto.Label, error) {
+	var label *models.Label
+	err := ls.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := getLabel(ctx, tx, l.ID); err != nil {
+			return err
+		}
+		if _, err := tx.UpdateLabel(ctx, l); err != nil {
+			if store.IsErrDuplicateEntry(err) {
+				return &ServiceError{ErrAlreadyExists, fmt.Sprintf("name exists: %s", l.Name)}
+			}
+			return err
+		}
+		var err error
+		label, err = tx.GetLabel(ctx, l.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newLabelDTO(label), nil
+}
+
+// DeleteLabel deletes the label id, which is removed from all its tasks.
+func (ls *LabelService) DeleteLabel(ctx context.Context, id int64) error {
+	return ls.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := getLabel(ctx, tx, id); err != nil {
+			return err
+		}
+		_, err := tx.DeleteLabel(ctx, id)
+		return err
+	})
+}
+
+// LabelTask tags the task taskID with the label labelID, which has to belong
+// to the hospital of the task. Tagging a task twice with a label does
+// nothing.
+func (ls *LabelService) LabelTask(ctx context.Context, taskID, labelID int64) error {
+	return ls.store.WithTx(ctx, func(tx store.Store) error {
+		task, err := getTask(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+		label, err := getLabel(ctx, tx, labelID)
+		if err != nil {
+			return err
+		}
+		return labelTask(ctx, tx, task, label)
+	})
+}
+
+// labelTask tags the task with the label, see LabelTask.
+func labelTask(ctx context.Context, tx store.Store, task *models.Task, label *models.Label) error {
+	if label.HospitalID != task.HospitalID {
+		return &ServiceError{ErrPermissionDenied, "the label belongs to another hospital"}
+	}
+	labels, err := tx.FindTaskLabels(ctx, []int64{task.ID})
+	if err != nil {
+		return err
+	}
+	for _, l := range labels[task.ID] {
+		if l.ID == label.ID {
+			return nil
+		}
+	}
+	return tx.CreateTaskLabel(ctx, task.ID, label.ID)
+}
+
+// UnlabelTask removes the label labelID from the task taskID.
+func (ls *LabelService) UnlabelTask(ctx context.Context, taskID, labelID int64) error {
+	if _, err := getTask(ctx, ls.store, taskID); err != nil {
+		return err
+	}
+	r, err := ls.store.DeleteTaskLabel(ctx, taskID, labelID)
+	if err != nil {
+		return err
+	}
+	if r == 0 {
+		return &ServiceError{ErrResourceNotFound, fmt.Sprintf("the task has no label %d", labelID)}
+	}
+	return nil
+}
+
+func getLabel(ctx context.Context, s store.LabelStore, id int64) (*models.Label, error) {
+	label, err := s.GetLabel(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid label id: %d", id)}
+		}
+		return nil, err
+	}
+	return label, nil
+}
+
+// setTaskLabels sets the labels of the tasks.
+func setTaskLabels(ctx context.Context, s store.LabelStore, tasks ...*dto.Task) error {
+	ids := make([]int64, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	labels, err := s.FindTaskLabels(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		t.Labels = nil
+		for _, l := range labels[t.ID] {
+			t.Labels = append(t.Labels, newLabelDTO(l))
+		}
+	}
+	return nil
+}
+
+func newLabelDTO(l *models.Label) *dto.Label {
+	return &dto.Label{
+		ID:         l.ID,
+		HospitalID: l.HospitalID,
+		Name:       l.Name,
+		Color:      l.Color,
+		CreatedAt:  l.CreatedAt,
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// OverdueSweeper periodically flags the open tasks which are past their due
+// date, see store.TaskStore.SweepOverdueTasks.
+type OverdueSweeper struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideOverdueSweeper(logger logr.Logger, s store.Store) *OverdueSweeper {
+	return &OverdueSweeper{
+		logger: logger.WithName("overdueSweeper"),
+		store:  s,
+	}
+}
+
+// Run sweeps every interval until ctx is done. A failed sweep is logged and
+// retried at the next tick.
+func (sw *OverdueSweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := sw.Sweep(ctx); err != nil && ctx.Err() == nil {
+			sw.logger.Error(err, "failed to sweep the overdue tasks")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep flags the tasks which are overdue now.
+func (sw *OverdueSweeper) Sweep(ctx context.Context) error {
+	n, err := sw.store.SweepOverdueTasks(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		sw.logger.V(1).Info("flagged overdue tasks", "count", n)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+// pageOptions returns the options fetching one more item than opts asks
+// for, which tells nextPage whether there is a next page.
+func pageOptions(opts dto.ListOptions) dto.ListOptions {
+	opts.Limit++
+	return opts
+}
+
+// nextPage drops the extra item fetched with pageOptions and returns the
+// cursor of the next page if there is one, which starts after the last item.
+func nextPage[T any](items []T, opts dto.ListOptions, cursor func(T) string) ([]T, string) {
+	if uint(len(items)) <= opts.Limit {
+		return items, ""
+	}
+	items = items[:opts.Limit]
+	if len(items) == 0 {
+		return items, ""
+	}
+	return items, cursor(items[len(items)-1])
+}
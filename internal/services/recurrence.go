@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	// The time zones of the hospitals mustn't depend on the host.
+	_ "time/tzdata"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/cron"
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+type RecurrenceService struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideRecurrenceService(logger logr.Logger, s store.Store) *RecurrenceService {
+	return &RecurrenceService{
+		logger: logger.WithName("recurrenceService"),
+		store:  s,
+	}
+}
+
+// CreateTaskRecurrence creates a recurrence in the hospital r.HospitalID.
+// Its owners have to be employees of the same hospital. The first task is
+// created at the first occurrence of the schedule after now.
+func (rs *RecurrenceService) CreateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (*dto.TaskRecurrence, error) {
+	var recurrence *dto.TaskRecurrence
+	err := rs.store.WithTx(ctx, func(tx store.Store) error {
+		hospital, err := tx.GetHospital(ctx, r.HospitalID)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", r.HospitalID)}
+			}
+			return err
+		}
+		if r.NextAt, err = firstOccurrence(hospital, r.Schedule, time.Now()); err != nil {
+			return err
+		}
+		for _, oid := range r.OwnerIDs {
+			if err := checkOwner(ctx, tx, r.HospitalID, oid); err != nil {
+				return err
+			}
+		}
+		created, err := tx.CreateTaskRecurrence(ctx, r)
+		if err != nil {
+			return err
+		}
+		if err := tx.SetTaskRecurrenceOwners(ctx, created.ID, r.OwnerIDs); err != nil {
+			return err
+		}
+		recurrence = newTaskRecurrenceDTO(created, r.OwnerIDs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recurrence, nil
+}
+
+// ListTaskRecurrences lists the recurrences of the hospital hid, oldest
+// first.
+func (rs *RecurrenceService) ListTaskRecurrences(ctx context.Context, hid int64, opts dto.ListOptions) (*dto.TaskRecurrenceList, error) {
+	total, err := rs.store.CountTaskRecurrences(ctx, hid, opts)
+	if err != nil {
+		return nil, err
+	}
+	recurrences, err := rs.store.FindTaskRecurrences(ctx, hid, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	recurrences, next := nextPage(recurrences, opts, func(r *models.TaskRecurrence) string { return dto.EncodeCursor(r.ID, nil) })
+	ids := make([]int64, len(recurrences))
+	for i := range recurrences {
+		ids[i] = recurrences[i].ID
+	}
+	owners, err := rs.store.FindTaskRecurrenceOwners(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*dto.TaskRecurrence, len(recurrences))
+	for i, r := range recurrences {
+		items[i] = newTaskRecurrenceDTO(r, owners[r.ID])
+	}
+	return &dto.TaskRecurrenceList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}, nil
+}
+
+func (rs *RecurrenceService) GetTaskRecurrence(ctx context.Context, id int64) (*dto.TaskRecurrence, error) {
+	recurrence, err := getTaskRecurrence(ctx, rs.store, id)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := rs.store.FindTaskRecurrenceOwners(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	return newTaskRecurrenceDTO(recurrence, owners[id]), nil
+}
+
+// UpdateTaskRecurrence updates the template, the schedule and the owners of
+// the recurrence r.ID. A new schedule starts after now. If r.Version is
+// set, the update fails with ErrPreconditionFailed unless the recurrence is
+// still at that version.
+func (rs *RecurrenceService) UpdateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (*dto.TaskRecurrence, error) {
+	var recurrence *dto.TaskRecurrence
+	err := rs.store.WithTx(ctx, func(tx store.Store) error {
+		current, err := getTaskRecurrence(ctx, tx, r.ID)
+		if err != nil {
+			return err
+		}
+		hospital, err := tx.GetHospital(ctx, current.HospitalID)
+		if err != nil {
+			return err
+		}
+		r.NextAt = current.NextAt
+		if r.Schedule != current.Schedule {
+			if r.NextAt, err = firstOccurrence(hospital, r.Schedule, time.Now()); err != nil {
+				return err
+			}
+		}
+		for _, oid := range r.OwnerIDs {
+			if err := checkOwner(ctx, tx, current.HospitalID, oid); err != nil {
+				return err
+			}
+		}
+		n, err := tx.UpdateTaskRecurrence(ctx, r)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return &ServiceError{ErrPreconditionFailed, fmt.Sprintf("version mismatch: %d", r.Version)}
+		}
+		if err := tx.SetTaskRecurrenceOwners(ctx, r.ID, r.OwnerIDs); err != nil {
+			return err
+		}
+		updated, err := tx.GetTaskRecurrence(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+		recurrence = newTaskRecurrenceDTO(updated, r.OwnerIDs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recurrence, nil
+}
+
+// DeleteTaskRecurrence soft-deletes the recurrence, which stops creating
+// tasks. The tasks it created are kept.
+func (rs *RecurrenceService) DeleteTaskRecurrence(ctx context.Context, id int64) error {
+	r, err := rs.store.DeleteTaskRecurrence(ctx, id)
+	if err != nil {
+		return err
+	}
+	if r == 0 {
+		return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+	}
+	return nil
+}
+
+// getTaskRecurrence returns the recurrence id, or ErrResourceNotFound if
+// there is none.
+func getTaskRecurrence(ctx context.Context, s store.TaskRecurrenceStore, id int64) (*models.TaskRecurrence, error) {
+	recurrence, err := s.GetTaskRecurrence(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+		}
+		return nil, err
+	}
+	return recurrence, nil
+}
+
+// hospitalLocation returns the time zone of the hospital, UTC if it has
+// none.
+func hospitalLocation(h *models.Hospital) (*time.Location, error) {
+	return time.LoadLocation(h.Timezone)
+}
+
+// firstOccurrence returns the first occurrence of the schedule after now, in
+// the time zone of the hospital.
+func firstOccurrence(h *models.Hospital, schedule string, now time.Time) (time.Time, error) {
+	sched, err := cron.Parse(schedule)
+	if err != nil {
+		return time.Time{}, &ServiceError{ErrBadArgument, err.Error()}
+	}
+	loc, err := hospitalLocation(h)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := sched.Next(now.In(loc))
+	if next.IsZero() {
+		return time.Time{}, &ServiceError{ErrBadArgument, fmt.Sprintf("the schedule never occurs: %s", schedule)}
+	}
+	return next, nil
+}
+
+func newTaskRecurrenceDTO(r *models.TaskRecurrence, ownerIDs []int64) *dto.TaskRecurrence {
+	return &dto.TaskRecurrence{
+		ID:          r.ID,
+		HospitalID:  r.HospitalID,
+		Title:       r.Title,
+		Description: r.Description,
+		Priority:    r.Priority,
+		DueIn:       r.DueIn,
+		Schedule:    r.Schedule,
+		OwnerIDs:    ownerIDs,
+		NextAt:      r.NextAt,
+		Version:     r.Version,
+		CreatedAt:   r.CreatedAt,
+		DeletedAt:   r.DeletedAt,
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/cron"
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// schedulerBatchSize is the number of recurrences handled by one run of the
+// scheduler. The other due ones wait for the next run.
+const schedulerBatchSize = 100
+
+// errScheduled aborts the transaction of an occurrence which another
+// scheduler got first.
+var errScheduled = errors.New("occurrence already scheduled")
+
+// TaskScheduler periodically creates the tasks of the recurrences whose
+// next occurrence is due.
+//
+// Several schedulers can run against the same database, in other processes
+// or replicas: each occurrence is handled in a transaction which only
+// commits if the recurrence hasn't moved on meanwhile, and the tasks are
+// unique per recurrence and occurrence.
+type TaskScheduler struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideTaskScheduler(logger logr.Logger, s store.Store) *TaskScheduler {
+	return &TaskScheduler{
+		logger: logger.WithName("taskScheduler"),
+		store:  s,
+	}
+}
+
+// Run schedules every interval until ctx is done. A failed run is logged and
+// retried at the next tick.
+func (sc *TaskScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := sc.Schedule(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			sc.logger.Error(err, "failed to schedule the recurring tasks")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Schedule creates the tasks of the recurrences which are due at now. A
+// recurrence which fails is logged and doesn't stop the other ones.
+func (sc *TaskScheduler) Schedule(ctx context.Context, now time.Time) error {
+	ids, err := sc.store.FindDueTaskRecurrenceIDs(ctx, now, schedulerBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := sc.scheduleRecurrence(ctx, id, now); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			sc.logger.Error(err, "failed to schedule the recurrence", "id", id)
+		}
+	}
+	return nil
+}
+
+// scheduleRecurrence creates the task of the latest occurrence of the
+// recurrence id due at now, and moves the recurrence to its next occurrence.
+// The occurrences missed while no scheduler ran get no task.
+func (sc *TaskScheduler) scheduleRecurrence(ctx context.Context, id int64, now time.Time) error {
+	err := sc.store.WithTx(ctx, func(tx store.Store) error {
+		r, err := tx.GetTaskRecurrence(ctx, id)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if r.NextAt.After(now) {
+			return errScheduled
+		}
+		hospital, err := tx.GetHospital(ctx, r.HospitalID)
+		if err != nil {
+			return err
+		}
+		loc, err := hospitalLocation(hospital)
+		if err != nil {
+			return err
+		}
+		sched, err := cron.Parse(r.Schedule)
+		if err != nil {
+			return err
+		}
+		at, next := r.NextAt, sched.Next(r.NextAt.In(loc))
+		for !next.IsZero() && !next.After(now) {
+			at, next = next, sched.Next(next)
+		}
+
+		owners, err := tx.FindTaskRecurrenceOwners(ctx, []int64{id})
+		if err != nil {
+			return err
+		}
+		oid, err := pickOwner(ctx, tx, r.HospitalID, owners[id], r.Occurrences)
+		if err != nil {
+			return err
+		}
+		if oid == 0 {
+			sc.logger.Info("no owner available, skipping the occurrence", "id", id, "at", at)
+		} else if err := createOccurrence(ctx, tx, r, oid, at); err != nil {
+			if store.IsErrDuplicateEntry(err) {
+				return errScheduled
+			}
+			return err
+		}
+
+		if next.IsZero() {
+			sc.logger.Info("the schedule never occurs again, deleting the recurrence", "id", id)
+			_, err := tx.DeleteTaskRecurrence(ctx, id)
+			return err
+		}
+		n, err := tx.AdvanceTaskRecurrence(ctx, id, r.Occurrences, next)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errScheduled
+		}
+		return nil
+	})
+	if errors.Is(err, errScheduled) {
+		return nil
+	}
+	return err
+}
+
+// pickOwner returns the owner of the occurrence number n: the next employee
+// of the rotation from the n-th one which can still own tasks of the
+// hospital hid, or 0 if none can.
+func pickOwner(ctx context.Context, s store.EmployeeStore, hid int64, ownerIDs []int64, n int64) (int64, error) {
+	for i := range ownerIDs {
+		oid := ownerIDs[(n+int64(i))%int64(len(ownerIDs))]
+		err := checkOwner(ctx, s, hid, oid)
+		if err == nil {
+			return oid, nil
+		}
+		var svcErr *ServiceError
+		if !errors.As(err, &svcErr) {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// createOccurrence creates the task of the recurrence r for its occurrence
+// at.
+func createOccurrence(ctx context.Context, tx store.Store, r *models.TaskRecurrence, oid int64, at time.Time) error {
+	at = at.UTC()
+	t := &dto.Task{
+		HospitalID:   r.HospitalID,
+		OwnerID:      oid,
+		RecurrenceID: r.ID,
+		OccurrenceAt: &at,
+		Title:        r.Title,
+		Description:  r.Description,
+		Priority:     r.Priority,
+		Status:       models.TaskStatusOpen,
+	}
+	if r.DueIn > 0 {
+		dueAt := at.Add(time.Duration(r.DueIn) * time.Second)
+		t.DueAt = &dueAt
+	}
+	_, err := createTask(ctx, tx, t)
+	return err
+}
@@ -0,0 +1,1382 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liuerfire/boxpractice/database/migrations"
+	"github.com/liuerfire/boxpractice/pkg/blob"
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+func assertErrCode(t *testing.T, code ErrCode, err error) {
+	t.Helper()
+	var svcErr *ServiceError
+	if assert.ErrorAs(t, err, &svcErr) {
+		assert.Equal(t, code, svcErr.Code)
+	}
+}
+
+// zeros is an endless reader of zeros.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestServices(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	logger := logr.Discard()
+
+	hospitalService := ProvideHospitalService(logger, s)
+	employeeService := ProvideEmployeeService(logger, s)
+	taskService := ProvideTaskService(logger, s)
+	commentService := ProvideCommentService(logger, s)
+	blobDir := t.TempDir()
+	blobStore, err := blob.NewFSStore(blobDir)
+	require.NoError(t, err)
+	attachmentService := ProvideAttachmentService(logger, s, blobStore)
+	labelService := ProvideLabelService(logger, s)
+
+	hospital, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc"})
+	require.NoError(t, err)
+
+	t.Run("Hospital", func(t *testing.T) {
+		_, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc"})
+		assertErrCode(t, ErrAlreadyExists, err)
+
+		_, err = hospitalService.GetHospital(ctx, hospital.ID+100)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		err = hospitalService.UpdateHospital(ctx, &dto.Hospital{ID: hospital.ID + 100, Name: "x"})
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		err = hospitalService.UpdateHospital(ctx, &dto.Hospital{ID: hospital.ID, Name: "svc", Version: hospital.Version + 1})
+		assertErrCode(t, ErrPreconditionFailed, err)
+		err = hospitalService.UpdateHospital(ctx, &dto.Hospital{ID: hospital.ID, Name: "svc", Version: hospital.Version})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Employee", func(t *testing.T) {
+		employee, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "e"})
+		require.NoError(t, err)
+
+		_, err = employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "e"})
+		assertErrCode(t, ErrAlreadyExists, err)
+
+		_, err = employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID + 100, Username: "e2"})
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		list, err := employeeService.ListEmployees(ctx, hospital.ID, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+		assert.Equal(t, employee.ID, list.Items[0].ID)
+	})
+
+	t.Run("Task", func(t *testing.T) {
+		owner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "owner"})
+		require.NoError(t, err)
+
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    owner.ID,
+			Title:      "t",
+			Priority:   models.TaskPriorityLow,
+			Status:     models.TaskStatusOpen,
+		})
+		require.NoError(t, err)
+
+		// The status only changes through the transitions.
+		task.Status = models.TaskStatusInProgress
+		assertErrCode(t, ErrInvalidTransition, taskService.UpdateTask(ctx, task))
+		_, err = taskService.TransitionTask(ctx, task.ID, models.TaskStatusInProgress, 0)
+		require.NoError(t, err)
+
+		got, err := taskService.TransitionTask(ctx, task.ID, models.TaskStatusCOMPLETED, 0)
+		require.NoError(t, err)
+		assert.Equal(t, models.TaskStatusCOMPLETED, got.Status)
+		got, err = taskService.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.TaskStatusCOMPLETED, got.Status)
+
+		// Not even to an allowed one like reopening.
+		got.Status = models.TaskStatusOpen
+		assertErrCode(t, ErrInvalidTransition, taskService.UpdateTask(ctx, got))
+
+		_, err = taskService.GetTask(ctx, task.ID+100)
+		assertErrCode(t, ErrResourceNotFound, err)
+	})
+
+	t.Run("TaskLifecycle", func(t *testing.T) {
+		owner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "lifecycle"})
+		require.NoError(t, err)
+		_, err = taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    owner.ID,
+			Title:      "t",
+			Priority:   models.TaskPriorityLow,
+			Status:     models.TaskStatusCOMPLETED,
+		})
+		assertErrCode(t, ErrInvalidTransition, err)
+
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    owner.ID,
+			Title:      "t",
+			Priority:   models.TaskPriorityLow,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.TaskStatusOpen, task.Status)
+
+		_, err = taskService.TransitionTask(ctx, task.ID, models.TaskStatusFAILED, 0)
+		assertErrCode(t, ErrInvalidTransition, err)
+		_, err = taskService.TransitionTask(ctx, task.ID, models.TaskStatusInProgress, task.Version+1)
+		assertErrCode(t, ErrPreconditionFailed, err)
+		for _, status := range []string{models.TaskStatusInProgress, models.TaskStatusBlocked, models.TaskStatusFAILED, models.TaskStatusOpen} {
+			_, err = taskService.TransitionTask(ctx, task.ID, status, 0)
+			require.NoError(t, err, status)
+		}
+
+		list, err := taskService.ListTaskTransitions(ctx, task.ID)
+		require.NoError(t, err)
+		var statuses []string
+		for _, transition := range list.Items {
+			statuses = append(statuses, transition.To)
+			assert.False(t, transition.At.IsZero())
+		}
+		assert.Equal(t, []string{
+			models.TaskStatusOpen, models.TaskStatusInProgress, models.TaskStatusBlocked,
+			models.TaskStatusFAILED, models.TaskStatusOpen,
+		}, statuses)
+		assert.Equal(t, models.TaskStatusFAILED, list.Items[4].From)
+	})
+
+	t.Run("OverdueSweeper", func(t *testing.T) {
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-overdue"})
+		require.NoError(t, err)
+		owner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "overdue"})
+		require.NoError(t, err)
+		newTask := func(due time.Time) *dto.Task {
+			task, err := taskService.CreateTask(ctx, &dto.Task{
+				HospitalID: h.ID,
+				OwnerID:    owner.ID,
+				Title:      "t",
+				Priority:   models.TaskPriorityLow,
+				DueAt:      &due,
+			})
+			require.NoError(t, err)
+			return task
+		}
+		late := newTask(time.Now().Add(-time.Hour))
+		newTask(time.Now().Add(time.Hour))
+
+		overdue := dto.TaskFilter{Overdue: true}
+		list, err := taskService.ListTasksByHospital(ctx, h.ID, overdue, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), list.Total)
+
+		sweeper := ProvideOverdueSweeper(logger, s)
+		require.NoError(t, sweeper.Sweep(ctx))
+		list, err = taskService.ListTasksByHospital(ctx, h.ID, overdue, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		if assert.Equal(t, uint(1), list.Total) {
+			assert.Equal(t, late.ID, list.Items[0].ID)
+			assert.True(t, list.Items[0].Overdue)
+		}
+
+		// Pushing the due date back clears the flag at the next sweep.
+		later := time.Now().Add(time.Hour)
+		late.DueAt = &later
+		require.NoError(t, taskService.UpdateTask(ctx, late))
+		require.NoError(t, sweeper.Sweep(ctx))
+		got, err := taskService.GetTask(ctx, late.ID)
+		require.NoError(t, err)
+		assert.False(t, got.Overdue)
+	})
+
+	t.Run("TaskScheduler", func(t *testing.T) {
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-recurrence", Timezone: "Asia/Tokyo"})
+		require.NoError(t, err)
+		alice, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "round-alice"})
+		require.NoError(t, err)
+		bob, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "round-bob"})
+		require.NoError(t, err)
+		recurrenceService := ProvideRecurrenceService(logger, s)
+
+		newRecurrence := func(schedule string, ownerIDs ...int64) (*dto.TaskRecurrence, error) {
+			return recurrenceService.CreateTaskRecurrence(ctx, &dto.TaskRecurrence{
+				HospitalID: h.ID,
+				Title:      "medication round",
+				Priority:   models.TaskPriorityHight,
+				DueIn:      1800,
+				Schedule:   schedule,
+				OwnerIDs:   ownerIDs,
+			})
+		}
+		_, err = newRecurrence("0 25 * * *", alice.ID)
+		assertErrCode(t, ErrBadArgument, err)
+		_, err = newRecurrence("0 0 30 2 *", alice.ID)
+		assertErrCode(t, ErrBadArgument, err)
+		foreigner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "round-foreigner"})
+		require.NoError(t, err)
+		_, err = newRecurrence("@daily", foreigner.ID)
+		assertErrCode(t, ErrPermissionDenied, err)
+
+		r, err := newRecurrence("0 8 * * *", alice.ID, bob.ID)
+		require.NoError(t, err)
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		at := r.NextAt.In(tokyo)
+		assert.Equal(t, 8, at.Hour())
+		assert.True(t, at.After(time.Now()))
+		assert.Equal(t, []int64{alice.ID, bob.ID}, r.OwnerIDs)
+
+		listTasks := func() []*dto.Task {
+			list, err := taskService.ListTasksByHospital(ctx, h.ID, dto.TaskFilter{}, dto.ListOptions{Limit: 10})
+			require.NoError(t, err)
+			return list.Items
+		}
+
+		// Several schedulers create the task of an occurrence only once.
+		scheduler := ProvideTaskScheduler(logger, s)
+		require.NoError(t, scheduler.Schedule(ctx, at.Add(-time.Minute)))
+		assert.Empty(t, listTasks())
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, ProvideTaskScheduler(logger, s).Schedule(ctx, at))
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, scheduler.Schedule(ctx, at))
+		tasks := listTasks()
+		if assert.Len(t, tasks, 1) {
+			assert.Equal(t, alice.ID, tasks[0].OwnerID)
+			assert.Equal(t, r.ID, tasks[0].RecurrenceID)
+			assert.True(t, at.Equal(*tasks[0].OccurrenceAt))
+			assert.True(t, at.Add(30*time.Minute).Equal(*tasks[0].DueAt))
+			assert.Equal(t, models.TaskStatusOpen, tasks[0].Status)
+		}
+		got, err := recurrenceService.GetTaskRecurrence(ctx, r.ID)
+		require.NoError(t, err)
+		assert.True(t, at.AddDate(0, 0, 1).Equal(got.NextAt))
+
+		// After a downtime, only the latest missed occurrence gets a task,
+		// owned by the next employee of the rotation.
+		require.NoError(t, scheduler.Schedule(ctx, at.AddDate(0, 0, 3).Add(time.Hour)))
+		tasks = listTasks()
+		if assert.Len(t, tasks, 2) {
+			assert.Equal(t, bob.ID, tasks[1].OwnerID)
+			assert.True(t, at.AddDate(0, 0, 3).Equal(*tasks[1].OccurrenceAt))
+		}
+
+		// A new schedule starts after now.
+		got.Schedule = "30 20 * * *"
+		got.Version = 0
+		updated, err := recurrenceService.UpdateTaskRecurrence(ctx, got)
+		require.NoError(t, err)
+		assert.Equal(t, 20, updated.NextAt.In(tokyo).Hour())
+		assert.True(t, updated.NextAt.After(time.Now()))
+		updated.Version = 1
+		_, err = recurrenceService.UpdateTaskRecurrence(ctx, updated)
+		assertErrCode(t, ErrPreconditionFailed, err)
+
+		require.NoError(t, recurrenceService.DeleteTaskRecurrence(ctx, r.ID))
+		require.NoError(t, scheduler.Schedule(ctx, updated.NextAt))
+		assert.Len(t, listTasks(), 2)
+		assertErrCode(t, ErrResourceNotFound, recurrenceService.DeleteTaskRecurrence(ctx, r.ID))
+	})
+
+	t.Run("Comment", func(t *testing.T) {
+		author, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "author"})
+		require.NoError(t, err)
+		other, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "not-author"})
+		require.NoError(t, err)
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-comment"})
+		require.NoError(t, err)
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "comment-stranger"})
+		require.NoError(t, err)
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    author.ID,
+			Title:      "t",
+			Priority:   models.TaskPriorityLow,
+		})
+		require.NoError(t, err)
+
+		_, err = commentService.CreateComment(ctx, &dto.Comment{TaskID: task.ID, AuthorID: stranger.ID, Body: "hi"})
+		assertErrCode(t, ErrPermissionDenied, err)
+		_, err = commentService.CreateComment(ctx, &dto.Comment{TaskID: task.ID + 100, AuthorID: author.ID, Body: "hi"})
+		assertErrCode(t, ErrResourceNotFound, err)
+		comment, err := commentService.CreateComment(ctx, &dto.Comment{TaskID: task.ID, AuthorID: author.ID, Body: "hi"})
+		require.NoError(t, err)
+
+		_, err = commentService.UpdateComment(ctx, &dto.Comment{ID: comment.ID, Body: "edited"}, other.ID)
+		assertErrCode(t, ErrPermissionDenied, err)
+		updated, err := commentService.UpdateComment(ctx, &dto.Comment{ID: comment.ID, Body: "edited"}, author.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "edited", updated.Body)
+
+		err = commentService.DeleteComment(ctx, comment.ID, other.ID)
+		assertErrCode(t, ErrPermissionDenied, err)
+		require.NoError(t, commentService.DeleteComment(ctx, comment.ID, author.ID))
+		err = commentService.DeleteComment(ctx, comment.ID, author.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		list, err := commentService.ListComments(ctx, task.ID, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), list.Total)
+	})
+
+	t.Run("Attachment", func(t *testing.T) {
+		uploader, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "uploader"})
+		require.NoError(t, err)
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    uploader.ID,
+			Title:      "t",
+			Priority:   models.TaskPriorityLow,
+		})
+		require.NoError(t, err)
+		pdf := []byte("%PDF-1.4\n%%EOF\n")
+
+		_, err = attachmentService.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID + 100, Filename: "a.pdf"}, bytes.NewReader(pdf))
+		assertErrCode(t, ErrResourceNotFound, err)
+		_, err = attachmentService.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID, Filename: "a.txt"}, strings.NewReader("text"))
+		assertErrCode(t, ErrUnsupportedMediaType, err)
+		tooLarge := io.MultiReader(bytes.NewReader(pdf), io.LimitReader(zeros{}, MaxAttachmentSize))
+		_, err = attachmentService.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID, Filename: "a.pdf"}, tooLarge)
+		assertErrCode(t, ErrTooLarge, err)
+
+		a, err := attachmentService.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID, UploaderID: uploader.ID, Filename: "a.pdf"}, bytes.NewReader(pdf))
+		require.NoError(t, err)
+		assert.Equal(t, "application/pdf", a.ContentType)
+		assert.Equal(t, int64(len(pdf)), a.Size)
+
+		// Only the blob of the attachment which was created is left.
+		var files []string
+		filepath.WalkDir(blobDir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, path)
+			}
+			return err
+		})
+		assert.Len(t, files, 1)
+
+		_, rc, err := attachmentService.OpenAttachment(ctx, task.ID+100, a.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+		_, rc, err = attachmentService.OpenAttachment(ctx, task.ID, a.ID)
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		assert.Equal(t, pdf, content)
+
+		require.NoError(t, attachmentService.DeleteAttachment(ctx, task.ID, a.ID))
+		err = attachmentService.DeleteAttachment(ctx, task.ID, a.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+		list, err := attachmentService.ListAttachments(ctx, task.ID, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), list.Total)
+	})
+
+	t.Run("Label", func(t *testing.T) {
+		owner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "label-owner"})
+		require.NoError(t, err)
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-label"})
+		require.NoError(t, err)
+		foreign, err := labelService.CreateLabel(ctx, &dto.Label{HospitalID: h.ID, Name: "wounds", Color: "#ff0000"})
+		require.NoError(t, err)
+
+		wounds, err := labelService.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "wounds", Color: "#ff0000"})
+		require.NoError(t, err)
+		icu, err := labelService.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "icu", Color: "#00ff00"})
+		require.NoError(t, err)
+		_, err = labelService.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "icu", Color: "#0000ff"})
+		assertErrCode(t, ErrAlreadyExists, err)
+		_, err = labelService.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID + 100, Name: "x", Color: "#0000ff"})
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    owner.ID,
+			Title:      "labelled",
+			Priority:   models.TaskPriorityLow,
+		})
+		require.NoError(t, err)
+		other, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    owner.ID,
+			Title:      "wounds only",
+			Priority:   models.TaskPriorityLow,
+		})
+		require.NoError(t, err)
+
+		err = labelService.LabelTask(ctx, task.ID, foreign.ID)
+		assertErrCode(t, ErrPermissionDenied, err)
+		require.NoError(t, labelService.LabelTask(ctx, task.ID, wounds.ID))
+		require.NoError(t, labelService.LabelTask(ctx, task.ID, wounds.ID))
+		require.NoError(t, labelService.LabelTask(ctx, task.ID, icu.ID))
+		require.NoError(t, labelService.LabelTask(ctx, other.ID, wounds.ID))
+
+		got, err := taskService.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		if assert.Len(t, got.Labels, 2) {
+			assert.Equal(t, "icu", got.Labels[0].Name)
+			assert.Equal(t, "wounds", got.Labels[1].Name)
+		}
+
+		filter := dto.TaskFilter{LabelIDs: []int64{wounds.ID, icu.ID}}
+		list, err := taskService.ListTasksByHospital(ctx, hospital.ID, filter, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), list.Total)
+		filter.LabelMatch = dto.LabelMatchAll
+		list, err = taskService.ListTasksByHospital(ctx, hospital.ID, filter, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		if assert.Len(t, list.Items, 1) {
+			assert.Equal(t, task.ID, list.Items[0].ID)
+			assert.Len(t, list.Items[0].Labels, 2)
+		}
+
+		require.NoError(t, labelService.UnlabelTask(ctx, task.ID, icu.ID))
+		err = labelService.UnlabelTask(ctx, task.ID, icu.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		_, err = labelService.UpdateLabel(ctx, &dto.Label{ID: wounds.ID, Name: "icu", Color: "#ff0000"})
+		assertErrCode(t, ErrAlreadyExists, err)
+		renamed, err := labelService.UpdateLabel(ctx, &dto.Label{ID: wounds.ID, Name: "wound-care", Color: "#ff0000"})
+		require.NoError(t, err)
+		assert.Equal(t, "wound-care", renamed.Name)
+
+		require.NoError(t, labelService.DeleteLabel(ctx, wounds.ID))
+		got, err = taskService.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Empty(t, got.Labels)
+		err = labelService.DeleteLabel(ctx, wounds.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		owner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "dependency-owner"})
+		require.NoError(t, err)
+		newTask := func(title string, parentID int64) *dto.Task {
+			task, err := taskService.CreateTask(ctx, &dto.Task{
+				HospitalID: hospital.ID,
+				OwnerID:    owner.ID,
+				ParentID:   parentID,
+				Title:      title,
+				Priority:   models.TaskPriorityLow,
+			})
+			require.NoError(t, err)
+			return task
+		}
+		parent := newTask("parent", 0)
+		child := newTask("child", parent.ID)
+		grandchild := newTask("grandchild", child.ID)
+		assert.Equal(t, parent.ID, child.ParentID)
+
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-dependency"})
+		require.NoError(t, err)
+		e, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "foreigner"})
+		require.NoError(t, err)
+		foreign, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: h.ID,
+			OwnerID:    e.ID,
+			Title:      "foreign",
+			Priority:   models.TaskPriorityLow,
+		})
+		require.NoError(t, err)
+		_, err = taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: h.ID,
+			OwnerID:    e.ID,
+			ParentID:   parent.ID,
+			Title:      "x",
+			Priority:   models.TaskPriorityLow,
+		})
+		assertErrCode(t, ErrPermissionDenied, err)
+
+		_, err = taskService.SetTaskParent(ctx, parent.ID, grandchild.ID)
+		assertErrCode(t, ErrConflict, err)
+		_, err = taskService.SetTaskParent(ctx, parent.ID, parent.ID)
+		assertErrCode(t, ErrConflict, err)
+		_, err = taskService.SetTaskParent(ctx, parent.ID, parent.ID+100)
+		assertErrCode(t, ErrResourceNotFound, err)
+		moved, err := taskService.SetTaskParent(ctx, grandchild.ID, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, parent.ID, moved.ParentID)
+		assert.Equal(t, grandchild.Version+1, moved.Version)
+
+		subtasks, err := taskService.ListSubtasks(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), subtasks.Total)
+
+		err = taskService.AddTaskBlocker(ctx, parent.ID, parent.ID)
+		assertErrCode(t, ErrBadArgument, err)
+		err = taskService.AddTaskBlocker(ctx, parent.ID, foreign.ID)
+		assertErrCode(t, ErrPermissionDenied, err)
+		require.NoError(t, taskService.AddTaskBlocker(ctx, parent.ID, child.ID))
+		require.NoError(t, taskService.AddTaskBlocker(ctx, parent.ID, child.ID))
+		require.NoError(t, taskService.AddTaskBlocker(ctx, child.ID, grandchild.ID))
+		err = taskService.AddTaskBlocker(ctx, grandchild.ID, parent.ID)
+		assertErrCode(t, ErrConflict, err)
+
+		blockers, err := taskService.ListTaskBlockers(ctx, parent.ID)
+		require.NoError(t, err)
+		if assert.Len(t, blockers.Items, 1) {
+			assert.Equal(t, child.ID, blockers.Items[0].ID)
+		}
+
+		for _, status := range []string{models.TaskStatusInProgress, models.TaskStatusCOMPLETED} {
+			_, err = taskService.TransitionTask(ctx, grandchild.ID, status, 0)
+			require.NoError(t, err, status)
+		}
+		_, err = taskService.TransitionTask(ctx, parent.ID, models.TaskStatusInProgress, 0)
+		require.NoError(t, err)
+		_, err = taskService.TransitionTask(ctx, parent.ID, models.TaskStatusCOMPLETED, 0)
+		assertErrCode(t, ErrConflict, err)
+
+		got, err := taskService.GetTask(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, &dto.SubtaskRollup{Total: 2, Done: 1}, got.Subtasks)
+		got, err = taskService.GetTask(ctx, grandchild.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.Subtasks)
+
+		require.NoError(t, taskService.RemoveTaskBlocker(ctx, parent.ID, child.ID))
+		err = taskService.RemoveTaskBlocker(ctx, parent.ID, child.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+		_, err = taskService.TransitionTask(ctx, parent.ID, models.TaskStatusCOMPLETED, 0)
+		require.NoError(t, err)
+
+		detached, err := taskService.SetTaskParent(ctx, child.ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), detached.ParentID)
+	})
+
+	t.Run("TaskHistory", func(t *testing.T) {
+		alice, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "history-alice"})
+		require.NoError(t, err)
+		bob, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "history-bob"})
+		require.NoError(t, err)
+		ctx := WithActor(ctx, alice.ID)
+
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    alice.ID,
+			Title:      "before",
+			Priority:   models.TaskPriorityLow,
+		})
+		require.NoError(t, err)
+		history, err := taskService.ListTaskHistory(ctx, task.ID, dto.ListOptions{Limit: 100})
+		require.NoError(t, err)
+		created := history.Items[len(history.Items)-1]
+		assert.Equal(t, alice.ID, created.ActorID)
+
+		task.Title = "after"
+		task.Priority = models.TaskPriorityUrgent
+		require.NoError(t, taskService.UpdateTask(ctx, task))
+		_, err = taskService.AssignTask(WithActor(ctx, bob.ID), task.ID, bob.ID)
+		require.NoError(t, err)
+
+		history, err = taskService.ListTaskHistory(ctx, task.ID, dto.ListOptions{Limit: 100})
+		require.NoError(t, err)
+		var changes []string
+		for _, c := range history.Items[len(history.Items)-3:] {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", c.Field, c.OldValue, c.NewValue))
+		}
+		assert.Equal(t, []string{
+			"title: before -> after",
+			"priority: LOW -> URGENT",
+			fmt.Sprintf("ownerId: %d -> %d", alice.ID, bob.ID),
+		}, changes)
+		assert.Equal(t, bob.ID, history.Items[len(history.Items)-1].ActorID)
+
+		// Revert to right after the update, before the assignment.
+		reverted, err := taskService.RevertTask(ctx, task.ID, history.Items[len(history.Items)-2].ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "after", reverted.Title)
+		assert.Equal(t, alice.ID, reverted.OwnerID)
+
+		// The status can't go back to OPEN once the task is started.
+		_, err = taskService.TransitionTask(ctx, task.ID, models.TaskStatusInProgress, 0)
+		require.NoError(t, err)
+		_, err = taskService.RevertTask(ctx, task.ID, created.ID, 0)
+		assertErrCode(t, ErrInvalidTransition, err)
+
+		_, err = taskService.RevertTask(ctx, task.ID, history.Items[0].ID-1, 0)
+		assertErrCode(t, ErrResourceNotFound, err)
+	})
+
+	t.Run("TaskOwnership", func(t *testing.T) {
+		other, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-other"})
+		require.NoError(t, err)
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: other.ID, Username: "stranger"})
+		require.NoError(t, err)
+		owner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "owner2"})
+		require.NoError(t, err)
+
+		newTask := func(oid int64) *dto.Task {
+			return &dto.Task{
+				HospitalID: hospital.ID,
+				OwnerID:    oid,
+				Title:      "t",
+				Priority:   models.TaskPriorityLow,
+				Status:     models.TaskStatusOpen,
+			}
+		}
+
+		_, err = taskService.CreateTask(ctx, newTask(stranger.ID))
+		assertErrCode(t, ErrPermissionDenied, err)
+		_, err = taskService.CreateTask(ctx, newTask(stranger.ID+100))
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		task, err := taskService.CreateTask(ctx, newTask(owner.ID))
+		require.NoError(t, err)
+
+		_, err = taskService.AssignTask(ctx, task.ID, stranger.ID)
+		assertErrCode(t, ErrPermissionDenied, err)
+		_, err = taskService.AssignTask(ctx, task.ID+100, owner.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		got, err := taskService.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, owner.ID, got.OwnerID)
+	})
+
+	t.Run("TaskPool", func(t *testing.T) {
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-pool"})
+		require.NoError(t, err)
+		var employees []*dto.Employee
+		for i := 0; i < 5; i++ {
+			e, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: fmt.Sprintf("pool-%d", i)})
+			require.NoError(t, err)
+			employees = append(employees, e)
+		}
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "pool-stranger"})
+		require.NoError(t, err)
+
+		task, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, Title: "pooled", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), task.OwnerID)
+		pool := dto.TaskFilter{Unassigned: true}
+		list, err := taskService.ListTasksByHospital(ctx, h.ID, pool, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+
+		_, err = taskService.ClaimTask(ctx, task.ID, stranger.ID)
+		assertErrCode(t, ErrPermissionDenied, err)
+		_, err = taskService.ClaimTask(ctx, task.ID+100, employees[0].ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		// Only one of the employees claiming at once gets the task.
+		var wg sync.WaitGroup
+		errs := make([]error, len(employees))
+		for i, e := range employees {
+			wg.Add(1)
+			go func(i int, oid int64) {
+				defer wg.Done()
+				_, errs[i] = taskService.ClaimTask(ctx, task.ID, oid)
+			}(i, e.ID)
+		}
+		wg.Wait()
+		var winner int64
+		for i, err := range errs {
+			if err == nil {
+				assert.Zero(t, winner)
+				winner = employees[i].ID
+			} else {
+				assertErrCode(t, ErrConflict, err)
+			}
+		}
+		require.NotZero(t, winner)
+
+		got, err := taskService.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, winner, got.OwnerID)
+		list, err = taskService.ListTasksByHospital(ctx, h.ID, pool, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), list.Total)
+
+		// Reverting the claim puts the task back in the pool.
+		history, err := taskService.ListTaskHistory(ctx, task.ID, dto.ListOptions{Limit: 100})
+		require.NoError(t, err)
+		claim := history.Items[len(history.Items)-1]
+		assert.Equal(t, fmt.Sprintf("ownerId:  -> %d", winner), fmt.Sprintf("%s: %s -> %s", claim.Field, claim.OldValue, claim.NewValue))
+		reverted, err := taskService.RevertTask(ctx, task.ID, history.Items[len(history.Items)-2].ID, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), reverted.OwnerID)
+	})
+
+	t.Run("TaskAssignment", func(t *testing.T) {
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-assign"})
+		require.NoError(t, err)
+		assert.Equal(t, models.AssignmentRoundRobin, h.AssignmentStrategy)
+		h.Version = 0
+		auto := func(priority string) (*dto.Task, error) {
+			return taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, Title: "auto", Priority: priority, AutoAssign: true})
+		}
+		_, err = auto(models.TaskPriorityLow)
+		assertErrCode(t, ErrConflict, err)
+
+		var employees []*dto.Employee
+		for i := 0; i < 3; i++ {
+			e, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: fmt.Sprintf("assign-%d", i)})
+			require.NoError(t, err)
+			employees = append(employees, e)
+		}
+		_, err = employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "assign-stranger"})
+		require.NoError(t, err)
+
+		// The round-robin strategy goes through the employees in turn, and
+		// never picks the ones of another hospital.
+		for i := 0; i < 4; i++ {
+			task, err := auto(models.TaskPriorityLow)
+			require.NoError(t, err)
+			assert.Equal(t, employees[i%3].ID, task.OwnerID)
+		}
+
+		// employees[0] has 2 LOW tasks, the others 1.
+		h.AssignmentStrategy = models.AssignmentLeastOpen
+		require.NoError(t, hospitalService.UpdateHospital(ctx, h))
+		task, err := auto(models.TaskPriorityUrgent)
+		require.NoError(t, err)
+		assert.Equal(t, employees[1].ID, task.OwnerID)
+
+		// employees[1] has the URGENT task, so the next ones go to the
+		// others, the lighter loaded first.
+		h.AssignmentStrategy = models.AssignmentPriorityAware
+		require.NoError(t, hospitalService.UpdateHospital(ctx, h))
+		task, err = auto(models.TaskPriorityUrgent)
+		require.NoError(t, err)
+		assert.Equal(t, employees[2].ID, task.OwnerID)
+		task, err = auto(models.TaskPriorityUrgent)
+		require.NoError(t, err)
+		assert.Equal(t, employees[0].ID, task.OwnerID)
+
+		// Once the hospital assigns by default, the tasks created without
+		// owner aren't pooled, but an explicit owner is kept.
+		h.AutoAssign = true
+		require.NoError(t, hospitalService.UpdateHospital(ctx, h))
+		task, err = taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, Title: "default", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+		assert.NotZero(t, task.OwnerID)
+		task, err = taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: employees[0].ID, Title: "explicit", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+		assert.Equal(t, employees[0].ID, task.OwnerID)
+
+		h.AssignmentStrategy = "random"
+		assertErrCode(t, ErrBadArgument, hospitalService.UpdateHospital(ctx, h))
+		_, err = hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-assign-bad", AssignmentStrategy: "random"})
+		assertErrCode(t, ErrBadArgument, err)
+	})
+
+	t.Run("SLAEscalation", func(t *testing.T) {
+		slaService := ProvideSLAService(logger, s)
+		evaluator := ProvideSLAEvaluator(logger, s)
+
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-sla"})
+		require.NoError(t, err)
+		var employees []*dto.Employee
+		for i := 0; i < 3; i++ {
+			e, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: fmt.Sprintf("sla-%d", i)})
+			require.NoError(t, err)
+			employees = append(employees, e)
+		}
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "sla-stranger"})
+		require.NoError(t, err)
+
+		_, err = slaService.SetTaskSLA(ctx, &dto.TaskSLA{HospitalID: h.ID, Priority: models.TaskPriorityUrgent, RespondWithin: 60, Escalation: models.EscalationReassign})
+		assertErrCode(t, ErrBadArgument, err)
+		_, err = slaService.SetTaskSLA(ctx, &dto.TaskSLA{HospitalID: h.ID, Priority: models.TaskPriorityUrgent, RespondWithin: 60, Escalation: "page"})
+		assertErrCode(t, ErrBadArgument, err)
+		_, err = slaService.SetTaskSLA(ctx, &dto.TaskSLA{HospitalID: h.ID, Priority: models.TaskPriorityUrgent, RespondWithin: 60, Escalation: models.EscalationReassign, EscalationChain: []int64{stranger.ID}})
+		assert.Error(t, err)
+		_, err = slaService.SetTaskSLA(ctx, &dto.TaskSLA{HospitalID: h.ID + 100, Priority: models.TaskPriorityUrgent, RespondWithin: 60})
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		sla, err := slaService.SetTaskSLA(ctx, &dto.TaskSLA{HospitalID: h.ID, Priority: models.TaskPriorityLow, RespondWithin: 600})
+		require.NoError(t, err)
+		assert.Equal(t, models.EscalationNone, sla.Escalation)
+		// Setting it again replaces it.
+		_, err = slaService.SetTaskSLA(ctx, &dto.TaskSLA{HospitalID: h.ID, Priority: models.TaskPriorityLow, RespondWithin: 60, Escalation: models.EscalationBumpPriority})
+		require.NoError(t, err)
+		chain := []int64{employees[1].ID, employees[2].ID}
+		_, err = slaService.SetTaskSLA(ctx, &dto.TaskSLA{HospitalID: h.ID, Priority: models.TaskPriorityUrgent, RespondWithin: 60, Escalation: models.EscalationReassign, EscalationChain: chain})
+		require.NoError(t, err)
+		slas, err := slaService.ListTaskSLAs(ctx, h.ID)
+		require.NoError(t, err)
+		require.Equal(t, uint(2), slas.Total)
+		assert.Equal(t, int64(60), slas.Items[0].RespondWithin)
+		assert.Equal(t, chain, slas.Items[1].EscalationChain)
+
+		low, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: employees[0].ID, Title: "low", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+		urgent, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: employees[0].ID, Title: "urgent", Priority: models.TaskPriorityUrgent})
+		require.NoError(t, err)
+
+		// Nothing breaches yet.
+		require.NoError(t, evaluator.Evaluate(ctx, time.Now()))
+		got, err := taskService.GetTask(ctx, low.ID)
+		require.NoError(t, err)
+		assert.False(t, got.Breached)
+
+		now := time.Now().Add(2 * time.Minute)
+		require.NoError(t, evaluator.Evaluate(ctx, now))
+		got, err = taskService.GetTask(ctx, low.ID)
+		require.NoError(t, err)
+		assert.True(t, got.Breached)
+		assert.Equal(t, models.TaskPriorityHight, got.Priority)
+		got, err = taskService.GetTask(ctx, urgent.ID)
+		require.NoError(t, err)
+		assert.True(t, got.Breached)
+		assert.Equal(t, employees[1].ID, got.OwnerID)
+
+		// The clock restarts at the escalation, so another run at the same
+		// time doesn't escalate again.
+		require.NoError(t, evaluator.Evaluate(ctx, now))
+		escalations, err := slaService.ListTaskEscalations(ctx, urgent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), escalations.Total)
+
+		// The urgent task goes down the chain, then stays with its last
+		// employee.
+		now = now.Add(2 * time.Minute)
+		require.NoError(t, evaluator.Evaluate(ctx, now))
+		now = now.Add(2 * time.Minute)
+		require.NoError(t, evaluator.Evaluate(ctx, now))
+		got, err = taskService.GetTask(ctx, urgent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, employees[2].ID, got.OwnerID)
+		escalations, err = slaService.ListTaskEscalations(ctx, urgent.ID)
+		require.NoError(t, err)
+		require.Equal(t, uint(3), escalations.Total)
+		for i, action := range []string{models.EscalationReassign, models.EscalationReassign, models.EscalationNone} {
+			assert.Equal(t, int64(i+1), escalations.Items[i].Level)
+			assert.Equal(t, action, escalations.Items[i].Action)
+		}
+		assert.Equal(t, strconv.FormatInt(employees[1].ID, 10), escalations.Items[1].OldValue)
+		assert.Equal(t, strconv.FormatInt(employees[2].ID, 10), escalations.Items[1].NewValue)
+		// The HIGHT priority has no SLA.
+		escalations, err = slaService.ListTaskEscalations(ctx, low.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), escalations.Total)
+
+		breached := dto.TaskFilter{Breached: true}
+		list, err := taskService.ListTasksByHospital(ctx, h.ID, breached, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), list.Total)
+
+		// The flag is cleared once the task leaves OPEN.
+		_, err = taskService.TransitionTask(ctx, urgent.ID, models.TaskStatusInProgress, got.Version)
+		require.NoError(t, err)
+		require.NoError(t, evaluator.Evaluate(ctx, now))
+		list, err = taskService.ListTasksByHospital(ctx, h.ID, breached, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), list.Total)
+		got, err = taskService.GetTask(ctx, urgent.ID)
+		require.NoError(t, err)
+		assert.False(t, got.Breached)
+
+		require.NoError(t, slaService.DeleteTaskSLA(ctx, h.ID, models.TaskPriorityLow))
+		assertErrCode(t, ErrResourceNotFound, slaService.DeleteTaskSLA(ctx, h.ID, models.TaskPriorityLow))
+		_, err = slaService.ListTaskEscalations(ctx, urgent.ID+100)
+		assertErrCode(t, ErrResourceNotFound, err)
+	})
+
+	t.Run("TaskWatchers", func(t *testing.T) {
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-watch"})
+		require.NoError(t, err)
+		var employees []*dto.Employee
+		for _, name := range []string{"watch-owner", "watch-next", "watch-supervisor"} {
+			e, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: name})
+			require.NoError(t, err)
+			employees = append(employees, e)
+		}
+		owner, next, supervisor := employees[0], employees[1], employees[2]
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "watch-stranger"})
+		require.NoError(t, err)
+
+		task, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: owner.ID, Title: "watched", Priority: models.TaskPriorityLow, Status: models.TaskStatusOpen})
+		require.NoError(t, err)
+		other, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: owner.ID, Title: "other", Priority: models.TaskPriorityLow, Status: models.TaskStatusOpen})
+		require.NoError(t, err)
+
+		assertErrCode(t, ErrPermissionDenied, taskService.WatchTask(ctx, task.ID, stranger.ID))
+		assertErrCode(t, ErrResourceNotFound, taskService.WatchTask(ctx, task.ID, stranger.ID+100))
+		assertErrCode(t, ErrResourceNotFound, taskService.WatchTask(ctx, other.ID+100, supervisor.ID))
+		require.NoError(t, taskService.WatchTask(ctx, task.ID, supervisor.ID))
+		require.NoError(t, taskService.WatchTask(ctx, task.ID, supervisor.ID))
+		watchers, err := taskService.ListTaskWatchers(ctx, task.ID)
+		require.NoError(t, err)
+		if assert.Equal(t, uint(1), watchers.Total) {
+			assert.Equal(t, supervisor.ID, watchers.Items[0].ID)
+		}
+		watched, err := taskService.ListWatchedTasks(ctx, supervisor.ID, dto.TaskFilter{}, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		if assert.Equal(t, uint(1), watched.Total) {
+			assert.Equal(t, task.ID, watched.Items[0].ID)
+		}
+
+		events := func(eid int64) []*dto.TaskEvent {
+			list, err := taskService.ListTaskEvents(ctx, eid, dto.ListOptions{Limit: 100})
+			require.NoError(t, err)
+			return list.Items
+		}
+		// The owner received the creation of both tasks.
+		ownerEvents := len(events(owner.ID))
+		assert.NotZero(t, ownerEvents)
+		assert.Empty(t, events(supervisor.ID))
+
+		// An update reaches the owner and the watcher alike.
+		task.Title = "watched closely"
+		task.Version = 0
+		require.NoError(t, taskService.UpdateTask(WithActor(ctx, owner.ID), task))
+		got := events(supervisor.ID)
+		if assert.Len(t, got, 1) {
+			assert.Equal(t, supervisor.ID, got[0].EmployeeID)
+			assert.Equal(t, task.ID, got[0].Change.TaskID)
+			assert.Equal(t, models.TaskFieldTitle, got[0].Change.Field)
+			assert.Equal(t, "watched closely", got[0].Change.NewValue)
+			assert.Equal(t, owner.ID, got[0].Change.ActorID)
+		}
+		assert.Len(t, events(owner.ID), ownerEvents+1)
+
+		// An assignment reaches the previous owner and the new one.
+		_, err = taskService.AssignTask(ctx, task.ID, next.ID)
+		require.NoError(t, err)
+		for _, eid := range []int64{owner.ID, next.ID, supervisor.ID} {
+			got := events(eid)
+			if assert.NotEmpty(t, got) {
+				assert.Equal(t, models.TaskFieldOwnerID, got[len(got)-1].Change.Field)
+			}
+		}
+		// The changes of the tasks they don't watch don't reach them.
+		other.Title = "other title"
+		other.Version = 0
+		require.NoError(t, taskService.UpdateTask(ctx, other))
+		assert.Len(t, events(supervisor.ID), 2)
+
+		require.NoError(t, taskService.UnwatchTask(ctx, task.ID, supervisor.ID))
+		assertErrCode(t, ErrResourceNotFound, taskService.UnwatchTask(ctx, task.ID, supervisor.ID))
+		_, err = taskService.TransitionTask(ctx, task.ID, models.TaskStatusInProgress, 0)
+		require.NoError(t, err)
+		assert.Len(t, events(supervisor.ID), 2)
+
+		list, err := taskService.ListTaskEvents(ctx, next.ID, dto.ListOptions{Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), list.Total)
+		assert.NotEmpty(t, list.NextCursor)
+
+		// Reassigning the tasks of a deleted employee reaches the previous
+		// owner, the new one and the watchers too.
+		require.NoError(t, taskService.WatchTask(ctx, task.ID, supervisor.ID))
+		err = employeeService.DeleteEmployee(ctx, next.ID, dto.DeleteOptions{Policy: dto.DeleteReassign, ReassignTo: owner.ID})
+		require.NoError(t, err)
+		for _, eid := range []int64{next.ID, owner.ID, supervisor.ID} {
+			got := events(eid)
+			if assert.NotEmpty(t, got) {
+				change := got[len(got)-1].Change
+				assert.Equal(t, task.ID, change.TaskID)
+				assert.Equal(t, models.TaskFieldOwnerID, change.Field)
+				assert.Equal(t, strconv.FormatInt(next.ID, 10), change.OldValue)
+				assert.Equal(t, strconv.FormatInt(owner.ID, 10), change.NewValue)
+			}
+		}
+	})
+
+	t.Run("Shifts", func(t *testing.T) {
+		shiftService := ProvideShiftService(logger, s)
+
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-shift"})
+		require.NoError(t, err)
+		assert.Equal(t, models.OffShiftAllow, h.OffShiftAssignment)
+		h.Version = 0
+		alice, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "shift-alice"})
+		require.NoError(t, err)
+		bob, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "shift-bob"})
+		require.NoError(t, err)
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "shift-stranger"})
+		require.NoError(t, err)
+
+		now := time.Now().Truncate(time.Second)
+		day, err := shiftService.CreateShift(ctx, &dto.Shift{HospitalID: h.ID, EmployeeID: alice.ID, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(7 * time.Hour), Ward: "icu"})
+		require.NoError(t, err)
+		night, err := shiftService.CreateShift(ctx, &dto.Shift{HospitalID: h.ID, EmployeeID: bob.ID, StartsAt: now.Add(7 * time.Hour), EndsAt: now.Add(15 * time.Hour)})
+		require.NoError(t, err)
+
+		_, err = shiftService.CreateShift(ctx, &dto.Shift{HospitalID: h.ID, EmployeeID: stranger.ID, StartsAt: now, EndsAt: now.Add(time.Hour)})
+		assertErrCode(t, ErrPermissionDenied, err)
+		_, err = shiftService.CreateShift(ctx, &dto.Shift{HospitalID: h.ID + 100, EmployeeID: alice.ID, StartsAt: now, EndsAt: now.Add(time.Hour)})
+		assertErrCode(t, ErrResourceNotFound, err)
+		_, err = shiftService.CreateShift(ctx, &dto.Shift{HospitalID: h.ID, EmployeeID: alice.ID, StartsAt: now.Add(6 * time.Hour), EndsAt: now.Add(8 * time.Hour)})
+		assertErrCode(t, ErrConflict, err)
+
+		current, err := shiftService.ListShifts(ctx, h.ID, dto.ShiftFilter{At: now}, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, current.Items, 1)
+		assert.Equal(t, alice.ID, current.Items[0].EmployeeID)
+
+		// A shift doesn't overlap itself when it's moved.
+		night.StartsAt = now.Add(8 * time.Hour)
+		night.Version++
+		_, err = shiftService.UpdateShift(ctx, night)
+		assertErrCode(t, ErrPreconditionFailed, err)
+		night.Version--
+		updated, err := shiftService.UpdateShift(ctx, night)
+		require.NoError(t, err)
+		assert.Equal(t, night.Version+1, updated.Version)
+
+		task, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, Title: "shift", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+		assigned, err := taskService.AssignTask(ctx, task.ID, bob.ID)
+		require.NoError(t, err)
+		assert.Empty(t, assigned.Warnings)
+
+		h.OffShiftAssignment = "ignore"
+		assertErrCode(t, ErrBadArgument, hospitalService.UpdateHospital(ctx, h))
+		h.OffShiftAssignment = models.OffShiftWarn
+		require.NoError(t, hospitalService.UpdateHospital(ctx, h))
+		assigned, err = taskService.AssignTask(ctx, task.ID, bob.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{fmt.Sprintf("employee %d is off shift", bob.ID)}, assigned.Warnings)
+		assigned, err = taskService.AssignTask(ctx, task.ID, alice.ID)
+		require.NoError(t, err)
+		assert.Empty(t, assigned.Warnings)
+		report, err := taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{TaskIDs: []int64{task.ID}, Action: dto.BulkActionAssign, OwnerID: bob.ID, DryRun: true})
+		require.NoError(t, err)
+		assert.Len(t, report.Items[0].Task.Warnings, 1)
+
+		h.OffShiftAssignment = models.OffShiftReject
+		require.NoError(t, hospitalService.UpdateHospital(ctx, h))
+		_, err = taskService.AssignTask(ctx, task.ID, bob.ID)
+		assertErrCode(t, ErrConflict, err)
+		_, err = taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: bob.ID, Title: "shift", Priority: models.TaskPriorityLow})
+		assertErrCode(t, ErrConflict, err)
+		_, err = taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{TaskIDs: []int64{task.ID}, Action: dto.BulkActionAssign, OwnerID: bob.ID})
+		assertErrCode(t, ErrConflict, err)
+		pooled, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, Title: "shift", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+		_, err = taskService.ClaimTask(ctx, pooled.ID, bob.ID)
+		assertErrCode(t, ErrConflict, err)
+		assertErrCode(t, ErrConflict, employeeService.DeleteEmployee(ctx, alice.ID, dto.DeleteOptions{Policy: dto.DeleteReassign, ReassignTo: bob.ID}))
+		_, err = employeeService.GetEmployee(ctx, alice.ID)
+		require.NoError(t, err)
+		got, err := taskService.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, got.OwnerID)
+
+		// The automatic assignments only go to the employees on shift.
+		for i := 0; i < 2; i++ {
+			task, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, Title: "auto", Priority: models.TaskPriorityLow, AutoAssign: true})
+			require.NoError(t, err)
+			assert.Equal(t, alice.ID, task.OwnerID)
+		}
+
+		require.NoError(t, shiftService.DeleteShift(ctx, day.ID))
+		assertErrCode(t, ErrResourceNotFound, shiftService.DeleteShift(ctx, day.ID))
+		_, err = taskService.AssignTask(ctx, task.ID, alice.ID)
+		assertErrCode(t, ErrConflict, err)
+	})
+
+	t.Run("TaskTemplates", func(t *testing.T) {
+		templateService := ProvideTemplateService(logger, s)
+
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-template"})
+		require.NoError(t, err)
+		nurse, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "template-nurse"})
+		require.NoError(t, err)
+		postOp, err := labelService.CreateLabel(ctx, &dto.Label{HospitalID: h.ID, Name: "post-op", Color: "#ff0000"})
+		require.NoError(t, err)
+		stranger, err := labelService.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "template-stranger", Color: "#00ff00"})
+		require.NoError(t, err)
+
+		_, err = templateService.CreateTaskTemplate(ctx, &dto.TaskTemplate{HospitalID: h.ID, Title: "x", Priority: models.TaskPriorityLow, LabelIDs: []int64{stranger.ID}})
+		assertErrCode(t, ErrPermissionDenied, err)
+		_, err = templateService.CreateTaskTemplate(ctx, &dto.TaskTemplate{HospitalID: h.ID + 100, Title: "x", Priority: models.TaskPriorityLow})
+		assertErrCode(t, ErrResourceNotFound, err)
+		template, err := templateService.CreateTaskTemplate(ctx, &dto.TaskTemplate{
+			HospitalID: h.ID,
+			Title:      "post-op check",
+			Priority:   models.TaskPriorityHight,
+			LabelIDs:   []int64{postOp.ID},
+			Checklist:  []string{"vitals", "wound", "pain"},
+		})
+		require.NoError(t, err)
+		got, err := templateService.GetTaskTemplate(ctx, template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{postOp.ID}, got.LabelIDs)
+		assert.Equal(t, []string{"vitals", "wound", "pain"}, got.Checklist)
+
+		// The task gets the labels of the template, and its owner gets a
+		// subtask for each item of the checklist.
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: h.ID,
+			OwnerID:    nurse.ID,
+			TemplateID: template.ID,
+			Title:      template.Title,
+			Priority:   models.TaskPriorityUrgent,
+		})
+		require.NoError(t, err)
+		if assert.Len(t, task.Labels, 1) {
+			assert.Equal(t, postOp.ID, task.Labels[0].ID)
+		}
+		assert.Equal(t, &dto.SubtaskRollup{Total: 3}, task.Subtasks)
+		subtasks, err := taskService.ListSubtasks(ctx, task.ID)
+		require.NoError(t, err)
+		require.Len(t, subtasks.Items, 3)
+		for i, title := range template.Checklist {
+			assert.Equal(t, title, subtasks.Items[i].Title)
+			assert.Equal(t, nurse.ID, subtasks.Items[i].OwnerID)
+			assert.Equal(t, models.TaskPriorityUrgent, subtasks.Items[i].Priority)
+		}
+
+		other, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-template-other"})
+		require.NoError(t, err)
+		_, err = taskService.CreateTask(ctx, &dto.Task{HospitalID: other.ID, TemplateID: template.ID, Title: "x", Priority: models.TaskPriorityLow})
+		assertErrCode(t, ErrPermissionDenied, err)
+
+		template.Checklist = []string{"vitals"}
+		template.LabelIDs = nil
+		template.Version++
+		_, err = templateService.UpdateTaskTemplate(ctx, template)
+		assertErrCode(t, ErrPreconditionFailed, err)
+		template.Version--
+		updated, err := templateService.UpdateTaskTemplate(ctx, template)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"vitals"}, updated.Checklist)
+		assert.Empty(t, updated.LabelIDs)
+
+		require.NoError(t, templateService.DeleteTaskTemplate(ctx, template.ID))
+		_, err = taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, TemplateID: template.ID, Title: "x", Priority: models.TaskPriorityLow})
+		assertErrCode(t, ErrResourceNotFound, err)
+		list, err := templateService.ListTaskTemplates(ctx, h.ID, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), list.Total)
+	})
+
+	t.Run("BulkUpdateTasks", func(t *testing.T) {
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-bulk"})
+		require.NoError(t, err)
+		day, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "bulk-day"})
+		require.NoError(t, err)
+		night, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "bulk-night"})
+		require.NoError(t, err)
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "bulk-stranger"})
+		require.NoError(t, err)
+		var ids []int64
+		for i := 0; i < 3; i++ {
+			task, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: day.ID, Title: "t", Priority: models.TaskPriorityLow})
+			require.NoError(t, err)
+			ids = append(ids, task.ID)
+		}
+		foreign, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: hospital.ID, OwnerID: stranger.ID, Title: "t", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+
+		_, err = taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{TaskIDs: ids, Action: dto.BulkActionAssign, OwnerID: stranger.ID})
+		assertErrCode(t, ErrPermissionDenied, err)
+		_, err = taskService.BulkUpdateTasks(ctx, h.ID+100, &dto.TaskBulk{TaskIDs: ids, Action: dto.BulkActionAssign, OwnerID: night.ID})
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		// A dry run reports the changes without applying them.
+		report, err := taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{Filter: dto.TaskFilter{OwnerID: day.ID}, Action: dto.BulkActionAssign, OwnerID: night.ID, DryRun: true})
+		require.NoError(t, err)
+		assert.False(t, report.Applied)
+		if assert.Len(t, report.Items, 3) {
+			assert.Equal(t, night.ID, report.Items[0].Task.OwnerID)
+		}
+		got, err := taskService.GetTask(ctx, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, day.ID, got.OwnerID)
+
+		// A task which fails rolls back the others.
+		report, err = taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{TaskIDs: append(ids, foreign.ID, ids[0]), Action: dto.BulkActionAssign, OwnerID: night.ID})
+		require.NoError(t, err)
+		assert.False(t, report.Applied)
+		assert.Equal(t, uint(1), report.Failed)
+		if assert.Len(t, report.Items, 4) {
+			assert.Equal(t, string(ErrPermissionDenied), report.Items[3].Error)
+			assert.Nil(t, report.Items[3].Task)
+		}
+		got, err = taskService.GetTask(ctx, ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, day.ID, got.OwnerID)
+
+		report, err = taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{TaskIDs: ids, Action: dto.BulkActionAssign, OwnerID: night.ID})
+		require.NoError(t, err)
+		assert.True(t, report.Applied)
+		list, err := taskService.ListTasksByOwner(ctx, night.ID, dto.TaskFilter{}, dto.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), list.Total)
+
+		_, err = taskService.TransitionTask(ctx, ids[0], models.TaskStatusInProgress, 0)
+		require.NoError(t, err)
+		report, err = taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{TaskIDs: ids, Action: dto.BulkActionSetStatus, Status: models.TaskStatusCOMPLETED})
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), report.Failed)
+		assert.Equal(t, string(ErrInvalidTransition), report.Items[1].Error)
+
+		report, err = taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{TaskIDs: ids, Action: dto.BulkActionSetPriority, Priority: models.TaskPriorityUrgent})
+		require.NoError(t, err)
+		assert.True(t, report.Applied)
+		got, err = taskService.GetTask(ctx, ids[2])
+		require.NoError(t, err)
+		assert.Equal(t, models.TaskPriorityUrgent, got.Priority)
+
+		label, err := labelService.CreateLabel(ctx, &dto.Label{HospitalID: h.ID, Name: "handover", Color: "#ff0000"})
+		require.NoError(t, err)
+		report, err = taskService.BulkUpdateTasks(ctx, h.ID, &dto.TaskBulk{Filter: dto.TaskFilter{Priorities: []string{models.TaskPriorityUrgent}}, Action: dto.BulkActionAddLabel, LabelID: label.ID})
+		require.NoError(t, err)
+		assert.True(t, report.Applied)
+		if assert.Len(t, report.Items, 3) && assert.Len(t, report.Items[0].Task.Labels, 1) {
+			assert.Equal(t, "handover", report.Items[0].Task.Labels[0].Name)
+		}
+	})
+
+	t.Run("DeletePolicies", func(t *testing.T) {
+		h, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-delete"})
+		require.NoError(t, err)
+		alice, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "alice"})
+		require.NoError(t, err)
+		bob, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "bob"})
+		require.NoError(t, err)
+		stranger, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "stranger2"})
+		require.NoError(t, err)
+		task, err := taskService.CreateTask(ctx, &dto.Task{
+			HospitalID: h.ID,
+			OwnerID:    alice.ID,
+			Title:      "t",
+			Priority:   models.TaskPriorityLow,
+			Status:     models.TaskStatusOpen,
+		})
+		require.NoError(t, err)
+
+		err = employeeService.DeleteEmployee(ctx, alice.ID, dto.DeleteOptions{})
+		assertErrCode(t, ErrConflict, err)
+		err = employeeService.DeleteEmployee(ctx, alice.ID, dto.DeleteOptions{Policy: "unknown"})
+		assertErrCode(t, ErrBadArgument, err)
+		err = employeeService.DeleteEmployee(ctx, alice.ID, dto.DeleteOptions{Policy: dto.DeleteReassign, ReassignTo: stranger.ID})
+		assertErrCode(t, ErrPermissionDenied, err)
+
+		// lastChange returns the last change recorded in the history of the
+		// task id, even if it is deleted.
+		lastChange := func(id int64) string {
+			changes, err := s.FindTaskChanges(ctx, id, dto.ListOptions{Limit: 100})
+			require.NoError(t, err)
+			require.NotEmpty(t, changes)
+			c := changes[len(changes)-1]
+			return fmt.Sprintf("%s: %s -> %s", c.Field, c.OldValue, c.NewValue)
+		}
+
+		err = employeeService.DeleteEmployee(ctx, alice.ID, dto.DeleteOptions{Policy: dto.DeleteReassign, ReassignTo: bob.ID})
+		require.NoError(t, err)
+		got, err := taskService.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, bob.ID, got.OwnerID)
+		assert.Equal(t, fmt.Sprintf("ownerId: %d -> %d", alice.ID, bob.ID), lastChange(task.ID))
+		_, err = employeeService.GetEmployee(ctx, alice.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		carol, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "carol"})
+		require.NoError(t, err)
+		carolTask, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: h.ID, OwnerID: carol.ID, Title: "c", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+		err = employeeService.DeleteEmployee(ctx, carol.ID, dto.DeleteOptions{Policy: dto.DeleteCascade})
+		require.NoError(t, err)
+		_, err = taskService.GetTask(ctx, carolTask.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+		assert.Equal(t, "deleted: false -> true", lastChange(carolTask.ID))
+
+		err = hospitalService.DeleteHospital(ctx, h.ID, dto.DeleteOptions{Policy: dto.DeleteRestrict})
+		assertErrCode(t, ErrConflict, err)
+		err = hospitalService.DeleteHospital(ctx, h.ID, dto.DeleteOptions{Policy: dto.DeleteReassign})
+		assertErrCode(t, ErrBadArgument, err)
+
+		err = hospitalService.DeleteHospital(ctx, h.ID, dto.DeleteOptions{Policy: dto.DeleteCascade})
+		require.NoError(t, err)
+		_, err = taskService.GetTask(ctx, task.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+		assert.Equal(t, "deleted: false -> true", lastChange(task.ID))
+		_, err = employeeService.GetEmployee(ctx, bob.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		// Restoring the hospital restores what the cascade deleted, but not
+		// what was deleted before it.
+		assertErrCode(t, ErrConflict, employeeService.RestoreEmployee(ctx, bob.ID))
+		require.NoError(t, hospitalService.RestoreHospital(ctx, h.ID))
+		require.NoError(t, hospitalService.RestoreHospital(ctx, h.ID))
+		_, err = employeeService.GetEmployee(ctx, bob.ID)
+		assert.NoError(t, err)
+		got, err = taskService.GetTask(ctx, task.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, bob.ID, got.OwnerID)
+		}
+		assert.Equal(t, "deleted: true -> false", lastChange(task.ID))
+		_, err = employeeService.GetEmployee(ctx, carol.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+		_, err = taskService.GetTask(ctx, carolTask.ID)
+		assertErrCode(t, ErrResourceNotFound, err)
+
+		require.NoError(t, employeeService.RestoreEmployee(ctx, carol.ID))
+		_, err = taskService.GetTask(ctx, carolTask.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "deleted: true -> false", lastChange(carolTask.ID))
+		assertErrCode(t, ErrResourceNotFound, hospitalService.RestoreHospital(ctx, h.ID+100))
+		assertErrCode(t, ErrResourceNotFound, employeeService.RestoreEmployee(ctx, carol.ID+100))
+	})
+}
+
+// TestServicesSQL runs the services against a SQL store, for the behaviours
+// the memory store can't show, like a statement failing inside a transaction.
+func TestServicesSQL(t *testing.T) {
+	ctx := context.Background()
+	s, err := store.OpenSQLStore("sqlite://" + filepath.Join(t.TempDir(), "services.db"))
+	require.NoError(t, err)
+	defer s.Close()
+	migrator, err := store.NewMigrator(s, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx))
+	logger := logr.Discard()
+
+	hospitalService := ProvideHospitalService(logger, s)
+	employeeService := ProvideEmployeeService(logger, s)
+	taskService := ProvideTaskService(logger, s)
+
+	hospital, err := hospitalService.CreateHospital(ctx, &dto.Hospital{Name: "svc-sql"})
+	require.NoError(t, err)
+
+	t.Run("WatchTaskTwice", func(t *testing.T) {
+		owner, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "sql-owner"})
+		require.NoError(t, err)
+		watcher, err := employeeService.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "sql-watcher"})
+		require.NoError(t, err)
+		task, err := taskService.CreateTask(ctx, &dto.Task{HospitalID: hospital.ID, OwnerID: owner.ID, Title: "watched", Priority: models.TaskPriorityLow})
+		require.NoError(t, err)
+
+		require.NoError(t, taskService.WatchTask(ctx, task.ID, watcher.ID))
+		require.NoError(t, taskService.WatchTask(ctx, task.ID, watcher.ID))
+		watchers, err := taskService.ListTaskWatchers(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), watchers.Total)
+	})
+}
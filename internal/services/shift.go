@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+type ShiftService struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideShiftService(logger logr.Logger, s store.Store) *ShiftService {
+	return &ShiftService{
+		logger: logger.WithName("shiftService"),
+		store:  s,
+	}
+}
+
+// CreateShift creates a shift in the hospital sh.HospitalID for one of its
+// employees. It fails with ErrConflict if the employee has another shift
+// overlapping it.
+func (ss *ShiftService) CreateShift(ctx context.Context, sh *dto.Shift) (*dto.Shift, error) {
+	var shift *dto.Shift
+	err := ss.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.GetHospital(ctx, sh.HospitalID); err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", sh.HospitalID)}
+			}
+			return err
+		}
+		if err := checkShift(ctx, tx, sh); err != nil {
+			return err
+		}
+		created, err := tx.CreateShift(ctx, sh)
+		if err != nil {
+			return err
+		}
+		shift = newShiftDTO(created)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// ListShifts lists the shifts of the hospital hid selected by the filter,
+// oldest first.
+func (ss *ShiftService) ListShifts(ctx context.Context, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) (*dto.ShiftList, error) {
+	total, err := ss.store.CountShifts(ctx, hid, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	shifts, err := ss.store.FindShifts(ctx, hid, filter, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	shifts, next := nextPage(shifts, opts, func(sh *models.Shift) string { return dto.EncodeCursor(sh.ID, nil) })
+	items := make([]*dto.Shift, len(shifts))
+	for i := range shifts {
+		items[i] = newShiftDTO(shifts[i])
+	}
+	return &dto.ShiftList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}, nil
+}
+
+func (ss *ShiftService) GetShift(ctx context.Context, id int64) (*dto.Shift, error) {
+	shift, err := getShift(ctx, ss.store, id)
+	if err != nil {
+		return nil, err
+	}
+	return newShiftDTO(shift), nil
+}
+
+// UpdateShift updates the employee, the times and the ward of the shift
+// sh.ID, which mustn't overlap another shift of the employee. If sh.Version
+// is set, the update fails with ErrPreconditionFailed unless the shift is
+// still at that version.
+func (ss *ShiftService) UpdateShift(ctx context.Context, sh *dto.Shift) (*dto.Shift, error) {
+	var shift *dto.Shift
+	err := ss.store.WithTx(ctx, func(tx store.Store) error {
+		current, err := getShift(ctx, tx, sh.ID)
+		if err != nil {
+			return err
+		}
+		sh.HospitalID = current.HospitalID
+		if err := checkShift(ctx, tx, sh); err != nil {
+			return err
+		}
+		n, err := tx.UpdateShift(ctx, sh)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return &ServiceError{ErrPreconditionFailed, fmt.Sprintf("version mismatch: %d", sh.Version)}
+		}
+		updated, err := tx.GetShift(ctx, sh.ID)
+		if err != nil {
+			return err
+		}
+		shift = newShiftDTO(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+func (ss *ShiftService) DeleteShift(ctx context.Context, id int64) error {
+	r, err := ss.store.DeleteShift(ctx, id)
+	if err != nil {
+		return err
+	}
+	if r == 0 {
+		return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+	}
+	return nil
+}
+
+// checkShift checks that the employee of the shift sh works in its hospital
+// and has no other shift overlapping it.
+func checkShift(ctx context.Context, tx store.Store, sh *dto.Shift) error {
+	if err := checkOwner(ctx, tx, sh.HospitalID, sh.EmployeeID); err != nil {
+		return err
+	}
+	n, err := tx.CountOverlappingShifts(ctx, sh.EmployeeID, sh.StartsAt, sh.EndsAt, sh.ID)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &ServiceError{ErrConflict, fmt.Sprintf("the shift overlaps %d other shifts of the employee", n)}
+	}
+	return nil
+}
+
+// getShift returns the shift id, or ErrResourceNotFound if there is none.
+func getShift(ctx context.Context, s store.ShiftStore, id int64) (*models.Shift, error) {
+	shift, err := s.GetShift(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+		}
+		return nil, err
+	}
+	return shift, nil
+}
+
+func checkOffShiftAssignment(policy string) error {
+	switch policy {
+	case models.OffShiftAllow, models.OffShiftWarn, models.OffShiftReject:
+		return nil
+	}
+	return &ServiceError{ErrBadArgument, fmt.Sprintf("invalid off-shift assignment: %s", policy)}
+}
+
+// checkOnShift applies the off-shift policy of the hospital to the
+// assignment of a task to the employee oid at now. It fails with
+// ErrConflict if the hospital rejects the assignments to the employees off
+// shift, and returns a warning if it only warns about them.
+func checkOnShift(ctx context.Context, tx store.ShiftStore, hospital *models.Hospital, oid int64, now time.Time) (string, error) {
+	if hospital.OffShiftAssignment == "" || hospital.OffShiftAssignment == models.OffShiftAllow {
+		return "", nil
+	}
+	n, err := tx.CountShifts(ctx, hospital.ID, dto.ShiftFilter{EmployeeID: oid, At: now}, dto.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	if n > 0 {
+		return "", nil
+	}
+	msg := fmt.Sprintf("employee %d is off shift", oid)
+	if hospital.OffShiftAssignment == models.OffShiftReject {
+		return "", &ServiceError{ErrConflict, msg}
+	}
+	return msg, nil
+}
+
+// onShift returns the candidates on shift at now, or all of them if none
+// is, so the hospitals minding the shifts don't get their tasks assigned
+// to the employees gone home.
+func onShift(ctx context.Context, tx store.ShiftStore, hospital *models.Hospital, candidates []*models.Employee, now time.Time) ([]*models.Employee, error) {
+	if hospital.OffShiftAssignment == "" || hospital.OffShiftAssignment == models.OffShiftAllow {
+		return candidates, nil
+	}
+	shifts, err := tx.FindShifts(ctx, hospital.ID, dto.ShiftFilter{At: now}, dto.ListOptions{Limit: math.MaxInt32})
+	if err != nil {
+		return nil, err
+	}
+	on := make(map[int64]bool)
+	for _, sh := range shifts {
+		on[sh.EmployeeID] = true
+	}
+	var ret []*models.Employee
+	for _, e := range candidates {
+		if on[e.ID] {
+			ret = append(ret, e)
+		}
+	}
+	if len(ret) == 0 {
+		return candidates, nil
+	}
+	return ret, nil
+}
+
+func newShiftDTO(shift *models.Shift) *dto.Shift {
+	return &dto.Shift{
+		ID:         shift.ID,
+		HospitalID: shift.HospitalID,
+		EmployeeID: shift.EmployeeID,
+		StartsAt:   shift.StartsAt,
+		EndsAt:     shift.EndsAt,
+		Ward:       shift.Ward,
+		Version:    shift.Version,
+		CreatedAt:  shift.CreatedAt,
+		DeletedAt:  shift.DeletedAt,
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+type SLAService struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideSLAService(logger logr.Logger, s store.Store) *SLAService {
+	return &SLAService{
+		logger: logger.WithName("slaService"),
+		store:  s,
+	}
+}
+
+// SetTaskSLA creates or replaces the SLA of the hospital sla.HospitalID for
+// the priority sla.Priority. The employees of the escalation chain have to
+// be of the same hospital, and the reassign escalation needs at least one.
+func (ss *SLAService) SetTaskSLA(ctx context.Context, sla *dto.TaskSLA) (*dto.TaskSLA, error) {
+	if sla.Escalation == "" {
+		sla.Escalation = models.EscalationNone
+	}
+	switch sla.Escalation {
+	case models.EscalationNone, models.EscalationBumpPriority:
+	case models.EscalationReassign:
+		if len(sla.EscalationChain) == 0 {
+			return nil, &ServiceError{ErrBadArgument, "the reassign escalation needs an escalation chain"}
+		}
+	default:
+		return nil, &ServiceError{ErrBadArgument, fmt.Sprintf("invalid escalation: %s", sla.Escalation)}
+	}
+	var result *dto.TaskSLA
+	err := ss.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.GetHospital(ctx, sla.HospitalID); err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", sla.HospitalID)}
+			}
+			return err
+		}
+		for _, eid := range sla.EscalationChain {
+			if err := checkOwner(ctx, tx, sla.HospitalID, eid); err != nil {
+				return err
+			}
+		}
+		id, err := ss.saveTaskSLA(ctx, tx, sla)
+		if err != nil {
+			return err
+		}
+		if err := tx.SetTaskSLAChain(ctx, id, sla.EscalationChain); err != nil {
+			return err
+		}
+		saved, err := tx.GetTaskSLA(ctx, sla.HospitalID, sla.Priority)
+		if err != nil {
+			return err
+		}
+		result = newTaskSLADTO(saved, sla.EscalationChain)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveTaskSLA creates or updates the SLA, and returns its id.
+func (ss *SLAService) saveTaskSLA(ctx context.Context, tx store.Store, sla *dto.TaskSLA) (int64, error) {
+	current, err := tx.GetTaskSLA(ctx, sla.HospitalID, sla.Priority)
+	if err != nil {
+		if !store.IsErrNotFound(err) {
+			return 0, err
+		}
+		created, err := tx.CreateTaskSLA(ctx, sla)
+		if err != nil {
+			return 0, err
+		}
+		return created.ID, nil
+	}
+	if _, err := tx.UpdateTaskSLA(ctx, sla); err != nil {
+		return 0, err
+	}
+	return current.ID, nil
+}
+
+// ListTaskSLAs lists the SLAs of the hospital hid.
+func (ss *SLAService) ListTaskSLAs(ctx context.Context, hid int64) (*dto.TaskSLAList, error) {
+	slas, err := ss.store.FindTaskSLAs(ctx, hid)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(slas))
+	for i := range slas {
+		ids[i] = slas[i].ID
+	}
+	chains, err := ss.store.FindTaskSLAChains(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*dto.TaskSLA, len(slas))
+	for i, sla := range slas {
+		items[i] = newTaskSLADTO(sla, chains[sla.ID])
+	}
+	return &dto.TaskSLAList{
+		Total: uint(len(items)),
+		Items: items,
+	}, nil
+}
+
+// DeleteTaskSLA deletes the SLA of the hospital hid for the priority. The
+// tasks it flagged stay flagged until they leave OPEN.
+func (ss *SLAService) DeleteTaskSLA(ctx context.Context, hid int64, priority string) error {
+	return ss.store.WithTx(ctx, func(tx store.Store) error {
+		sla, err := tx.GetTaskSLA(ctx, hid, priority)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("no SLA for the priority: %s", priority)}
+			}
+			return err
+		}
+		_, err = tx.DeleteTaskSLA(ctx, sla.ID)
+		return err
+	})
+}
+
+// ListTaskEscalations lists the escalations of the task id, oldest first.
+func (ss *SLAService) ListTaskEscalations(ctx context.Context, id int64) (*dto.TaskEscalationList, error) {
+	if _, err := getTask(ctx, ss.store, id); err != nil {
+		return nil, err
+	}
+	escalations, err := ss.store.FindTaskEscalations(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*dto.TaskEscalation, len(escalations))
+	for i, e := range escalations {
+		items[i] = &dto.TaskEscalation{
+			ID:        e.ID,
+			TaskID:    e.TaskID,
+			Level:     e.Level,
+			Action:    e.Action,
+			OldValue:  e.OldValue,
+			NewValue:  e.NewValue,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	return &dto.TaskEscalationList{
+		Total: uint(len(items)),
+		Items: items,
+	}, nil
+}
+
+func newTaskSLADTO(sla *models.TaskSLA, chain []int64) *dto.TaskSLA {
+	return &dto.TaskSLA{
+		HospitalID:      sla.HospitalID,
+		Priority:        sla.Priority,
+		RespondWithin:   sla.RespondWithin,
+		Escalation:      sla.Escalation,
+		EscalationChain: chain,
+		CreatedAt:       sla.CreatedAt,
+		UpdatedAt:       sla.UpdatedAt,
+	}
+}
@@ -2,125 +2,538 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 
 	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
 	"github.com/liuerfire/boxpractice/pkg/store"
 )
 
 type TaskService struct {
-	logger   logr.Logger
-	sqlStore *store.SQLStore
+	logger logr.Logger
+	store  store.Store
 }
 
-func ProvideTaskService(logger logr.Logger, sqlStore *store.SQLStore) *TaskService {
+func ProvideTaskService(logger logr.Logger, s store.Store) *TaskService {
 	return &TaskService{
-		logger:   logger.WithName("taskService"),
-		sqlStore: sqlStore,
+		logger: logger.WithName("taskService"),
+		store:  s,
 	}
 }
 
+// CreateTask creates a task in the hospital t.HospitalID. The owner has to
+// be an employee of the same hospital, and the task starts OPEN. If
+// t.AutoAssign is set, the owner is picked by the assignment strategy of the
+// hospital. A task without owner goes to the pool of the hospital, see
+// ClaimTask, unless the hospital assigns them automatically. The owner is
+// subject to the off-shift policy of the hospital. If t.TemplateID is set,
+// the task gets the labels of the template and a subtask for each item of
+// its checklist.
 func (ts *TaskService) CreateTask(ctx context.Context, t *dto.Task) (*dto.Task, error) {
-	task, err := ts.sqlStore.CreateTask(ctx, t)
+	if t.Status == "" {
+		t.Status = models.TaskStatusOpen
+	}
+	if err := checkTransition("", t.Status); err != nil {
+		return nil, err
+	}
+	var task *dto.Task
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		created, err := createTask(ctx, tx, t)
+		if err != nil {
+			return err
+		}
+		task = newTaskDTO(created)
+		if err := checkAssignee(ctx, tx, task); err != nil {
+			return err
+		}
+		if t.TemplateID == 0 {
+			return nil
+		}
+		if err := applyTaskTemplate(ctx, tx, created, t.TemplateID); err != nil {
+			return err
+		}
+		if err := setTaskLabels(ctx, tx, task); err != nil {
+			return err
+		}
+		total, done, err := tx.CountSubtasks(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			task.Subtasks = &dto.SubtaskRollup{Total: total, Done: done}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &dto.Task{
-		ID:          task.ID,
-		HospitalID:  task.HospitalID,
-		OwnerID:     task.OwnerID,
-		Title:       task.Title,
-		Description: task.Description,
-		Priority:    task.Priority,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt,
-	}, nil
+	return task, nil
 }
 
-func (ts *TaskService) ListTasksByHospital(ctx context.Context, hid int64, page, limit uint) (*dto.TaskList, error) {
-	total, err := ts.sqlStore.CountTasksByHospital(ctx, hid)
+// createTask creates the task t, whose status is already checked, along with
+// its first transition and its history.
+func createTask(ctx context.Context, tx store.Store, t *dto.Task) (*models.Task, error) {
+	hospital, err := tx.GetHospital(ctx, t.HospitalID)
 	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", t.HospitalID)}
+		}
 		return nil, err
 	}
-	tasks, err := ts.sqlStore.FindTasksByHospital(ctx, hid, page, limit)
+	if t.AutoAssign || (t.OwnerID == 0 && hospital.AutoAssign) {
+		oid, err := assignTask(ctx, tx, hospital, t)
+		if err != nil {
+			return nil, err
+		}
+		if oid == 0 && t.AutoAssign {
+			return nil, &ServiceError{ErrConflict, "no employee to assign the task to"}
+		}
+		// t is left alone, the transaction may be retried.
+		assigned := *t
+		assigned.OwnerID = oid
+		t = &assigned
+	} else if t.OwnerID != 0 {
+		if err := checkOwner(ctx, tx, t.HospitalID, t.OwnerID); err != nil {
+			return nil, err
+		}
+	}
+	if t.ParentID != 0 {
+		if err := checkParent(ctx, tx, t.HospitalID, 0, t.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	task, err := tx.CreateTask(ctx, t)
 	if err != nil {
+		if store.IsErrForeignKeyViolation(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid owner id: %d", t.OwnerID)}
+		}
 		return nil, err
 	}
-	items := make([]*dto.Task, len(tasks))
-	for i := range tasks {
-		employee := tasks[i]
-		items[i] = &dto.Task{
-			ID:          employee.ID,
-			HospitalID:  employee.HospitalID,
-			OwnerID:     employee.OwnerID,
-			Title:       employee.Title,
-			Description: employee.Description,
-			Priority:    employee.Priority,
-			Status:      employee.Status,
-			CreatedAt:   employee.CreatedAt,
-		}
+	if _, err = tx.CreateTaskTransition(ctx, task.ID, "", task.Status); err != nil {
+		return nil, err
 	}
-	return &dto.TaskList{
-		Total: total,
-		Items: items,
-	}, nil
+	if err := recordTaskChanges(ctx, tx, task.ID, nil, taskValues(newTaskDTO(task))); err != nil {
+		return nil, err
+	}
+	return task, nil
 }
 
-func (ts *TaskService) ListTasksByOwner(ctx context.Context, oid int64, page, limit uint) (*dto.TaskList, error) {
-	total, err := ts.sqlStore.CountTasksByOwner(ctx, oid)
+func (ts *TaskService) ListTasksByHospital(ctx context.Context, hid int64, filter dto.TaskFilter, opts dto.ListOptions) (*dto.TaskList, error) {
+	if err := checkTaskSort(opts); err != nil {
+		return nil, err
+	}
+	total, err := ts.store.CountTasksByHospital(ctx, hid, filter, opts)
 	if err != nil {
 		return nil, err
 	}
-	tasks, err := ts.sqlStore.FindTasksByOwner(ctx, oid, page, limit)
+	tasks, err := ts.store.FindTasksByHospital(ctx, hid, filter, pageOptions(opts))
 	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			return nil, &ServiceError{ErrBadArgument, err.Error()}
+		}
 		return nil, err
 	}
-	items := make([]*dto.Task, len(tasks))
-	for i := range tasks {
-		employee := tasks[i]
-		items[i] = &dto.Task{
-			ID:          employee.ID,
-			HospitalID:  employee.HospitalID,
-			OwnerID:     employee.OwnerID,
-			Title:       employee.Title,
-			Description: employee.Description,
-			Priority:    employee.Priority,
-			Status:      employee.Status,
-			CreatedAt:   employee.CreatedAt,
+	list := newTaskList(total, tasks, opts)
+	if err := setTaskLabels(ctx, ts.store, list.Items...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (ts *TaskService) ListTasksByOwner(ctx context.Context, oid int64, filter dto.TaskFilter, opts dto.ListOptions) (*dto.TaskList, error) {
+	if err := checkTaskSort(opts); err != nil {
+		return nil, err
+	}
+	total, err := ts.store.CountTasksByOwner(ctx, oid, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := ts.store.FindTasksByOwner(ctx, oid, filter, pageOptions(opts))
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			return nil, &ServiceError{ErrBadArgument, err.Error()}
 		}
+		return nil, err
 	}
-	return &dto.TaskList{
-		Total: total,
-		Items: items,
-	}, nil
+	list := newTaskList(total, tasks, opts)
+	if err := setTaskLabels(ctx, ts.store, list.Items...); err != nil {
+		return nil, err
+	}
+	return list, nil
 }
 
 func (ts *TaskService) GetTask(ctx context.Context, id int64) (*dto.Task, error) {
-	task, err := ts.sqlStore.GetTask(ctx, id)
+	task, err := ts.store.GetTask(ctx, id)
 	if err != nil {
 		if store.IsErrNotFound(err) {
 			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
 		}
 		return nil, err
 	}
-	return &dto.Task{
+	t := newTaskDTO(task)
+	if err := setTaskLabels(ctx, ts.store, t); err != nil {
+		return nil, err
+	}
+	total, done, err := ts.store.CountSubtasks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 {
+		t.Subtasks = &dto.SubtaskRollup{Total: total, Done: done}
+	}
+	return t, nil
+}
+
+// AssignTask makes the employee oid the owner of the task id. Both have to
+// belong to the same hospital, and the employee is subject to the off-shift
+// policy of the hospital.
+func (ts *TaskService) AssignTask(ctx context.Context, id, oid int64) (*dto.Task, error) {
+	var task *dto.Task
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		current, err := getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkOwner(ctx, tx, current.HospitalID, oid); err != nil {
+			return err
+		}
+		t := newTaskDTO(current)
+		t.OwnerID = oid
+		if err := updateTask(ctx, tx, t); err != nil {
+			return err
+		}
+		updated, err := tx.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		task = newTaskDTO(updated)
+		if err := checkAssignee(ctx, tx, task); err != nil {
+			return err
+		}
+		return setTaskLabels(ctx, tx, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// ClaimTask makes the employee oid the owner of the task id, which has to be
+// in the pool of the hospital of the employee. Only one of the employees
+// claiming a task at once gets it, the others get ErrConflict.
+func (ts *TaskService) ClaimTask(ctx context.Context, id, oid int64) (*dto.Task, error) {
+	var task *dto.Task
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		t, err := getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkOwner(ctx, tx, t.HospitalID, oid); err != nil {
+			return err
+		}
+		before := taskValues(newTaskDTO(t))
+		r, err := tx.ClaimTask(ctx, id, oid)
+		if err != nil {
+			return err
+		}
+		if r == 0 {
+			return &ServiceError{ErrConflict, fmt.Sprintf("the task is claimed already: %d", id)}
+		}
+		if t, err = tx.GetTask(ctx, id); err != nil {
+			return err
+		}
+		task = newTaskDTO(t)
+		if err := checkAssignee(ctx, tx, task); err != nil {
+			return err
+		}
+		if err := recordTaskChanges(ctx, tx, id, before, taskValues(task)); err != nil {
+			return err
+		}
+		return setTaskLabels(ctx, tx, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// checkOwner checks that the employee oid works in the hospital hid.
+func checkOwner(ctx context.Context, s store.EmployeeStore, hid, oid int64) error {
+	owner, err := s.GetEmployee(ctx, oid)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", oid)}
+		}
+		return err
+	}
+	if owner.HospitalID != hid {
+		return &ServiceError{Code: ErrPermissionDenied, Msg: "forbidden"}
+	}
+	return nil
+}
+
+// checkAssignee applies the off-shift policy of the hospital of the task
+// to its owner, if it has one, adding its warning to the task.
+func checkAssignee(ctx context.Context, tx store.Store, task *dto.Task) error {
+	if task.OwnerID == 0 {
+		return nil
+	}
+	hospital, err := tx.GetHospital(ctx, task.HospitalID)
+	if err != nil {
+		return err
+	}
+	warning, err := checkOnShift(ctx, tx, hospital, task.OwnerID, time.Now())
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		task.Warnings = append(task.Warnings, warning)
+	}
+	return nil
+}
+
+// getTask returns the task id, or ErrResourceNotFound if there is none.
+func getTask(ctx context.Context, s store.TaskStore, id int64) (*models.Task, error) {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid task id: %d", id)}
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// UpdateTask updates the task. If t.Version is set, the update fails with
+// ErrPreconditionFailed unless the task is still at that version. The status
+// only changes through TransitionTask, so a different one fails with
+// ErrInvalidTransition. Every changed field is recorded in the history of the
+// task.
+func (ts *TaskService) UpdateTask(ctx context.Context, t *dto.Task) error {
+	return ts.store.WithTx(ctx, func(tx store.Store) error {
+		current, err := getTask(ctx, tx, t.ID)
+		if err != nil {
+			return err
+		}
+		if t.Status != current.Status {
+			return &ServiceError{ErrInvalidTransition, fmt.Sprintf("the status changes through the task actions: %s -> %s", current.Status, t.Status)}
+		}
+		return updateTask(ctx, tx, t)
+	})
+}
+
+// updateTask updates the task like UpdateTask, except that a change of status
+// allowed by the task lifecycle is recorded as a transition.
+func updateTask(ctx context.Context, tx store.Store, t *dto.Task) error {
+	task, err := tx.GetTask(ctx, t.ID)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", t.ID)}
+		}
+		return err
+	}
+	if task.Status != t.Status {
+		if err := checkTransition(task.Status, t.Status); err != nil {
+			return err
+		}
+		if t.Status == models.TaskStatusCOMPLETED {
+			if err := checkBlockers(ctx, tx, t.ID); err != nil {
+				return err
+			}
+		}
+	}
+	r, err := tx.UpdateTask(ctx, t)
+	if err != nil {
+		if store.IsErrForeignKeyViolation(err) {
+			return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid owner id: %d", t.OwnerID)}
+		}
+		return err
+	}
+	if r == 0 {
+		return &ServiceError{ErrPreconditionFailed, fmt.Sprintf("version mismatch: %d", t.Version)}
+	}
+	if task.Status != t.Status {
+		if _, err = tx.CreateTaskTransition(ctx, t.ID, task.Status, t.Status); err != nil {
+			return err
+		}
+	}
+	return recordTaskChanges(ctx, tx, t.ID, taskValues(newTaskDTO(task)), taskValues(t))
+}
+
+// TransitionTask moves the task id to the status to, and returns the updated
+// task. version works like the one of UpdateTask.
+func (ts *TaskService) TransitionTask(ctx context.Context, id int64, to string, version int64) (*dto.Task, error) {
+	var task *dto.Task
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		t, err := tx.GetTask(ctx, id)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+			}
+			return err
+		}
+		task = newTaskDTO(t)
+		task.Version = version
+		task.Status = to
+		if err := updateTask(ctx, tx, task); err != nil {
+			return err
+		}
+		t, err = tx.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		task = newTaskDTO(t)
+		return setTaskLabels(ctx, tx, task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// ListTaskTransitions returns the transitions of the task id, oldest first.
+func (ts *TaskService) ListTaskTransitions(ctx context.Context, id int64) (*dto.TaskTransitionList, error) {
+	if _, err := ts.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+	transitions, err := ts.store.FindTaskTransitions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*dto.TaskTransition, len(transitions))
+	for i, t := range transitions {
+		items[i] = &dto.TaskTransition{From: t.FromStatus, To: t.ToStatus, At: t.CreatedAt}
+	}
+	return &dto.TaskTransitionList{Total: uint(len(items)), Items: items}, nil
+}
+
+// checkTransition checks that a task can move from the status from to the
+// status to.
+func checkTransition(from, to string) error {
+	if !models.CanTransitionTask(from, to) {
+		return &ServiceError{ErrInvalidTransition, fmt.Sprintf("invalid transition: %s -> %s", from, to)}
+	}
+	return nil
+}
+
+// DeleteTask soft-deletes the task.
+func (ts *TaskService) DeleteTask(ctx context.Context, id int64) error {
+	return ts.store.WithTx(ctx, func(tx store.Store) error {
+		r, err := tx.DeleteTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if r == 0 {
+			return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+		}
+		_, err = recordTaskChange(ctx, tx, id, models.TaskFieldDeleted, "false", "true")
+		return err
+	})
+}
+
+// recordTasksDeleted records the deletion of the tasks, deleted at once along
+// their owner or hospital.
+func recordTasksDeleted(ctx context.Context, tx store.Store, tasks []*models.Task) error {
+	for _, t := range tasks {
+		if _, err := recordTaskChange(ctx, tx, t.ID, models.TaskFieldDeleted, "false", "true"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreTasksDeletedAt restores the tasks deleted at at, along their
+// hospital or owner, recording the restoration of each.
+func restoreTasksDeletedAt(ctx context.Context, tx store.Store, tasks []*models.Task, at time.Time) error {
+	for _, t := range tasks {
+		if t.DeletedAt == nil || !t.DeletedAt.Equal(at) {
+			continue
+		}
+		if _, err := tx.RestoreTask(ctx, t.ID); err != nil {
+			return err
+		}
+		if _, err := recordTaskChange(ctx, tx, t.ID, models.TaskFieldDeleted, "true", "false"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreTask undoes DeleteTask. Restoring a task which isn't deleted does
+// nothing.
+func (ts *TaskService) RestoreTask(ctx context.Context, id int64) error {
+	return ts.store.WithTx(ctx, func(tx store.Store) error {
+		r, err := tx.RestoreTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if r == 0 {
+			if _, err := tx.GetTask(ctx, id); err != nil {
+				if store.IsErrNotFound(err) {
+					return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+				}
+				return err
+			}
+			return nil
+		}
+		_, err = recordTaskChange(ctx, tx, id, models.TaskFieldDeleted, "true", "false")
+		return err
+	})
+}
+
+// checkTaskSort checks that the tasks can be sorted by the fields of opts.
+func checkTaskSort(opts dto.ListOptions) error {
+	for _, f := range opts.Sort {
+		if !store.IsTaskSortField(f.Name) {
+			return &ServiceError{ErrBadArgument, fmt.Sprintf("invalid sort field: %s", f.Name)}
+		}
+	}
+	return nil
+}
+
+func newTaskList(total uint, tasks []*models.Task, opts dto.ListOptions) *dto.TaskList {
+	tasks, next := nextPage(tasks, opts, func(t *models.Task) string {
+		return dto.EncodeCursor(t.ID, store.TaskCursorKey(t, opts.Sort))
+	})
+	items := make([]*dto.Task, len(tasks))
+	for i := range tasks {
+		items[i] = newTaskDTO(tasks[i])
+	}
+	return &dto.TaskList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}
+}
+
+func newTaskDTO(task *models.Task) *dto.Task {
+	t := &dto.Task{
 		ID:          task.ID,
 		HospitalID:  task.HospitalID,
-		OwnerID:     task.OwnerID,
 		Title:       task.Title,
 		Description: task.Description,
 		Priority:    task.Priority,
 		Status:      task.Status,
+		DueAt:       task.DueAt,
+		Overdue:     task.Overdue,
+		Breached:    task.Breached,
+		Version:     task.Version,
 		CreatedAt:   task.CreatedAt,
-	}, nil
-}
-
-func (ts *TaskService) UpdateTask(ctx context.Context, t *dto.Task) error {
-	r, err := ts.sqlStore.UpdateTask(ctx, t)
-	if r == 0 {
-		return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", t.ID)}
+		DeletedAt:   task.DeletedAt,
+	}
+	if task.OwnerID != nil {
+		t.OwnerID = *task.OwnerID
+	}
+	if task.ParentID != nil {
+		t.ParentID = *task.ParentID
+	}
+	if task.RecurrenceID != nil {
+		t.RecurrenceID = *task.RecurrenceID
+		t.OccurrenceAt = task.OccurrenceAt
 	}
-	return err
+	return t
 }
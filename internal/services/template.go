@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+type TemplateService struct {
+	logger logr.Logger
+	store  store.Store
+}
+
+func ProvideTemplateService(logger logr.Logger, s store.Store) *TemplateService {
+	return &TemplateService{
+		logger: logger.WithName("templateService"),
+		store:  s,
+	}
+}
+
+// CreateTaskTemplate creates a template in the hospital t.HospitalID. Its
+// labels have to belong to the same hospital.
+func (ts *TemplateService) CreateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (*dto.TaskTemplate, error) {
+	var template *dto.TaskTemplate
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.GetHospital(ctx, t.HospitalID); err != nil {
+			if store.IsErrNotFound(err) {
+				return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", t.HospitalID)}
+			}
+			return err
+		}
+		if err := checkTemplateLabels(ctx, tx, t.HospitalID, t.LabelIDs); err != nil {
+			return err
+		}
+		created, err := tx.CreateTaskTemplate(ctx, t)
+		if err != nil {
+			return err
+		}
+		template, err = setTaskTemplateParts(ctx, tx, created, t)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// ListTaskTemplates lists the templates of the hospital hid, oldest first.
+func (ts *TemplateService) ListTaskTemplates(ctx context.Context, hid int64, opts dto.ListOptions) (*dto.TaskTemplateList, error) {
+	total, err := ts.store.CountTaskTemplates(ctx, hid, opts)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := ts.store.FindTaskTemplates(ctx, hid, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	templates, next := nextPage(templates, opts, func(t *models.TaskTemplate) string { return dto.EncodeCursor(t.ID, nil) })
+	ids := make([]int64, len(templates))
+	for i := range templates {
+		ids[i] = templates[i].ID
+	}
+	labels, err := ts.store.FindTaskTemplateLabelIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ts.store.FindTaskTemplateItems(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*dto.TaskTemplate, len(templates))
+	for i, t := range templates {
+		list[i] = newTaskTemplateDTO(t, labels[t.ID], items[t.ID])
+	}
+	return &dto.TaskTemplateList{
+		Total:      total,
+		Items:      list,
+		NextCursor: next,
+	}, nil
+}
+
+func (ts *TemplateService) GetTaskTemplate(ctx context.Context, id int64) (*dto.TaskTemplate, error) {
+	return getTaskTemplateDTO(ctx, ts.store, id)
+}
+
+// UpdateTaskTemplate updates the template t.ID, along with its labels and
+// its checklist. The tasks already created from it are left as they are.
+// If t.Version is set, the update fails with ErrPreconditionFailed unless
+// the template is still at that version.
+func (ts *TemplateService) UpdateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (*dto.TaskTemplate, error) {
+	var template *dto.TaskTemplate
+	err := ts.store.WithTx(ctx, func(tx store.Store) error {
+		current, err := getTaskTemplate(ctx, tx, t.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkTemplateLabels(ctx, tx, current.HospitalID, t.LabelIDs); err != nil {
+			return err
+		}
+		n, err := tx.UpdateTaskTemplate(ctx, t)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return &ServiceError{ErrPreconditionFailed, fmt.Sprintf("version mismatch: %d", t.Version)}
+		}
+		updated, err := tx.GetTaskTemplate(ctx, t.ID)
+		if err != nil {
+			return err
+		}
+		template, err = setTaskTemplateParts(ctx, tx, updated, t)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// DeleteTaskTemplate soft-deletes the template. The tasks created from it
+// are kept.
+func (ts *TemplateService) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	r, err := ts.store.DeleteTaskTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	if r == 0 {
+		return &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid id: %d", id)}
+	}
+	return nil
+}
+
+// applyTaskTemplate gives the labels of the template tid to the task just
+// created from it, and creates a subtask of the task for each item of its
+// checklist. The subtasks go to the owner of the task.
+func applyTaskTemplate(ctx context.Context, tx store.Store, task *models.Task, tid int64) error {
+	template, err := getTaskTemplateDTO(ctx, tx, tid)
+	if err != nil {
+		return err
+	}
+	if template.HospitalID != task.HospitalID {
+		return &ServiceError{ErrPermissionDenied, "the template belongs to another hospital"}
+	}
+	for _, lid := range template.LabelIDs {
+		label, err := getLabel(ctx, tx, lid)
+		if err != nil {
+			return err
+		}
+		if err := labelTask(ctx, tx, task, label); err != nil {
+			return err
+		}
+	}
+	for _, title := range template.Checklist {
+		subtask := &dto.Task{
+			HospitalID: task.HospitalID,
+			ParentID:   task.ID,
+			Title:      title,
+			Priority:   task.Priority,
+			Status:     models.TaskStatusOpen,
+		}
+		if task.OwnerID != nil {
+			subtask.OwnerID = *task.OwnerID
+		}
+		if _, err := createTask(ctx, tx, subtask); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTemplateLabels checks that the labels belong to the hospital hid.
+func checkTemplateLabels(ctx context.Context, tx store.Store, hid int64, labelIDs []int64) error {
+	for _, lid := range labelIDs {
+		label, err := getLabel(ctx, tx, lid)
+		if err != nil {
+			return err
+		}
+		if label.HospitalID != hid {
+			return &ServiceError{ErrPermissionDenied, "the label belongs to another hospital"}
+		}
+	}
+	return nil
+}
+
+// setTaskTemplateParts replaces the labels and the checklist of the
+// template with those of t, and returns the whole template.
+func setTaskTemplateParts(ctx context.Context, tx store.Store, template *models.TaskTemplate, t *dto.TaskTemplate) (*dto.TaskTemplate, error) {
+	if err := tx.SetTaskTemplateLabels(ctx, template.ID, t.LabelIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.SetTaskTemplateItems(ctx, template.ID, t.Checklist); err != nil {
+		return nil, err
+	}
+	labels, err := tx.FindTaskTemplateLabelIDs(ctx, []int64{template.ID})
+	if err != nil {
+		return nil, err
+	}
+	return newTaskTemplateDTO(template, labels[template.ID], t.Checklist), nil
+}
+
+// getTaskTemplate returns the template id, or ErrResourceNotFound if there
+// is none.
+func getTaskTemplate(ctx context.Context, s store.TaskTemplateStore, id int64) (*models.TaskTemplate, error) {
+	template, err := s.GetTaskTemplate(ctx, id)
+	if err != nil {
+		if store.IsErrNotFound(err) {
+			return nil, &ServiceError{ErrResourceNotFound, fmt.Sprintf("invalid template id: %d", id)}
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+// getTaskTemplateDTO returns the template id along with its labels and its
+// checklist.
+func getTaskTemplateDTO(ctx context.Context, s store.TaskTemplateStore, id int64) (*dto.TaskTemplate, error) {
+	template, err := getTaskTemplate(ctx, s, id)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := s.FindTaskTemplateLabelIDs(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.FindTaskTemplateItems(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	return newTaskTemplateDTO(template, labels[id], items[id]), nil
+}
+
+func newTaskTemplateDTO(t *models.TaskTemplate, labelIDs []int64, checklist []string) *dto.TaskTemplate {
+	return &dto.TaskTemplate{
+		ID:          t.ID,
+		HospitalID:  t.HospitalID,
+		Title:       t.Title,
+		Description: t.Description,
+		Priority:    t.Priority,
+		LabelIDs:    labelIDs,
+		Checklist:   checklist,
+		Version:     t.Version,
+		CreatedAt:   t.CreatedAt,
+		DeletedAt:   t.DeletedAt,
+	}
+}
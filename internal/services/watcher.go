@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+	"github.com/liuerfire/boxpractice/pkg/store"
+)
+
+// WatchTask makes the employee eid a watcher of the task id, so they
+// receive the changes of the task like its owner does. They have to belong
+// to the hospital of the task. Watching a task twice does nothing.
+func (ts *TaskService) WatchTask(ctx context.Context, id, eid int64) error {
+	return ts.store.WithTx(ctx, func(tx store.Store) error {
+		task, err := getTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkOwner(ctx, tx, task.HospitalID, eid); err != nil {
+			return err
+		}
+		// Look before inserting: a failed insert aborts the whole
+		// transaction on PostgreSQL.
+		ids, err := tx.FindTaskWatcherIDs(ctx, id)
+		if err != nil {
+			return err
+		}
+		if containsID(ids, eid) {
+			return nil
+		}
+		return tx.CreateTaskWatcher(ctx, id, eid)
+	})
+}
+
+// UnwatchTask undoes WatchTask.
+func (ts *TaskService) UnwatchTask(ctx context.Context, id, eid int64) error {
+	return ts.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := getTask(ctx, tx, id); err != nil {
+			return err
+		}
+		r, err := tx.DeleteTaskWatcher(ctx, id, eid)
+		if err != nil {
+			return err
+		}
+		if r == 0 {
+			return &ServiceError{ErrResourceNotFound, fmt.Sprintf("employee %d doesn't watch task %d", eid, id)}
+		}
+		return nil
+	})
+}
+
+// ListTaskWatchers lists the watchers of the task id, by id. The watchers
+// who left the hospital are left out.
+func (ts *TaskService) ListTaskWatchers(ctx context.Context, id int64) (*dto.EmployeeList, error) {
+	if _, err := getTask(ctx, ts.store, id); err != nil {
+		return nil, err
+	}
+	ids, err := ts.store.FindTaskWatcherIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*dto.Employee, 0, len(ids))
+	for _, eid := range ids {
+		e, err := ts.store.GetEmployee(ctx, eid)
+		if err != nil {
+			if store.IsErrNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		items = append(items, newEmployeeDTO(e))
+	}
+	return &dto.EmployeeList{
+		Total: uint(len(items)),
+		Items: items,
+	}, nil
+}
+
+// ListWatchedTasks lists the tasks the employee eid watches.
+func (ts *TaskService) ListWatchedTasks(ctx context.Context, eid int64, filter dto.TaskFilter, opts dto.ListOptions) (*dto.TaskList, error) {
+	if err := checkTaskSort(opts); err != nil {
+		return nil, err
+	}
+	total, err := ts.store.CountWatchedTasks(ctx, eid, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := ts.store.FindWatchedTasks(ctx, eid, filter, pageOptions(opts))
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			return nil, &ServiceError{ErrBadArgument, err.Error()}
+		}
+		return nil, err
+	}
+	list := newTaskList(total, tasks, opts)
+	if err := setTaskLabels(ctx, ts.store, list.Items...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListTaskEvents lists the changes of the tasks sent to the employee eid as
+// their owner or watcher, oldest first.
+func (ts *TaskService) ListTaskEvents(ctx context.Context, eid int64, opts dto.ListOptions) (*dto.TaskEventList, error) {
+	total, err := ts.store.CountTaskEvents(ctx, eid)
+	if err != nil {
+		return nil, err
+	}
+	events, err := ts.store.FindTaskEvents(ctx, eid, pageOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	events, next := nextPage(events, opts, func(e *models.TaskEvent) string { return dto.EncodeCursor(e.ID, nil) })
+	items := make([]*dto.TaskEvent, len(events))
+	for i, e := range events {
+		items[i] = &dto.TaskEvent{
+			ID:         e.ID,
+			EmployeeID: e.EmployeeID,
+			Change: newTaskChangeDTO(&models.TaskChange{
+				ID:        e.ChangeID,
+				TaskID:    e.TaskID,
+				Field:     e.Field,
+				OldValue:  e.OldValue,
+				NewValue:  e.NewValue,
+				ActorID:   e.ActorID,
+				CreatedAt: e.CreatedAt,
+			}),
+		}
+	}
+	return &dto.TaskEventList{
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+	}, nil
+}
+
+// containsID tells whether id is one of ids.
+func containsID(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+// Package blob stores the bytes which don't belong in the database, such as
+// the attachments of the tasks. The database only keeps the key of a blob.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when there is no blob with the given key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a key-value store of blobs. The keys are slash-separated paths
+// made of the characters [A-Za-z0-9._-], none of them being "." or "..".
+type Store interface {
+	// Put stores the content of r under key, replacing any blob with the
+	// same key, and returns the number of bytes written. Nothing is stored
+	// if reading r fails.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Get returns a reader of the blob, which the caller has to close.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob. Deleting a missing blob isn't an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFSStore(t.TempDir())
+	require.NoError(t, err)
+
+	n, err := s.Put(ctx, "tasks/1/a.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	r, err := s.Get(ctx, "tasks/1/a.txt")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	// A failed Put leaves the previous blob as is.
+	errRead := errors.New("read failed")
+	_, err = s.Put(ctx, "tasks/1/a.txt", io.MultiReader(bytes.NewReader([]byte("partial")), &failingReader{errRead}))
+	assert.ErrorIs(t, err, errRead)
+	r, err = s.Get(ctx, "tasks/1/a.txt")
+	require.NoError(t, err)
+	data, _ = io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, s.Delete(ctx, "tasks/1/a.txt"))
+	require.NoError(t, s.Delete(ctx, "tasks/1/a.txt"))
+	_, err = s.Get(ctx, "tasks/1/a.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", "../a", "tasks//a", "tasks/./a", "/abs", "a b"} {
+		_, err := s.Put(ctx, key, strings.NewReader("x"))
+		assert.Error(t, err, key)
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
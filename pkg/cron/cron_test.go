@@ -0,0 +1,60 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	utc := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return ts
+	}
+	for _, tc := range []struct {
+		spec, from, next string
+	}{
+		{"* * * * *", "2022-03-01T10:00:30Z", "2022-03-01T10:01:00Z"},
+		{"0 8,20 * * *", "2022-03-01T08:00:00Z", "2022-03-01T20:00:00Z"},
+		{"0 8,20 * * *", "2022-03-01T20:00:00Z", "2022-03-02T08:00:00Z"},
+		{"*/15 9-17 * * 1-5", "2022-03-04T17:45:00Z", "2022-03-07T09:00:00Z"},
+		{"30 6 1 * *", "2022-12-15T00:00:00Z", "2023-01-01T06:30:00Z"},
+		{"0 0 29 2 *", "2022-03-01T00:00:00Z", "2024-02-29T00:00:00Z"},
+		{"0 0 13 * 5", "2022-03-01T00:00:00Z", "2022-03-04T00:00:00Z"},
+		{"0 12 * * 7", "2022-03-01T00:00:00Z", "2022-03-06T12:00:00Z"},
+		{"@hourly", "2022-03-01T10:59:00Z", "2022-03-01T11:00:00Z"},
+		{"0 0 30 2 *", "2022-03-01T00:00:00Z", "0001-01-01T00:00:00Z"},
+	} {
+		s, err := Parse(tc.spec)
+		require.NoError(t, err, tc.spec)
+		assert.Equal(t, utc(tc.next), s.Next(utc(tc.from)).UTC(), tc.spec)
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	s, err := Parse("30 2 * * *")
+	require.NoError(t, err)
+
+	// 2:30 doesn't exist on the day the clocks go forward.
+	next := s.Next(time.Date(2022, 3, 12, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2022, 3, 14, 2, 30, 0, 0, loc), next)
+
+	s, err = Parse("0 8 * * *")
+	require.NoError(t, err)
+	next = s.Next(time.Date(2022, 11, 5, 9, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2022, 11, 6, 8, 0, 0, 0, loc), next)
+	assert.Equal(t, 25*time.Hour, next.Sub(time.Date(2022, 11, 5, 8, 0, 0, 0, loc)))
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"time"
+)
+
+type Attachment struct {
+	ID          int64     `json:"id,omitempty"`
+	TaskID      int64     `json:"taskId,omitempty"`
+	UploaderID  int64     `json:"uploaderId,omitempty"`
+	Filename    string    `json:"filename,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Size        int64     `json:"size"`
+	BlobKey     string    `json:"-"`
+	CreatedAt   time.Time `json:"createdAt,omitempty"`
+}
+
+type AttachmentList struct {
+	Total uint          `json:"total"`
+	Items []*Attachment `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
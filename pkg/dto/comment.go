@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"time"
+)
+
+type Comment struct {
+	ID        int64     `json:"id,omitempty"`
+	TaskID    int64     `json:"taskId,omitempty"`
+	AuthorID  int64     `json:"authorId,omitempty"`
+	Body      string    `json:"body,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+type CommentList struct {
+	Total uint       `json:"total"`
+	Items []*Comment `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
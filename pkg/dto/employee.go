@@ -5,15 +5,18 @@ import (
 )
 
 type Employee struct {
-	ID         int64     `json:"id,omitempty"`
-	HospitalID int64     `json:"hospitalId,omitempty"`
-	Username   string    `json:"username,omitempty"`
-	FirstName  string    `json:"firstName,omitempty"`
-	LastName   string    `json:"lastName,omitempty"`
-	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	ID         int64      `json:"id,omitempty"`
+	HospitalID int64      `json:"hospitalId,omitempty"`
+	Username   string     `json:"username,omitempty"`
+	FirstName  string     `json:"firstName,omitempty"`
+	LastName   string     `json:"lastName,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt,omitempty"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
 }
 
 type EmployeeList struct {
 	Total uint        `json:"total"`
 	Items []*Employee `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
@@ -5,13 +5,28 @@ import (
 )
 
 type Hospital struct {
-	ID          int64     `json:"id,omitempty"`
-	Name        string    `json:"name,omitempty"`
-	DisplayName string    `json:"displayName,omitempty"`
-	CreatedAt   time.Time `json:"createdAt,omitempty"`
+	ID          int64  `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	// Timezone is the IANA name of the time zone the schedules of the
+	// recurring tasks are in.
+	Timezone string `json:"timezone,omitempty"`
+	// AssignmentStrategy picks the owners of the tasks created with the
+	// owner "auto", and of all the tasks created without owner if
+	// AutoAssign is set.
+	AssignmentStrategy string `json:"assignmentStrategy,omitempty"`
+	AutoAssign         bool   `json:"autoAssign"`
+	// OffShiftAssignment allows, warns about or rejects the assignments
+	// to the employees who aren't on shift.
+	OffShiftAssignment string     `json:"offShiftAssignment,omitempty"`
+	Version            int64      `json:"version,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt,omitempty"`
+	DeletedAt          *time.Time `json:"deletedAt,omitempty"`
 }
 
 type HospitalList struct {
 	Total uint        `json:"total"`
 	Items []*Hospital `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
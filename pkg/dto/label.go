@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"time"
+)
+
+type Label struct {
+	ID         int64     `json:"id,omitempty"`
+	HospitalID int64     `json:"hospitalId,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Color      string    `json:"color,omitempty"`
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+}
+
+type LabelList struct {
+	Total uint     `json:"total"`
+	Items []*Label `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// SortField is one of the fields a list is sorted by.
+type SortField struct {
+	Name string
+	Desc bool
+}
+
+// ListOptions controls which page of a list is returned.
+type ListOptions struct {
+	Offset uint
+	Limit  uint
+	// Sort orders the items, by id if empty. Only the tasks can be sorted
+	// by other fields. The id always breaks the ties.
+	Sort []SortField
+	// AfterID and AfterKey make the list start after the item AfterID,
+	// whose values of the Sort fields are AfterKey, regardless of the items
+	// inserted or deleted before it. They're decoded from a cursor.
+	AfterID  int64
+	AfterKey []string
+	// IncludeDeleted makes the soft-deleted items part of the list.
+	IncludeDeleted bool
+}
+
+type cursor struct {
+	ID  int64    `json:"id"`
+	Key []string `json:"key,omitempty"`
+}
+
+// EncodeCursor returns the opaque cursor of the page starting after the
+// item id, whose values of the sort fields are key.
+func EncodeCursor(id int64, key []string) string {
+	data, _ := json.Marshal(cursor{ID: id, Key: key})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the id and the key given to EncodeCursor.
+func DecodeCursor(s string) (int64, []string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, nil, err
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return 0, nil, err
+	}
+	if c.ID <= 0 {
+		return 0, nil, errors.New("invalid cursor")
+	}
+	return c.ID, c.Key, nil
+}
+
+const (
+	// DeleteRestrict refuses to delete while there are open tasks.
+	DeleteRestrict = "restrict"
+	// DeleteReassign gives the open tasks to another employee.
+	DeleteReassign = "reassign"
+	// DeleteCascade deletes the tasks along, and the employees too when a
+	// hospital is deleted.
+	DeleteCascade = "cascade"
+)
+
+// DeleteOptions tells what happens to the open tasks of the hospital or the
+// employee being deleted.
+type DeleteOptions struct {
+	// Policy is one of the Delete* constants, DeleteRestrict if empty.
+	Policy string
+	// ReassignTo is the employee given the open tasks by DeleteReassign.
+	ReassignTo int64
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+)
+
+type TaskRecurrence struct {
+	ID          int64  `json:"id,omitempty"`
+	HospitalID  int64  `json:"hospitalId,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    string `json:"priority,omitempty"`
+	// DueIn is the number of seconds from an occurrence to the due date of
+	// its task, 0 for no due date.
+	DueIn    int64  `json:"dueIn,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+	// OwnerIDs is the rotation of the owners of the tasks, which are given
+	// in turn to each of them. A single owner is the default owner.
+	OwnerIDs  []int64    `json:"ownerIds,omitempty"`
+	NextAt    time.Time  `json:"nextAt,omitempty"`
+	Version   int64      `json:"version,omitempty"`
+	CreatedAt time.Time  `json:"createdAt,omitempty"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+type TaskRecurrenceList struct {
+	Total uint              `json:"total"`
+	Items []*TaskRecurrence `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
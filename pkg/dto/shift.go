@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+)
+
+type Shift struct {
+	ID         int64      `json:"id,omitempty"`
+	HospitalID int64      `json:"hospitalId,omitempty"`
+	EmployeeID int64      `json:"employeeId,omitempty"`
+	StartsAt   time.Time  `json:"startsAt"`
+	EndsAt     time.Time  `json:"endsAt"`
+	Ward       string     `json:"ward,omitempty"`
+	Version    int64      `json:"version,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt,omitempty"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+}
+
+type ShiftList struct {
+	Total uint     `json:"total"`
+	Items []*Shift `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ShiftFilter selects the shifts of a list. The zero value selects them all.
+type ShiftFilter struct {
+	EmployeeID int64
+	Ward       string
+	// At only selects the shifts going on at that time.
+	At time.Time
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+)
+
+type TaskSLA struct {
+	HospitalID int64  `json:"hospitalId,omitempty"`
+	Priority   string `json:"priority,omitempty"`
+	// RespondWithin is the number of seconds a task may stay OPEN since it
+	// was last moved or escalated.
+	RespondWithin int64 `json:"respondWithin,omitempty"`
+	// Escalation is what is done to the tasks breaching the SLA, which are
+	// escalated again every RespondWithin while they stay OPEN.
+	Escalation string `json:"escalation,omitempty"`
+	// EscalationChain is the employees the reassign escalation gives the
+	// task to, each one in turn.
+	EscalationChain []int64   `json:"escalationChain,omitempty"`
+	CreatedAt       time.Time `json:"createdAt,omitempty"`
+	UpdatedAt       time.Time `json:"updatedAt,omitempty"`
+}
+
+type TaskSLAList struct {
+	Total uint       `json:"total"`
+	Items []*TaskSLA `json:"items"`
+}
+
+type TaskEscalation struct {
+	ID       int64  `json:"id,omitempty"`
+	TaskID   int64  `json:"taskId,omitempty"`
+	Level    int64  `json:"level,omitempty"`
+	Action   string `json:"action,omitempty"`
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue,omitempty"`
+	// CreatedAt is the time the escalation was evaluated at.
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+type TaskEscalationList struct {
+	Total uint              `json:"total"`
+	Items []*TaskEscalation `json:"items"`
+}
@@ -5,17 +5,160 @@ import (
 )
 
 type Task struct {
-	ID          int64     `json:"id,omitempty"`
-	HospitalID  int64     `json:"HospitalId,omitempty"`
-	OwnerID     int64     `json:"ownerId,omitempty"`
-	Title       string    `json:"title,omitempty"`
-	Description string    `json:"description,omitempty"`
-	Priority    string    `json:"priority,omitempty"`
-	Status      string    `json:"status,omitempty"`
-	CreatedAt   time.Time `json:"createdAt,omitempty"`
+	ID         int64 `json:"id,omitempty"`
+	HospitalID int64 `json:"HospitalId,omitempty"`
+	// OwnerID is 0 while the task is in the pool of its hospital.
+	OwnerID int64 `json:"ownerId,omitempty"`
+	// AutoAssign makes the assignment strategy of the hospital pick the
+	// owner of the task being created. It's set by the owner "auto".
+	AutoAssign bool `json:"-"`
+	// TemplateID makes the task being created get the labels and the
+	// checklist of the template. It's set by the fromTemplate param.
+	TemplateID int64 `json:"-"`
+	ParentID   int64 `json:"parentId,omitempty"`
+	// RecurrenceID and OccurrenceAt are only set on the tasks created by a
+	// recurrence.
+	RecurrenceID int64      `json:"recurrenceId,omitempty"`
+	OccurrenceAt *time.Time `json:"occurrenceAt,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	Priority     string     `json:"priority,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	DueAt        *time.Time `json:"dueAt,omitempty"`
+	Overdue      bool       `json:"overdue,omitempty"`
+	// Breached is set while the task is OPEN past the SLA of its priority.
+	Breached bool     `json:"breached,omitempty"`
+	Labels   []*Label `json:"labels,omitempty"`
+	// Subtasks is only set on a single task which has subtasks.
+	Subtasks *SubtaskRollup `json:"subtasks,omitempty"`
+	// Warnings is only set on a task just assigned, when the assignment
+	// went through despite a policy of its hospital, such as its owner
+	// being off shift.
+	Warnings  []string   `json:"warnings,omitempty"`
+	Version   int64      `json:"version,omitempty"`
+	CreatedAt time.Time  `json:"createdAt,omitempty"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
+// SubtaskRollup sums up the progress of the subtasks of a task.
+type SubtaskRollup struct {
+	Total uint `json:"total"`
+	Done  uint `json:"done"`
+}
+
+// TaskFilter selects the tasks of a list. The zero value selects them all.
+type TaskFilter struct {
+	Statuses      []string
+	Priorities    []string
+	OwnerID       int64
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	DueAfter      time.Time
+	DueBefore     time.Time
+	// Overdue only selects the open tasks flagged by the overdue sweeper.
+	Overdue bool
+	// Breached only selects the OPEN tasks flagged by the SLA evaluator.
+	Breached bool
+	// Unassigned only selects the tasks which have no owner.
+	Unassigned bool
+	// LabelIDs selects the tasks tagged with any of the labels, or with all
+	// of them if LabelMatch is LabelMatchAll.
+	LabelIDs   []int64
+	LabelMatch string
+}
+
+// IsEmpty tells whether the filter selects all the tasks. LabelMatch alone
+// selects nothing.
+func (f *TaskFilter) IsEmpty() bool {
+	return len(f.Statuses) == 0 && len(f.Priorities) == 0 && f.OwnerID == 0 &&
+		f.CreatedAfter.IsZero() && f.CreatedBefore.IsZero() &&
+		f.DueAfter.IsZero() && f.DueBefore.IsZero() &&
+		!f.Overdue && !f.Breached && !f.Unassigned && len(f.LabelIDs) == 0
+}
+
+// The ways the labels of a TaskFilter match those of a task.
+const (
+	LabelMatchAny = "any"
+	LabelMatchAll = "all"
+)
+
 type TaskList struct {
 	Total uint    `json:"total"`
 	Items []*Task `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// TaskTransition is a change of the status of a task.
+type TaskTransition struct {
+	From string    `json:"from,omitempty"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+type TaskTransitionList struct {
+	Total uint              `json:"total"`
+	Items []*TaskTransition `json:"items"`
+}
+
+// TaskChange is a change of one field of a task.
+type TaskChange struct {
+	ID       int64  `json:"id,omitempty"`
+	TaskID   int64  `json:"taskId,omitempty"`
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+	// ActorID is the employee who made the change, 0 if unknown.
+	ActorID   int64     `json:"actorId,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+type TaskChangeList struct {
+	Total uint          `json:"total"`
+	Items []*TaskChange `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// The actions of a TaskBulk.
+const (
+	BulkActionAssign      = "assign"
+	BulkActionSetStatus   = "setStatus"
+	BulkActionSetPriority = "setPriority"
+	BulkActionAddLabel    = "addLabel"
+)
+
+// TaskBulk applies one action to several tasks of a hospital.
+type TaskBulk struct {
+	// TaskIDs selects the tasks. If it's empty, Filter selects them.
+	TaskIDs []int64    `json:"taskIds,omitempty"`
+	Filter  TaskFilter `json:"-"`
+	Action  string     `json:"action"`
+	// The argument of the action: OwnerID for BulkActionAssign, Status for
+	// BulkActionSetStatus, Priority for BulkActionSetPriority and LabelID
+	// for BulkActionAddLabel.
+	OwnerID  int64  `json:"ownerId,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	LabelID  int64  `json:"labelId,omitempty"`
+	// DryRun reports what the action would do without applying it.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// TaskBulkReport tells how a TaskBulk went for each of its tasks. The
+// changes are only applied if none of the tasks failed.
+type TaskBulkReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Applied bool              `json:"applied"`
+	Failed  uint              `json:"failed"`
+	Items   []*TaskBulkResult `json:"items"`
+}
+
+// TaskBulkResult is the result of a TaskBulk for one task: the task once
+// changed, or the reason it can't be.
+type TaskBulkResult struct {
+	TaskID int64  `json:"taskId"`
+	Task   *Task  `json:"task,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Msg    string `json:"msg,omitempty"`
 }
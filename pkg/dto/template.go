@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"time"
+)
+
+type TaskTemplate struct {
+	ID          int64  `json:"id,omitempty"`
+	HospitalID  int64  `json:"hospitalId,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    string `json:"priority,omitempty"`
+	// LabelIDs are the labels given to the tasks created from the template.
+	LabelIDs []int64 `json:"labelIds,omitempty"`
+	// Checklist is the titles of the subtasks created along each task, in
+	// order.
+	Checklist []string   `json:"checklist,omitempty"`
+	Version   int64      `json:"version,omitempty"`
+	CreatedAt time.Time  `json:"createdAt,omitempty"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+type TaskTemplateList struct {
+	Total uint            `json:"total"`
+	Items []*TaskTemplate `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+// TaskEvent is a change of a task, as received by one of its owners or
+// watchers.
+type TaskEvent struct {
+	ID         int64       `json:"id,omitempty"`
+	EmployeeID int64       `json:"employeeId,omitempty"`
+	Change     *TaskChange `json:"change"`
+}
+
+type TaskEventList struct {
+	Total uint         `json:"total"`
+	Items []*TaskEvent `json:"items"`
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Attachment is a file attached to a task. Its content is kept in a blob
+// store under BlobKey, the database only has its metadata.
+type Attachment struct {
+	ID          int64      `db:"id"`
+	TaskID      int64      `db:"task_id"`
+	UploaderID  *int64     `db:"uploader_id"`
+	Filename    string     `db:"filename"`
+	ContentType string     `db:"content_type"`
+	Size        int64      `db:"size"`
+	BlobKey     string     `db:"blob_key"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+}
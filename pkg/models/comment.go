@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// Comment is a note left on a task by an employee.
+type Comment struct {
+	ID        int64      `db:"id"`
+	TaskID    int64      `db:"task_id"`
+	AuthorID  int64      `db:"author_id"`
+	Body      string     `db:"body"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
@@ -4,10 +4,38 @@ import (
 	"time"
 )
 
+// The strategies picking the owners of the tasks assigned automatically.
+const (
+	AssignmentRoundRobin    = "roundRobin"
+	AssignmentLeastOpen     = "leastOpen"
+	AssignmentPriorityAware = "priorityAware"
+)
+
+// The policies for the assignments to the employees who aren't on shift.
+const (
+	OffShiftAllow  = "allow"
+	OffShiftWarn   = "warn"
+	OffShiftReject = "reject"
+)
+
 type Hospital struct {
-	ID          int64     `db:"id"`
-	Name        string    `db:"name"`
-	DisplayName string    `db:"display_name"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          int64  `db:"id"`
+	Name        string `db:"name"`
+	DisplayName string `db:"display_name"`
+	Timezone    string `db:"timezone"`
+	// AssignmentStrategy picks the owners of the tasks assigned
+	// automatically, and AutoAssign makes the tasks created without owner
+	// assigned rather than pooled.
+	AssignmentStrategy string `db:"assignment_strategy"`
+	AutoAssign         bool   `db:"auto_assign"`
+	// LastAssigneeID is the employee last picked by the round-robin
+	// strategy.
+	LastAssigneeID *int64 `db:"last_assignee_id"`
+	// OffShiftAssignment is the policy for the assignments to the
+	// employees who aren't on shift.
+	OffShiftAssignment string     `db:"off_shift_assignment"`
+	Version            int64      `db:"version"`
+	CreatedAt          time.Time  `db:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+	DeletedAt          *time.Time `db:"deleted_at"`
 }
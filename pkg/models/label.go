@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Label is a hospital-scoped category the tasks can be tagged with.
+type Label struct {
+	ID         int64     `db:"id"`
+	HospitalID int64     `db:"hospital_id"`
+	Name       string    `db:"name"`
+	Color      string    `db:"color"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// TaskLabel tags the task TaskID with the label LabelID.
+type TaskLabel struct {
+	TaskID    int64     `db:"task_id"`
+	LabelID   int64     `db:"label_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskRecurrence creates a task from its template at each occurrence of its
+// cron schedule, which is in the time zone of the hospital.
+type TaskRecurrence struct {
+	ID          int64  `db:"id"`
+	HospitalID  int64  `db:"hospital_id"`
+	Title       string `db:"title"`
+	Description string `db:"description"`
+	Priority    string `db:"priority"`
+	// DueIn is the number of seconds from an occurrence to the due date of
+	// its task, 0 for no due date.
+	DueIn    int64  `db:"due_in"`
+	Schedule string `db:"schedule"`
+	// NextAt is the next occurrence to create a task for.
+	NextAt time.Time `db:"next_at"`
+	// Occurrences is the number of tasks created so far, which picks the
+	// owner of the next one in the rotation.
+	Occurrences int64      `db:"occurrences"`
+	Version     int64      `db:"version"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+}
+
+// TaskRecurrenceOwner puts the employee EmployeeID at the rank Position of
+// the owner rotation of the recurrence RecurrenceID.
+type TaskRecurrenceOwner struct {
+	RecurrenceID int64 `db:"recurrence_id"`
+	Position     int   `db:"position"`
+	EmployeeID   int64 `db:"employee_id"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Shift puts the employee EmployeeID on duty in the ward Ward from
+// StartsAt, included, to EndsAt, excluded.
+type Shift struct {
+	ID         int64      `db:"id"`
+	HospitalID int64      `db:"hospital_id"`
+	EmployeeID int64      `db:"employee_id"`
+	StartsAt   time.Time  `db:"starts_at"`
+	EndsAt     time.Time  `db:"ends_at"`
+	Ward       string     `db:"ward"`
+	Version    int64      `db:"version"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+	DeletedAt  *time.Time `db:"deleted_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// The escalations of the tasks breaching their SLA.
+const (
+	EscalationNone         = "none"
+	EscalationBumpPriority = "bumpPriority"
+	EscalationReassign     = "reassign"
+)
+
+// TaskSLA is the time the tasks of one priority of a hospital may stay
+// OPEN, and the escalation of the ones which stay longer.
+type TaskSLA struct {
+	ID         int64  `db:"id"`
+	HospitalID int64  `db:"hospital_id"`
+	Priority   string `db:"priority"`
+	// RespondWithin is the number of seconds a task may stay OPEN since it
+	// was last moved or escalated.
+	RespondWithin int64     `db:"respond_within"`
+	Escalation    string    `db:"escalation"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// TaskSLAChain puts the employee EmployeeID at the rank Position of the
+// escalation chain of the SLA SLAID.
+type TaskSLAChain struct {
+	SLAID      int64 `db:"sla_id"`
+	Position   int   `db:"position"`
+	EmployeeID int64 `db:"employee_id"`
+}
+
+// TaskEscalation records a task escalated for breaching its SLA. The values
+// are the priorities or the owners before and after, depending on the
+// action, and are empty if the action is EscalationNone.
+type TaskEscalation struct {
+	ID        int64     `db:"id"`
+	TaskID    int64     `db:"task_id"`
+	Level     int64     `db:"level"`
+	Action    string    `db:"action"`
+	OldValue  string    `db:"old_value"`
+	NewValue  string    `db:"new_value"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -9,19 +9,144 @@ const (
 	TaskPriorityHight  = "HIGHT"
 	TaskPriorityLow    = "LOW"
 
-	TaskStatusOpen      = "OPEN"
-	TaskStatusFAILED    = "FAILED"
-	TaskStatusCOMPLETED = "COMPLETED"
+	TaskStatusOpen       = "OPEN"
+	TaskStatusInProgress = "IN_PROGRESS"
+	TaskStatusBlocked    = "BLOCKED"
+	TaskStatusFAILED     = "FAILED"
+	TaskStatusCOMPLETED  = "COMPLETED"
+	TaskStatusCancelled  = "CANCELLED"
 )
 
+// TaskStatuses are all the statuses of a task.
+var TaskStatuses = []string{
+	TaskStatusOpen, TaskStatusInProgress, TaskStatusBlocked,
+	TaskStatusFAILED, TaskStatusCOMPLETED, TaskStatusCancelled,
+}
+
+// taskTransitions are the statuses a task can move to from each status. The
+// empty status is the one before the task is created, so every task starts
+// OPEN.
+var taskTransitions = map[string][]string{
+	"":                   {TaskStatusOpen},
+	TaskStatusOpen:       {TaskStatusInProgress, TaskStatusBlocked, TaskStatusCancelled},
+	TaskStatusInProgress: {TaskStatusBlocked, TaskStatusCOMPLETED, TaskStatusFAILED, TaskStatusCancelled},
+	TaskStatusBlocked:    {TaskStatusInProgress, TaskStatusFAILED, TaskStatusCancelled},
+	TaskStatusCOMPLETED:  {TaskStatusOpen},
+	TaskStatusFAILED:     {TaskStatusOpen},
+	TaskStatusCancelled:  {TaskStatusOpen},
+}
+
+// CanTransitionTask reports whether a task can move from the status from to
+// the status to.
+func CanTransitionTask(from, to string) bool {
+	for _, s := range taskTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskPriorityRank ranks the priorities, the most urgent first when sorted
+// in descending order.
+func TaskPriorityRank(priority string) int64 {
+	switch priority {
+	case TaskPriorityUrgent:
+		return 3
+	case TaskPriorityHight:
+		return 2
+	}
+	return 1
+}
+
+// TaskClosedStatuses are the statuses of the tasks which need no more work.
+var TaskClosedStatuses = []string{TaskStatusCOMPLETED, TaskStatusFAILED, TaskStatusCancelled}
+
+// IsTaskClosed reports whether status is one of TaskClosedStatuses.
+func IsTaskClosed(status string) bool {
+	for _, s := range TaskClosedStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 type Task struct {
-	ID          int64     `db:"id"`
-	HospitalID  int64     `db:"hospital_id"`
-	OwnerID     int64     `db:"owner_id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	Priority    string    `db:"priority"`
-	Status      string    `db:"status"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID         int64 `db:"id"`
+	HospitalID int64 `db:"hospital_id"`
+	// OwnerID is nil while the task is in the pool of its hospital, waiting
+	// for an employee to claim it.
+	OwnerID *int64 `db:"owner_id"`
+	// ParentID is the task this one is a subtask of, if any.
+	ParentID *int64 `db:"parent_id"`
+	// RecurrenceID and OccurrenceAt are the recurrence which created the
+	// task and the occurrence it was created for, if any.
+	RecurrenceID *int64     `db:"recurrence_id"`
+	OccurrenceAt *time.Time `db:"occurrence_at"`
+	Title        string     `db:"title"`
+	Description  string     `db:"description"`
+	Priority     string     `db:"priority"`
+	Status       string     `db:"status"`
+	DueAt        *time.Time `db:"due_at"`
+	// Overdue is set by the overdue sweeper while the task is open past its
+	// due date.
+	Overdue bool `db:"overdue"`
+	// Breached is set by the SLA evaluator while the task is OPEN past the
+	// SLA of its priority.
+	Breached  bool       `db:"breached"`
+	Version   int64      `db:"version"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// TaskDependency records that the task TaskID can't be completed until the
+// task BlockerID is closed.
+type TaskDependency struct {
+	TaskID    int64     `db:"task_id"`
+	BlockerID int64     `db:"blocker_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// OwnerTaskCount is the number of open tasks of one priority an employee
+// owns.
+type OwnerTaskCount struct {
+	OwnerID  int64  `db:"owner_id"`
+	Priority string `db:"priority"`
+	Count    uint   `db:"count"`
+}
+
+// TaskTransition records a task moving from one status to another. The
+// FromStatus of the transition made by the creation of the task is empty.
+type TaskTransition struct {
+	ID         int64     `db:"id"`
+	TaskID     int64     `db:"task_id"`
+	FromStatus string    `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// The fields of a task recorded by its TaskChanges.
+const (
+	TaskFieldOwnerID     = "ownerId"
+	TaskFieldTitle       = "title"
+	TaskFieldDescription = "description"
+	TaskFieldPriority    = "priority"
+	TaskFieldStatus      = "status"
+	TaskFieldDueAt       = "dueAt"
+	TaskFieldDeleted     = "deleted"
+)
+
+// TaskChange records the change of one field of a task. The values are
+// written as text, and the OldValue of the changes made by the creation of
+// the task is empty.
+type TaskChange struct {
+	ID        int64     `db:"id"`
+	TaskID    int64     `db:"task_id"`
+	Field     string    `db:"field"`
+	OldValue  string    `db:"old_value"`
+	NewValue  string    `db:"new_value"`
+	ActorID   *int64    `db:"actor_id"`
+	CreatedAt time.Time `db:"created_at"`
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskTemplate is the blueprint of a task typed often in a hospital. The
+// tasks created from it get its labels, and a subtask for each item of its
+// checklist.
+type TaskTemplate struct {
+	ID          int64      `db:"id"`
+	HospitalID  int64      `db:"hospital_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	Priority    string     `db:"priority"`
+	Version     int64      `db:"version"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+}
+
+// TaskTemplateLabel gives the label LabelID to the tasks created from the
+// template TemplateID.
+type TaskTemplateLabel struct {
+	TemplateID int64 `db:"template_id"`
+	LabelID    int64 `db:"label_id"`
+}
+
+// TaskTemplateItem puts the item Title at the rank Position of the
+// checklist of the template TemplateID.
+type TaskTemplateItem struct {
+	TemplateID int64  `db:"template_id"`
+	Position   int    `db:"position"`
+	Title      string `db:"title"`
+}
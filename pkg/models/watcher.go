@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskWatcher records that the employee EmployeeID follows the task TaskID.
+type TaskWatcher struct {
+	TaskID     int64     `db:"task_id"`
+	EmployeeID int64     `db:"employee_id"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// TaskEvent is a change of a task sent to one of its owners or watchers.
+// The fields after ChangeID are the ones of the change.
+type TaskEvent struct {
+	ID         int64     `db:"id"`
+	EmployeeID int64     `db:"employee_id"`
+	ChangeID   int64     `db:"change_id"`
+	TaskID     int64     `db:"task_id"`
+	Field      string    `db:"field"`
+	OldValue   string    `db:"old_value"`
+	NewValue   string    `db:"new_value"`
+	ActorID    *int64    `db:"actor_id"`
+	CreatedAt  time.Time `db:"created_at"`
+}
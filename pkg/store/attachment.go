@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+const attachmentColumns = "id, task_id, uploader_id, filename, content_type, size, blob_key, created_at, updated_at, deleted_at"
+
+func (s *SQLStore) GetAttachment(ctx context.Context, id int64) (*models.Attachment, error) {
+	var a models.Attachment
+	sql := "select " + attachmentColumns + " from task_attachment where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &a, sql, id)
+	return &a, err
+}
+
+func (s *SQLStore) CreateAttachment(ctx context.Context, a *dto.Attachment) (*models.Attachment, error) {
+	attachment := &models.Attachment{
+		TaskID:      a.TaskID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		BlobKey:     a.BlobKey,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if a.UploaderID != 0 {
+		attachment.UploaderID = &a.UploaderID
+	}
+	sql := "insert into task_attachment (task_id, uploader_id, filename, content_type, size, blob_key, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, attachment.TaskID, attachment.UploaderID, attachment.Filename, attachment.ContentType,
+		attachment.Size, attachment.BlobKey, attachment.CreatedAt, attachment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	attachment.ID = id
+	return attachment, nil
+}
+
+func (s *SQLStore) DeleteAttachment(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "task_attachment", "id = ?", id)
+}
+
+func (s *SQLStore) FindAttachments(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.Attachment, error) {
+	var attachments []*models.Attachment
+	cond, args := page(opts)
+	sql := "select " + attachmentColumns + " from task_attachment where task_id = ?" + notDeleted(opts) + cond
+	if err := s.selectContext(ctx, &attachments, sql, append([]any{taskID}, args...)...); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (s *SQLStore) CountAttachments(ctx context.Context, taskID int64, opts dto.ListOptions) (uint, error) {
+	var count uint
+	sql := "select count(1) from task_attachment where task_id = ?" + notDeleted(opts)
+	if err := s.getContext(ctx, &count, sql, taskID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+func TestAttachment(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "attachment_hospital"})
+	assert.NoError(t, err)
+	uploader, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "uploader"})
+	assert.NoError(t, err)
+	task, err := store.CreateTask(ctx, &dto.Task{
+		HospitalID: hospital.ID,
+		OwnerID:    uploader.ID,
+		Title:      "task with attachments",
+		Priority:   "LOW",
+		Status:     "OPEN",
+	})
+	assert.NoError(t, err)
+
+	t.Run("CreateAttachment", func(t *testing.T) {
+		a, err := store.CreateAttachment(ctx, &dto.Attachment{
+			TaskID:      task.ID,
+			UploaderID:  uploader.ID,
+			Filename:    "wound.png",
+			ContentType: "image/png",
+			Size:        42,
+			BlobKey:     "tasks/1/a",
+		})
+		assert.NoError(t, err)
+		assert.Greater(t, a.ID, int64(0))
+
+		got, err := store.GetAttachment(ctx, a.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "wound.png", got.Filename)
+		assert.Equal(t, int64(42), got.Size)
+		assert.Equal(t, "tasks/1/a", got.BlobKey)
+		if assert.NotNil(t, got.UploaderID) {
+			assert.Equal(t, uploader.ID, *got.UploaderID)
+		}
+
+		// The uploader is optional.
+		anonymous, err := store.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID, Filename: "b.pdf", ContentType: "application/pdf", BlobKey: "tasks/1/b"})
+		assert.NoError(t, err)
+		got, err = store.GetAttachment(ctx, anonymous.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, got.UploaderID)
+
+		_, err = store.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID, Filename: "c.png", BlobKey: "tasks/1/a"})
+		assert.True(t, IsErrDuplicateEntry(err))
+		_, err = store.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID + 100, Filename: "c.png", BlobKey: "tasks/1/c"})
+		assert.True(t, IsErrForeignKeyViolation(err))
+		_, err = store.CreateAttachment(ctx, &dto.Attachment{TaskID: task.ID, UploaderID: uploader.ID + 100, Filename: "c.png", BlobKey: "tasks/1/c"})
+		assert.True(t, IsErrForeignKeyViolation(err))
+	})
+
+	t.Run("FindAttachments", func(t *testing.T) {
+		attachments, err := store.FindAttachments(ctx, task.ID, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		if !assert.Len(t, attachments, 2) {
+			return
+		}
+		assert.Less(t, attachments[0].ID, attachments[1].ID)
+		rest, err := store.FindAttachments(ctx, task.ID, dto.ListOptions{Limit: 10, AfterID: attachments[0].ID})
+		assert.NoError(t, err)
+		assert.Len(t, rest, 1)
+
+		n, err := store.DeleteAttachment(ctx, attachments[1].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.GetAttachment(ctx, attachments[1].ID)
+		assert.True(t, IsErrNotFound(err))
+
+		total, err := store.CountAttachments(ctx, task.ID, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), total)
+		total, err = store.CountAttachments(ctx, task.ID, dto.ListOptions{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+	})
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+const commentColumns = "id, task_id, author_id, body, created_at, updated_at, deleted_at"
+
+func (s *SQLStore) GetComment(ctx context.Context, id int64) (*models.Comment, error) {
+	var c models.Comment
+	sql := "select " + commentColumns + " from task_comment where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &c, sql, id)
+	return &c, err
+}
+
+func (s *SQLStore) CreateComment(ctx context.Context, c *dto.Comment) (*models.Comment, error) {
+	comment := &models.Comment{
+		TaskID:    c.TaskID,
+		AuthorID:  c.AuthorID,
+		Body:      c.Body,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	sql := "insert into task_comment (task_id, author_id, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, comment.TaskID, comment.AuthorID, comment.Body, comment.CreatedAt, comment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	comment.ID = id
+	return comment, nil
+}
+
+func (s *SQLStore) UpdateComment(ctx context.Context, c *dto.Comment) (int64, error) {
+	sql := "update task_comment set body=?, updated_at=? where id = ? and deleted_at is null"
+	r, err := s.execContext(ctx, sql, c.Body, time.Now().UTC(), c.ID)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) DeleteComment(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "task_comment", "id = ?", id)
+}
+
+func (s *SQLStore) FindComments(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.Comment, error) {
+	var comments []*models.Comment
+	cond, args := page(opts)
+	sql := "select " + commentColumns + " from task_comment where task_id = ?" + notDeleted(opts) + cond
+	if err := s.selectContext(ctx, &comments, sql, append([]any{taskID}, args...)...); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (s *SQLStore) CountComments(ctx context.Context, taskID int64, opts dto.ListOptions) (uint, error) {
+	var count uint
+	sql := "select count(1) from task_comment where task_id = ?" + notDeleted(opts)
+	if err := s.getContext(ctx, &count, sql, taskID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestComment(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "comment_hospital"})
+	assert.NoError(t, err)
+	author, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "author"})
+	assert.NoError(t, err)
+	task, err := store.CreateTask(ctx, &dto.Task{
+		HospitalID: hospital.ID,
+		OwnerID:    author.ID,
+		Title:      "commented task",
+		Priority:   "LOW",
+		Status:     "OPEN",
+	})
+	assert.NoError(t, err)
+
+	var comment *models.Comment
+
+	t.Run("CreateComment", func(t *testing.T) {
+		comment, err = store.CreateComment(ctx, &dto.Comment{TaskID: task.ID, AuthorID: author.ID, Body: "handover"})
+		assert.NoError(t, err)
+		assert.Greater(t, comment.ID, int64(0))
+
+		_, err = store.CreateComment(ctx, &dto.Comment{TaskID: task.ID + 100, AuthorID: author.ID, Body: "x"})
+		assert.True(t, IsErrForeignKeyViolation(err))
+		_, err = store.CreateComment(ctx, &dto.Comment{TaskID: task.ID, AuthorID: author.ID + 100, Body: "x"})
+		assert.True(t, IsErrForeignKeyViolation(err))
+	})
+
+	t.Run("UpdateComment", func(t *testing.T) {
+		n, err := store.UpdateComment(ctx, &dto.Comment{ID: comment.ID, Body: "handover, updated"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		c, err := store.GetComment(ctx, comment.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "handover, updated", c.Body)
+		assert.Equal(t, author.ID, c.AuthorID)
+	})
+
+	t.Run("FindComments", func(t *testing.T) {
+		second, err := store.CreateComment(ctx, &dto.Comment{TaskID: task.ID, AuthorID: author.ID, Body: "second"})
+		assert.NoError(t, err)
+
+		comments, err := store.FindComments(ctx, task.ID, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, comments, 2) {
+			assert.Equal(t, comment.ID, comments[0].ID)
+			assert.Equal(t, second.ID, comments[1].ID)
+		}
+		comments, err = store.FindComments(ctx, task.ID, dto.ListOptions{Limit: 10, AfterID: comment.ID})
+		assert.NoError(t, err)
+		assert.Len(t, comments, 1)
+
+		n, err := store.DeleteComment(ctx, second.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.GetComment(ctx, second.ID)
+		assert.True(t, IsErrNotFound(err))
+
+		total, err := store.CountComments(ctx, task.ID, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), total)
+		total, err = store.CountComments(ctx, task.ID, dto.ListOptions{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+	})
+}
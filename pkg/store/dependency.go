@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+// SetTaskParent makes the task id a subtask of the task parentID, or a top
+// level task if parentID is 0, and bumps its version.
+func (s *SQLStore) SetTaskParent(ctx context.Context, id, parentID int64) (int64, error) {
+	sql := "update task set parent_id=?, version=version+1, updated_at=? where id = ? and deleted_at is null"
+	r, err := s.execContext(ctx, sql, nullID(parentID), time.Now().UTC(), id)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) GetTaskParentID(ctx context.Context, id int64) (int64, error) {
+	var parentID *int64
+	if err := s.getContext(ctx, &parentID, "select parent_id from task where id = ?", id); err != nil {
+		return 0, err
+	}
+	if parentID == nil {
+		return 0, nil
+	}
+	return *parentID, nil
+}
+
+func (s *SQLStore) FindSubtasks(ctx context.Context, parentID int64) ([]*models.Task, error) {
+	var tasks []*models.Task
+	sql := "select " + taskColumns + " from task where parent_id = ? and deleted_at is null order by id"
+	if err := s.selectContext(ctx, &tasks, sql, parentID); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *SQLStore) CountSubtasks(ctx context.Context, parentID int64) (uint, uint, error) {
+	var counts struct {
+		Total uint  `db:"total"`
+		Done  *uint `db:"done"`
+	}
+	sql := "select count(1) as total, sum(case when status = ? then 1 else 0 end) as done from task where parent_id = ? and deleted_at is null"
+	if err := s.getContext(ctx, &counts, sql, models.TaskStatusCOMPLETED, parentID); err != nil {
+		return 0, 0, err
+	}
+	if counts.Done == nil {
+		return counts.Total, 0, nil
+	}
+	return counts.Total, *counts.Done, nil
+}
+
+func (s *SQLStore) CreateTaskDependency(ctx context.Context, taskID, blockerID int64) error {
+	sql := "insert into task_dependency (task_id, blocker_id, created_at) VALUES (?, ?, ?)"
+	_, err := s.execContext(ctx, sql, taskID, blockerID, time.Now().UTC())
+	return err
+}
+
+func (s *SQLStore) DeleteTaskDependency(ctx context.Context, taskID, blockerID int64) (int64, error) {
+	r, err := s.execContext(ctx, "delete from task_dependency where task_id = ? and blocker_id = ?", taskID, blockerID)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) FindTaskBlockerIDs(ctx context.Context, taskID int64) ([]int64, error) {
+	var ids []int64
+	if err := s.selectContext(ctx, &ids, "select blocker_id from task_dependency where task_id = ? order by blocker_id", taskID); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SQLStore) FindTaskBlockers(ctx context.Context, taskID int64) ([]*models.Task, error) {
+	var tasks []*models.Task
+	sql := "select " + taskColumns + " from task where id in (select blocker_id from task_dependency where task_id = ?) and deleted_at is null order by id"
+	if err := s.selectContext(ctx, &tasks, sql, taskID); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (s *SQLStore) CountOpenBlockers(ctx context.Context, taskID int64) (uint, error) {
+	var count uint
+	marks, closed := inArgs(models.TaskClosedStatuses)
+	sql := "select count(1) from task where id in (select blocker_id from task_dependency where task_id = ?) and deleted_at is null and status not in (" + marks + ")"
+	if err := s.getContext(ctx, &count, sql, append([]any{taskID}, closed...)...); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
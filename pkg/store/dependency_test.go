@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestTaskDependency(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "dependency_hospital"})
+	assert.NoError(t, err)
+	employee, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "dependent"})
+	assert.NoError(t, err)
+
+	newTask := func(title string, parentID int64) *models.Task {
+		task, err := store.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    employee.ID,
+			ParentID:   parentID,
+			Title:      title,
+			Priority:   models.TaskPriorityLow,
+			Status:     models.TaskStatusOpen,
+		})
+		assert.NoError(t, err)
+		return task
+	}
+
+	parent := newTask("parent", 0)
+	child := newTask("child", parent.ID)
+	other := newTask("other", 0)
+
+	t.Run("Subtasks", func(t *testing.T) {
+		assert.Nil(t, parent.ParentID)
+		if assert.NotNil(t, child.ParentID) {
+			assert.Equal(t, parent.ID, *child.ParentID)
+		}
+		_, err := store.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    employee.ID,
+			ParentID:   other.ID + 100,
+			Title:      "orphan",
+			Priority:   models.TaskPriorityLow,
+			Status:     models.TaskStatusOpen,
+		})
+		assert.True(t, IsErrForeignKeyViolation(err))
+
+		r, err := store.SetTaskParent(ctx, other.ID, parent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r)
+		task, err := store.GetTask(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, other.Version+1, task.Version)
+		id, err := store.GetTaskParentID(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, parent.ID, id)
+
+		tasks, err := store.FindSubtasks(ctx, parent.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, tasks, 2) {
+			assert.Equal(t, child.ID, tasks[0].ID)
+			assert.Equal(t, other.ID, tasks[1].ID)
+		}
+
+		total, done, err := store.CountSubtasks(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), total)
+		assert.Equal(t, uint(0), done)
+
+		_, err = store.UpdateTask(ctx, &dto.Task{
+			ID:       other.ID,
+			OwnerID:  employee.ID,
+			Title:    other.Title,
+			Priority: other.Priority,
+			Status:   models.TaskStatusCOMPLETED,
+		})
+		assert.NoError(t, err)
+		total, done, err = store.CountSubtasks(ctx, parent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+		assert.Equal(t, uint(1), done)
+
+		_, err = store.SetTaskParent(ctx, other.ID, 0)
+		assert.NoError(t, err)
+		id, err = store.GetTaskParentID(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), id)
+		_, err = store.GetTaskParentID(ctx, other.ID+100)
+		assert.True(t, IsErrNotFound(err))
+	})
+
+	t.Run("Blockers", func(t *testing.T) {
+		assert.NoError(t, store.CreateTaskDependency(ctx, parent.ID, child.ID))
+		assert.NoError(t, store.CreateTaskDependency(ctx, parent.ID, other.ID))
+		err := store.CreateTaskDependency(ctx, parent.ID, child.ID)
+		assert.True(t, IsErrDuplicateEntry(err))
+		err = store.CreateTaskDependency(ctx, parent.ID, other.ID+100)
+		assert.True(t, IsErrForeignKeyViolation(err))
+
+		ids, err := store.FindTaskBlockerIDs(ctx, parent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{child.ID, other.ID}, ids)
+		tasks, err := store.FindTaskBlockers(ctx, parent.ID)
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 2)
+
+		// other was completed above.
+		n, err := store.CountOpenBlockers(ctx, parent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), n)
+
+		_, err = store.DeleteTask(ctx, child.ID)
+		assert.NoError(t, err)
+		n, err = store.CountOpenBlockers(ctx, parent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), n)
+		tasks, err = store.FindTaskBlockers(ctx, parent.ID)
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 1)
+
+		r, err := store.DeleteTaskDependency(ctx, parent.ID, child.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r)
+		r, err = store.DeleteTaskDependency(ctx, parent.ID, child.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), r)
+		ids, err = store.FindTaskBlockerIDs(ctx, parent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{other.ID}, ids)
+	})
+}
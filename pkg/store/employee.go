@@ -8,10 +8,19 @@ import (
 	"github.com/liuerfire/boxpractice/pkg/models"
 )
 
+const employeeColumns = "id, hospital_id, username, first_name, last_name, created_at, updated_at, deleted_at"
+
 func (s *SQLStore) GetEmployee(ctx context.Context, id int64) (*models.Employee, error) {
 	var e models.Employee
-	sql := "select id, hospital_id, username, first_name, last_name, created_at, updated_at from employee where id = ?"
-	err := s.db.GetContext(ctx, &e, sql, id)
+	sql := "select " + employeeColumns + " from employee where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &e, sql, id)
+	return &e, err
+}
+
+func (s *SQLStore) GetDeletedEmployee(ctx context.Context, id int64) (*models.Employee, error) {
+	var e models.Employee
+	sql := "select " + employeeColumns + " from employee where id = ? and deleted_at is not null" + s.forUpdate()
+	err := s.getContext(ctx, &e, sql, id)
 	return &e, err
 }
 
@@ -25,7 +34,7 @@ func (s *SQLStore) CreateEmployee(ctx context.Context, e *dto.Employee) (*models
 		UpdatedAt:  time.Now().UTC(),
 	}
 	sql := "insert into employee (hospital_id, username, first_name, last_name, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
-	r, err := s.db.ExecContext(ctx,
+	id, err := s.insert(ctx,
 		sql, employee.HospitalID, employee.Username,
 		employee.FirstName, employee.LastName,
 		employee.CreatedAt, employee.UpdatedAt,
@@ -33,27 +42,36 @@ func (s *SQLStore) CreateEmployee(ctx context.Context, e *dto.Employee) (*models
 	if err != nil {
 		return nil, err
 	}
-	id, err := r.LastInsertId()
-	if err != nil {
-		return nil, err
-	}
 	employee.ID = id
 	return employee, nil
 }
 
-func (s *SQLStore) FindEmployees(ctx context.Context, hid int64, offset, limit uint) ([]*models.Employee, error) {
+func (s *SQLStore) DeleteEmployee(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "employee", "id = ?", id)
+}
+
+func (s *SQLStore) RestoreEmployee(ctx context.Context, id int64) (int64, error) {
+	return s.restore(ctx, "employee", id)
+}
+
+func (s *SQLStore) DeleteEmployeesByHospital(ctx context.Context, hid int64, at time.Time) (int64, error) {
+	return s.softDeleteAt(ctx, at, "employee", "hospital_id = ?", hid)
+}
+
+func (s *SQLStore) FindEmployees(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.Employee, error) {
 	var employees []*models.Employee
-	sql := "select id, username, first_name, last_name, created_at, updated_at from employee where hospital_id = ? order by id limit ?, ?"
-	if err := s.db.Select(&employees, sql, hid, offset, limit); err != nil {
+	cond, args := page(opts)
+	sql := "select " + employeeColumns + " from employee where hospital_id = ?" + notDeleted(opts) + cond
+	if err := s.selectContext(ctx, &employees, sql, append([]any{hid}, args...)...); err != nil {
 		return nil, err
 	}
 	return employees, nil
 }
 
-func (s *SQLStore) CountEmployees(ctx context.Context, hid int64) (uint, error) {
+func (s *SQLStore) CountEmployees(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error) {
 	var count uint
-	sql := "select count(1) from employee where hospital_id = ?"
-	if err := s.db.Get(&count, sql, hid); err != nil {
+	sql := "select count(1) from employee where hospital_id = ?" + notDeleted(opts)
+	if err := s.getContext(ctx, &count, sql, hid); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -67,7 +67,7 @@ func TestEmployee(t *testing.T) {
 	})
 
 	t.Run("FindEmployees", func(t *testing.T) {
-		employees, err := store.FindEmployees(ctx, hospital.ID, 0, 10)
+		employees, err := store.FindEmployees(ctx, hospital.ID, dto.ListOptions{Offset: 0, Limit: 10})
 		assert.NoError(t, err)
 
 		assert.Equal(t, 2, len(employees))
@@ -84,11 +84,11 @@ func TestEmployee(t *testing.T) {
 	})
 
 	t.Run("ListEmployeesWithLimit", func(t *testing.T) {
-		total, err := store.CountEmployees(ctx, hospital.ID)
+		total, err := store.CountEmployees(ctx, hospital.ID, dto.ListOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint(2), total)
 
-		employees, err := store.FindEmployees(ctx, hospital.ID, 0, 1)
+		employees, err := store.FindEmployees(ctx, hospital.ID, dto.ListOptions{Offset: 0, Limit: 1})
 		assert.NoError(t, err)
 
 		assert.Equal(t, employee.ID, employees[0].ID)
@@ -96,7 +96,7 @@ func TestEmployee(t *testing.T) {
 		assert.Equal(t, employee.FirstName, employees[0].FirstName)
 		assert.Equal(t, employee.LastName, employees[0].LastName)
 
-		employees, err = store.FindEmployees(ctx, hospital.ID, 1, 1)
+		employees, err = store.FindEmployees(ctx, hospital.ID, dto.ListOptions{Offset: 1, Limit: 1})
 		assert.NoError(t, err)
 
 		assert.Equal(t, employeeOther.ID, employees[0].ID)
@@ -107,8 +107,17 @@ func TestEmployee(t *testing.T) {
 
 	t.Run("CreateEmployeeIfExist", func(t *testing.T) {
 		_, err = store.CreateEmployee(ctx, &dto.Employee{
-			Username: employee.Username,
+			HospitalID: hospital.ID,
+			Username:   employee.Username,
 		})
 		assert.True(t, IsErrDuplicateEntry(err))
 	})
+
+	t.Run("CreateEmployeeWithoutHospital", func(t *testing.T) {
+		_, err = store.CreateEmployee(ctx, &dto.Employee{
+			HospitalID: hospital.ID + 100,
+			Username:   "nobody",
+		})
+		assert.True(t, IsErrForeignKeyViolation(err))
+	})
 }
@@ -8,10 +8,19 @@ import (
 	"github.com/liuerfire/boxpractice/pkg/models"
 )
 
+const hospitalColumns = "id, name, display_name, timezone, assignment_strategy, auto_assign, last_assignee_id, off_shift_assignment, version, created_at, updated_at, deleted_at"
+
 func (s *SQLStore) GetHospital(ctx context.Context, id int64) (*models.Hospital, error) {
 	var hospital models.Hospital
-	sql := "select id, name, display_name, created_at, updated_at from hospital where id = ?"
-	err := s.db.GetContext(ctx, &hospital, sql, id)
+	sql := "select " + hospitalColumns + " from hospital where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &hospital, sql, id)
+	return &hospital, err
+}
+
+func (s *SQLStore) GetDeletedHospital(ctx context.Context, id int64) (*models.Hospital, error) {
+	var hospital models.Hospital
+	sql := "select " + hospitalColumns + " from hospital where id = ? and deleted_at is not null" + s.forUpdate()
+	err := s.getContext(ctx, &hospital, sql, id)
 	return &hospital, err
 }
 
@@ -19,15 +28,18 @@ func (s *SQLStore) CreateHospital(ctx context.Context, h *dto.Hospital) (*models
 	hs := &models.Hospital{
 		Name:        h.Name,
 		DisplayName: h.DisplayName,
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
+		Timezone:    h.Timezone,
+		// The strategy is set by the service, the column default is only
+		// there for the rows predating it.
+		AssignmentStrategy: h.AssignmentStrategy,
+		AutoAssign:         h.AutoAssign,
+		OffShiftAssignment: h.OffShiftAssignment,
+		Version:            1,
+		CreatedAt:          time.Now().UTC(),
+		UpdatedAt:          time.Now().UTC(),
 	}
-	sql := "insert into hospital (name, display_name, created_at, updated_at) VALUES (?, ?, ?, ?)"
-	r, err := s.db.ExecContext(ctx, sql, hs.Name, hs.DisplayName, hs.CreatedAt, hs.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	id, err := r.LastInsertId()
+	sql := "insert into hospital (name, display_name, timezone, assignment_strategy, auto_assign, off_shift_assignment, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, hs.Name, hs.DisplayName, hs.Timezone, hs.AssignmentStrategy, hs.AutoAssign, hs.OffShiftAssignment, hs.Version, hs.CreatedAt, hs.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -35,28 +47,55 @@ func (s *SQLStore) CreateHospital(ctx context.Context, h *dto.Hospital) (*models
 	return hs, nil
 }
 
+// UpdateHospital updates the hospital and bumps its version. If h.Version
+// is set, the hospital is only updated if it is still at that version.
 func (s *SQLStore) UpdateHospital(ctx context.Context, h *dto.Hospital) (int64, error) {
-	sql := "update hospital set name=?, display_name=? where id = ?"
-	r, err := s.db.ExecContext(ctx, sql, h.Name, h.DisplayName, h.ID)
+	sql := "update hospital set name=?, display_name=?, timezone=?, assignment_strategy=?, auto_assign=?, off_shift_assignment=?, version=version+1, updated_at=? where id = ? and deleted_at is null"
+	args := []any{h.Name, h.DisplayName, h.Timezone, h.AssignmentStrategy, h.AutoAssign, h.OffShiftAssignment, time.Now().UTC(), h.ID}
+	if h.Version > 0 {
+		sql += " and version = ?"
+		args = append(args, h.Version)
+	}
+	r, err := s.execContext(ctx, sql, args...)
 	if err != nil {
 		return 0, err
 	}
 	return r.RowsAffected()
 }
 
-func (s *SQLStore) FindHospitals(ctx context.Context, offset, limit uint) ([]*models.Hospital, error) {
+// SetLastAssignee records the employee eid as the last one picked by the
+// round-robin strategy of the hospital hid. It doesn't bump the version of
+// the hospital, the assignments aren't edits of it.
+func (s *SQLStore) SetLastAssignee(ctx context.Context, hid, eid int64) (int64, error) {
+	r, err := s.execContext(ctx, "update hospital set last_assignee_id = ? where id = ? and deleted_at is null", eid, hid)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) DeleteHospital(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "hospital", "id = ?", id)
+}
+
+func (s *SQLStore) RestoreHospital(ctx context.Context, id int64) (int64, error) {
+	return s.restore(ctx, "hospital", id)
+}
+
+func (s *SQLStore) FindHospitals(ctx context.Context, opts dto.ListOptions) ([]*models.Hospital, error) {
 	var hospitals []*models.Hospital
-	sql := "select id, name, display_name, created_at, updated_at from hospital order by id limit ?, ?"
-	if err := s.db.Select(&hospitals, sql, offset, limit); err != nil {
+	cond, args := page(opts)
+	sql := "select " + hospitalColumns + " from hospital where 1 = 1" + notDeleted(opts) + cond
+	if err := s.selectContext(ctx, &hospitals, sql, args...); err != nil {
 		return nil, err
 	}
 	return hospitals, nil
 }
 
-func (s *SQLStore) CountHosptials(ctx context.Context) (uint, error) {
+func (s *SQLStore) CountHosptials(ctx context.Context, opts dto.ListOptions) (uint, error) {
 	var count uint
-	sql := "select count(1) from hospital"
-	if err := s.db.Get(&count, sql); err != nil {
+	sql := "select count(1) from hospital where 1 = 1" + notDeleted(opts)
+	if err := s.getContext(ctx, &count, sql); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -50,7 +50,7 @@ func TestHospital(t *testing.T) {
 	})
 
 	t.Run("FindHospitals", func(t *testing.T) {
-		hospitals, err := store.FindHospitals(ctx, 0, 10)
+		hospitals, err := store.FindHospitals(ctx, dto.ListOptions{Offset: 0, Limit: 10})
 		assert.NoError(t, err)
 
 		assert.Equal(t, 2, len(hospitals))
@@ -66,11 +66,11 @@ func TestHospital(t *testing.T) {
 	})
 
 	t.Run("FindHospitalsWithLimit", func(t *testing.T) {
-		total, err := store.CountHosptials(ctx)
+		total, err := store.CountHosptials(ctx, dto.ListOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint(2), total)
 
-		hospitals, err := store.FindHospitals(ctx, 0, 1)
+		hospitals, err := store.FindHospitals(ctx, dto.ListOptions{Offset: 0, Limit: 1})
 		assert.NoError(t, err)
 
 		assert.Equal(t, 1, len(hospitals))
@@ -79,7 +79,7 @@ func TestHospital(t *testing.T) {
 		assert.Equal(t, hospital.DisplayName, hospitals[0].DisplayName)
 		assert.Equal(t, hospital.ID, hospitals[0].ID)
 
-		hospitals, err = store.FindHospitals(ctx, 1, 1)
+		hospitals, err = store.FindHospitals(ctx, dto.ListOptions{Offset: 1, Limit: 1})
 		assert.NoError(t, err)
 
 		assert.Equal(t, hospitalOther.ID, hospitals[0].ID)
@@ -88,6 +88,18 @@ func TestHospital(t *testing.T) {
 		assert.Equal(t, hospitalOther.ID, hospitals[0].ID)
 	})
 
+	t.Run("FindHospitalsAfterID", func(t *testing.T) {
+		hospitals, err := store.FindHospitals(ctx, dto.ListOptions{Limit: 10, AfterID: hospital.ID})
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, len(hospitals))
+		assert.Equal(t, hospitalOther.ID, hospitals[0].ID)
+
+		hospitals, err = store.FindHospitals(ctx, dto.ListOptions{Limit: 10, AfterID: hospitalOther.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(hospitals))
+	})
+
 	t.Run("CreateHospitalIfExist", func(t *testing.T) {
 		_, err = store.CreateHospital(ctx, &dto.Hospital{
 			Name:        "foo",
@@ -110,4 +122,126 @@ func TestHospital(t *testing.T) {
 		assert.Equal(t, "bar", h.Name)
 		assert.Equal(t, hospital.DisplayName, h.DisplayName)
 	})
+
+	t.Run("UpdateHospitalWithVersion", func(t *testing.T) {
+		h, err := store.GetHospital(ctx, hospitalOther.ID)
+		assert.NoError(t, err)
+
+		n, err := store.UpdateHospital(ctx, &dto.Hospital{
+			ID:      h.ID,
+			Name:    h.Name,
+			Version: h.Version,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		// h.Version is stale now.
+		n, err = store.UpdateHospital(ctx, &dto.Hospital{
+			ID:      h.ID,
+			Name:    h.Name,
+			Version: h.Version,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		hNew, err := store.GetHospital(ctx, h.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, h.Version+1, hNew.Version)
+	})
+	var employeeOther *models.Employee
+	t.Run("DeleteHospital", func(t *testing.T) {
+		_, err = store.GetDeletedHospital(ctx, hospitalOther.ID)
+		assert.True(t, IsErrNotFound(err))
+		employeeOther, err = store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospitalOther.ID, Username: "other"})
+		assert.NoError(t, err)
+
+		n, err := store.DeleteHospital(ctx, hospitalOther.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		// The employees deleted along share the deleted_at of the hospital.
+		deleted, err := store.GetDeletedHospital(ctx, hospitalOther.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, deleted.DeletedAt) {
+			n, err = store.DeleteEmployeesByHospital(ctx, hospitalOther.ID, *deleted.DeletedAt)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			e, err := store.GetDeletedEmployee(ctx, employeeOther.ID)
+			if assert.NoError(t, err) && assert.NotNil(t, e.DeletedAt) {
+				assert.True(t, deleted.DeletedAt.Equal(*e.DeletedAt))
+			}
+		}
+
+		n, err = store.DeleteHospital(ctx, hospitalOther.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		_, err = store.GetHospital(ctx, hospitalOther.ID)
+		assert.True(t, IsErrNotFound(err))
+
+		n, err = store.UpdateHospital(ctx, &dto.Hospital{ID: hospitalOther.ID, Name: hospitalOther.Name})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		total, err := store.CountHosptials(ctx, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), total)
+
+		hospitals, err := store.FindHospitals(ctx, dto.ListOptions{Limit: 10, IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(hospitals))
+		assert.Nil(t, hospitals[0].DeletedAt)
+		assert.NotNil(t, hospitals[1].DeletedAt)
+
+		total, err = store.CountHosptials(ctx, dto.ListOptions{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+	})
+
+	t.Run("RestoreHospital", func(t *testing.T) {
+		n, err := store.RestoreHospital(ctx, hospitalOther.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = store.RestoreHospital(ctx, hospitalOther.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		h, err := store.GetHospital(ctx, hospitalOther.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, h.DeletedAt)
+		_, err = store.GetDeletedHospital(ctx, hospitalOther.ID)
+		assert.True(t, IsErrNotFound(err))
+
+		n, err = store.RestoreEmployee(ctx, employeeOther.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.GetDeletedEmployee(ctx, employeeOther.ID)
+		assert.True(t, IsErrNotFound(err))
+	})
+
+	t.Run("Assignment", func(t *testing.T) {
+		h, err := store.GetHospital(ctx, hospital.ID)
+		assert.NoError(t, err)
+		n, err := store.UpdateHospital(ctx, &dto.Hospital{
+			ID:                 h.ID,
+			Name:               h.Name,
+			AssignmentStrategy: models.AssignmentLeastOpen,
+			AutoAssign:         true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		employee, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "assignee"})
+		assert.NoError(t, err)
+		n, err = store.SetLastAssignee(ctx, h.ID, employee.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		got, err := store.GetHospital(ctx, h.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.AssignmentLeastOpen, got.AssignmentStrategy)
+		assert.True(t, got.AutoAssign)
+		assert.Equal(t, &employee.ID, got.LastAssigneeID)
+		assert.Equal(t, h.Version+1, got.Version)
+	})
 }
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+const labelColumns = "id, hospital_id, name, color, created_at, updated_at"
+
+func (s *SQLStore) GetLabel(ctx context.Context, id int64) (*models.Label, error) {
+	var l models.Label
+	sql := "select " + labelColumns + " from label where id = ?" + s.forUpdate()
+	err := s.getContext(ctx, &l, sql, id)
+	return &l, err
+}
+
+func (s *SQLStore) CreateLabel(ctx context.Context, l *dto.Label) (*models.Label, error) {
+	label := &models.Label{
+		HospitalID: l.HospitalID,
+		Name:       l.Name,
+		Color:      l.Color,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	sql := "insert into label (hospital_id, name, color, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, label.HospitalID, label.Name, label.Color, label.CreatedAt, label.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	label.ID = id
+	return label, nil
+}
+
+func (s *SQLStore) UpdateLabel(ctx context.Context, l *dto.Label) (int64, error) {
+	sql := "update label set name=?, color=?, updated_at=? where id = ?"
+	r, err := s.execContext(ctx, sql, l.Name, l.Color, time.Now().UTC(), l.ID)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+// DeleteLabel deletes the task labels and the template labels of the label
+// first, so it has to run in a transaction.
+func (s *SQLStore) DeleteLabel(ctx context.Context, id int64) (int64, error) {
+	if _, err := s.execContext(ctx, "delete from task_label where label_id = ?", id); err != nil {
+		return 0, err
+	}
+	if _, err := s.execContext(ctx, "delete from task_template_label where label_id = ?", id); err != nil {
+		return 0, err
+	}
+	r, err := s.execContext(ctx, "delete from label where id = ?", id)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) FindLabels(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.Label, error) {
+	var labels []*models.Label
+	cond, args := page(opts)
+	sql := "select " + labelColumns + " from label where hospital_id = ?" + cond
+	if err := s.selectContext(ctx, &labels, sql, append([]any{hid}, args...)...); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (s *SQLStore) CountLabels(ctx context.Context, hid int64) (uint, error) {
+	var count uint
+	if err := s.getContext(ctx, &count, "select count(1) from label where hospital_id = ?", hid); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *SQLStore) CreateTaskLabel(ctx context.Context, taskID, labelID int64) error {
+	sql := "insert into task_label (task_id, label_id, created_at) VALUES (?, ?, ?)"
+	_, err := s.execContext(ctx, sql, taskID, labelID, time.Now().UTC())
+	return err
+}
+
+func (s *SQLStore) DeleteTaskLabel(ctx context.Context, taskID, labelID int64) (int64, error) {
+	r, err := s.execContext(ctx, "delete from task_label where task_id = ? and label_id = ?", taskID, labelID)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) FindTaskLabels(ctx context.Context, taskIDs []int64) (map[int64][]*models.Label, error) {
+	labels := make(map[int64][]*models.Label)
+	taskIDs = uniqueIDs(taskIDs)
+	if len(taskIDs) == 0 {
+		return labels, nil
+	}
+	var rows []struct {
+		TaskID int64 `db:"task_id"`
+		models.Label
+	}
+	marks, args := inArgs(taskIDs)
+	sql := "select tl.task_id, l.id, l.hospital_id, l.name, l.color, l.created_at, l.updated_at" +
+		" from task_label tl join label l on l.id = tl.label_id" +
+		" where tl.task_id in (" + marks + ") order by tl.task_id, l.name"
+	if err := s.selectContext(ctx, &rows, sql, args...); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		labels[rows[i].TaskID] = append(labels[rows[i].TaskID], &rows[i].Label)
+	}
+	return labels, nil
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestLabel(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "label_hospital"})
+	assert.NoError(t, err)
+	employee, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "labeler"})
+	assert.NoError(t, err)
+
+	var wound, urgent *models.Label
+
+	t.Run("CreateLabel", func(t *testing.T) {
+		wound, err = store.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "wound-care", Color: "#ff0000"})
+		assert.NoError(t, err)
+		assert.Greater(t, wound.ID, int64(0))
+		urgent, err = store.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "icu", Color: "#00ff00"})
+		assert.NoError(t, err)
+
+		_, err = store.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "wound-care", Color: "#000000"})
+		assert.True(t, IsErrDuplicateEntry(err))
+		_, err = store.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID + 100, Name: "x", Color: "#000000"})
+		assert.True(t, IsErrForeignKeyViolation(err))
+	})
+
+	t.Run("UpdateLabel", func(t *testing.T) {
+		n, err := store.UpdateLabel(ctx, &dto.Label{ID: wound.ID, Name: "wounds", Color: "#ff00ff"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		l, err := store.GetLabel(ctx, wound.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "wounds", l.Name)
+		assert.Equal(t, "#ff00ff", l.Color)
+
+		_, err = store.UpdateLabel(ctx, &dto.Label{ID: wound.ID, Name: "icu", Color: "#ff00ff"})
+		assert.True(t, IsErrDuplicateEntry(err))
+
+		labels, err := store.FindLabels(ctx, hospital.ID, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, labels, 2)
+		total, err := store.CountLabels(ctx, hospital.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+	})
+
+	t.Run("TaskLabels", func(t *testing.T) {
+		var tasks []*models.Task
+		for _, title := range []string{"both", "wound only", "none"} {
+			task, err := store.CreateTask(ctx, &dto.Task{
+				HospitalID: hospital.ID,
+				OwnerID:    employee.ID,
+				Title:      title,
+				Priority:   "LOW",
+				Status:     "OPEN",
+			})
+			assert.NoError(t, err)
+			tasks = append(tasks, task)
+		}
+		assert.NoError(t, store.CreateTaskLabel(ctx, tasks[0].ID, wound.ID))
+		assert.NoError(t, store.CreateTaskLabel(ctx, tasks[0].ID, urgent.ID))
+		assert.NoError(t, store.CreateTaskLabel(ctx, tasks[1].ID, wound.ID))
+		err := store.CreateTaskLabel(ctx, tasks[1].ID, wound.ID)
+		assert.True(t, IsErrDuplicateEntry(err))
+
+		labels, err := store.FindTaskLabels(ctx, []int64{tasks[0].ID, tasks[1].ID, tasks[2].ID})
+		assert.NoError(t, err)
+		if assert.Len(t, labels[tasks[0].ID], 2) {
+			// Sorted by name.
+			assert.Equal(t, "icu", labels[tasks[0].ID][0].Name)
+			assert.Equal(t, "wounds", labels[tasks[0].ID][1].Name)
+		}
+		assert.Len(t, labels[tasks[1].ID], 1)
+		assert.Empty(t, labels[tasks[2].ID])
+
+		anyLabel := dto.TaskFilter{LabelIDs: []int64{wound.ID, urgent.ID}}
+		n, err := store.CountTasksByHospital(ctx, hospital.ID, anyLabel, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), n)
+		allLabels := dto.TaskFilter{LabelIDs: []int64{wound.ID, urgent.ID, wound.ID}, LabelMatch: dto.LabelMatchAll}
+		found, err := store.FindTasksByHospital(ctx, hospital.ID, allLabels, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, found, 1) {
+			assert.Equal(t, tasks[0].ID, found[0].ID)
+		}
+
+		r, err := store.DeleteTaskLabel(ctx, tasks[1].ID, wound.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r)
+
+		// Deleting a label removes it from its tasks.
+		r, err = store.DeleteLabel(ctx, urgent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r)
+		_, err = store.GetLabel(ctx, urgent.ID)
+		assert.True(t, IsErrNotFound(err))
+		labels, err = store.FindTaskLabels(ctx, []int64{tasks[0].ID})
+		assert.NoError(t, err)
+		assert.Len(t, labels[tasks[0].ID], 1)
+	})
+}
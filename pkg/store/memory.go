@@ -0,0 +1,1925 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+// MemoryStore is an in-memory Store. It is safe for concurrent use and
+// mainly intended for tests and local development.
+type MemoryStore struct {
+	mu   *sync.RWMutex
+	data *memoryData
+	// inTx is set on the store given to a WithTx callback, which already
+	// holds the write lock.
+	inTx bool
+}
+
+type memoryData struct {
+	hospitalSeq int64
+	hospitals   map[int64]*models.Hospital
+
+	employeeSeq int64
+	employees   map[int64]*models.Employee
+
+	taskSeq int64
+	tasks   map[int64]*models.Task
+
+	taskTransitionSeq int64
+	taskTransitions   map[int64]*models.TaskTransition
+
+	commentSeq int64
+	comments   map[int64]*models.Comment
+
+	taskChangeSeq int64
+	taskChanges   map[int64]*models.TaskChange
+
+	attachmentSeq int64
+	attachments   map[int64]*models.Attachment
+
+	labelSeq int64
+	labels   map[int64]*models.Label
+	// taskLabels has no key of its own in SQL, taskLabelSeq is only used
+	// to index the map.
+	taskLabelSeq int64
+	taskLabels   map[int64]*models.TaskLabel
+
+	// Same as taskLabelSeq.
+	taskDependencySeq int64
+	taskDependencies  map[int64]*models.TaskDependency
+
+	taskRecurrenceSeq int64
+	taskRecurrences   map[int64]*models.TaskRecurrence
+	// Same as taskLabelSeq.
+	taskRecurrenceOwnerSeq int64
+	taskRecurrenceOwners   map[int64]*models.TaskRecurrenceOwner
+
+	taskSLASeq int64
+	taskSLAs   map[int64]*models.TaskSLA
+	// Same as taskLabelSeq.
+	taskSLAChainSeq   int64
+	taskSLAChains     map[int64]*models.TaskSLAChain
+	taskEscalationSeq int64
+	taskEscalations   map[int64]*models.TaskEscalation
+
+	// Same as taskLabelSeq.
+	taskWatcherSeq int64
+	taskWatchers   map[int64]*models.TaskWatcher
+	taskEventSeq   int64
+	taskEvents     map[int64]*models.TaskEvent
+
+	shiftSeq int64
+	shifts   map[int64]*models.Shift
+
+	taskTemplateSeq int64
+	taskTemplates   map[int64]*models.TaskTemplate
+	// Same as taskLabelSeq.
+	taskTemplateLabelSeq int64
+	taskTemplateLabels   map[int64]*models.TaskTemplateLabel
+	taskTemplateItemSeq  int64
+	taskTemplateItems    map[int64]*models.TaskTemplateItem
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		hospitals: make(map[int64]*models.Hospital),
+		employees: make(map[int64]*models.Employee),
+		tasks:     make(map[int64]*models.Task),
+
+		taskTransitions:  make(map[int64]*models.TaskTransition),
+		comments:         make(map[int64]*models.Comment),
+		taskChanges:      make(map[int64]*models.TaskChange),
+		attachments:      make(map[int64]*models.Attachment),
+		labels:           make(map[int64]*models.Label),
+		taskLabels:       make(map[int64]*models.TaskLabel),
+		taskDependencies: make(map[int64]*models.TaskDependency),
+
+		taskRecurrences:      make(map[int64]*models.TaskRecurrence),
+		taskRecurrenceOwners: make(map[int64]*models.TaskRecurrenceOwner),
+
+		taskSLAs:        make(map[int64]*models.TaskSLA),
+		taskSLAChains:   make(map[int64]*models.TaskSLAChain),
+		taskEscalations: make(map[int64]*models.TaskEscalation),
+
+		taskWatchers: make(map[int64]*models.TaskWatcher),
+		taskEvents:   make(map[int64]*models.TaskEvent),
+
+		shifts: make(map[int64]*models.Shift),
+
+		taskTemplates:      make(map[int64]*models.TaskTemplate),
+		taskTemplateLabels: make(map[int64]*models.TaskTemplateLabel),
+		taskTemplateItems:  make(map[int64]*models.TaskTemplateItem),
+	}
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := *d
+	c.hospitals = cloneMap(d.hospitals)
+	c.employees = cloneMap(d.employees)
+	c.tasks = cloneMap(d.tasks)
+	c.taskTransitions = cloneMap(d.taskTransitions)
+	c.comments = cloneMap(d.comments)
+	c.taskChanges = cloneMap(d.taskChanges)
+	c.attachments = cloneMap(d.attachments)
+	c.labels = cloneMap(d.labels)
+	c.taskLabels = cloneMap(d.taskLabels)
+	c.taskDependencies = cloneMap(d.taskDependencies)
+	c.taskRecurrences = cloneMap(d.taskRecurrences)
+	c.taskRecurrenceOwners = cloneMap(d.taskRecurrenceOwners)
+	c.taskSLAs = cloneMap(d.taskSLAs)
+	c.taskSLAChains = cloneMap(d.taskSLAChains)
+	c.taskEscalations = cloneMap(d.taskEscalations)
+	c.taskWatchers = cloneMap(d.taskWatchers)
+	c.taskEvents = cloneMap(d.taskEvents)
+	c.shifts = cloneMap(d.shifts)
+	c.taskTemplates = cloneMap(d.taskTemplates)
+	c.taskTemplateLabels = cloneMap(d.taskTemplateLabels)
+	c.taskTemplateItems = cloneMap(d.taskTemplateItems)
+	return &c
+}
+
+func cloneMap[T any](m map[int64]*T) map[int64]*T {
+	c := make(map[int64]*T, len(m))
+	for k, v := range m {
+		elem := *v
+		c[k] = &elem
+	}
+	return c
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu:   &sync.RWMutex{},
+		data: newMemoryData(),
+	}
+}
+
+// Cleanup removes all the data, like SQLStore.Cleanup does.
+func (s *MemoryStore) Cleanup() error {
+	defer s.lock()()
+	*s.data = *newMemoryData()
+	return nil
+}
+
+// WithTx runs fn while holding the write lock, so fn is isolated from any
+// other operation. Every change made by fn is undone if it returns an error.
+func (s *MemoryStore) WithTx(ctx context.Context, fn func(Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+	defer s.lock()()
+	snapshot := s.data.clone()
+	if err := fn(&MemoryStore{mu: s.mu, data: s.data, inTx: true}); err != nil {
+		*s.data = *snapshot
+		return err
+	}
+	return nil
+}
+
+func (s *MemoryStore) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *MemoryStore) rlock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+func (s *MemoryStore) GetHospital(ctx context.Context, id int64) (*models.Hospital, error) {
+	defer s.rlock()()
+	h, ok := s.data.hospitals[id]
+	if !ok || h.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	hospital := *h
+	return &hospital, nil
+}
+
+func (s *MemoryStore) GetDeletedHospital(ctx context.Context, id int64) (*models.Hospital, error) {
+	defer s.rlock()()
+	h, ok := s.data.hospitals[id]
+	if !ok || h.DeletedAt == nil {
+		return nil, sql.ErrNoRows
+	}
+	hospital := *h
+	return &hospital, nil
+}
+
+func (s *MemoryStore) CreateHospital(ctx context.Context, h *dto.Hospital) (*models.Hospital, error) {
+	defer s.lock()()
+	if s.hospitalNameTaken(h.Name, 0) {
+		return nil, ErrDuplicateEntry
+	}
+	s.data.hospitalSeq++
+	hs := &models.Hospital{
+		ID:                 s.data.hospitalSeq,
+		Name:               h.Name,
+		DisplayName:        h.DisplayName,
+		Timezone:           h.Timezone,
+		AssignmentStrategy: h.AssignmentStrategy,
+		AutoAssign:         h.AutoAssign,
+		OffShiftAssignment: h.OffShiftAssignment,
+		Version:            1,
+		CreatedAt:          time.Now().UTC(),
+		UpdatedAt:          time.Now().UTC(),
+	}
+	s.data.hospitals[hs.ID] = hs
+	hospital := *hs
+	return &hospital, nil
+}
+
+func (s *MemoryStore) UpdateHospital(ctx context.Context, h *dto.Hospital) (int64, error) {
+	defer s.lock()()
+	hospital, ok := s.data.hospitals[h.ID]
+	if !ok || hospital.DeletedAt != nil || (h.Version > 0 && h.Version != hospital.Version) {
+		return 0, nil
+	}
+	if s.hospitalNameTaken(h.Name, h.ID) {
+		return 0, ErrDuplicateEntry
+	}
+	hospital.Name = h.Name
+	hospital.DisplayName = h.DisplayName
+	hospital.Timezone = h.Timezone
+	hospital.AssignmentStrategy = h.AssignmentStrategy
+	hospital.AutoAssign = h.AutoAssign
+	hospital.OffShiftAssignment = h.OffShiftAssignment
+	hospital.Version++
+	hospital.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) SetLastAssignee(ctx context.Context, hid, eid int64) (int64, error) {
+	defer s.lock()()
+	hospital, ok := s.data.hospitals[hid]
+	if !ok || hospital.DeletedAt != nil {
+		return 0, nil
+	}
+	hospital.LastAssigneeID = &eid
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteHospital(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	h, ok := s.data.hospitals[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&h.DeletedAt, &h.UpdatedAt), nil
+}
+
+func (s *MemoryStore) RestoreHospital(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	h, ok := s.data.hospitals[id]
+	if !ok {
+		return 0, nil
+	}
+	return restore(&h.DeletedAt, &h.UpdatedAt), nil
+}
+
+func (s *MemoryStore) FindHospitals(ctx context.Context, opts dto.ListOptions) ([]*models.Hospital, error) {
+	defer s.rlock()()
+	hospitals := make([]*models.Hospital, 0, len(s.data.hospitals))
+	for _, h := range s.data.hospitals {
+		if h.ID > opts.AfterID && (h.DeletedAt == nil || opts.IncludeDeleted) {
+			hospital := *h
+			hospitals = append(hospitals, &hospital)
+		}
+	}
+	sort.Slice(hospitals, func(i, j int) bool { return hospitals[i].ID < hospitals[j].ID })
+	return paginate(hospitals, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountHosptials(ctx context.Context, opts dto.ListOptions) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, h := range s.data.hospitals {
+		if h.DeletedAt == nil || opts.IncludeDeleted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) hospitalNameTaken(name string, exceptID int64) bool {
+	for _, h := range s.data.hospitals {
+		if h.Name == name && h.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) GetEmployee(ctx context.Context, id int64) (*models.Employee, error) {
+	defer s.rlock()()
+	e, ok := s.data.employees[id]
+	if !ok || e.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	employee := *e
+	return &employee, nil
+}
+
+func (s *MemoryStore) GetDeletedEmployee(ctx context.Context, id int64) (*models.Employee, error) {
+	defer s.rlock()()
+	e, ok := s.data.employees[id]
+	if !ok || e.DeletedAt == nil {
+		return nil, sql.ErrNoRows
+	}
+	employee := *e
+	return &employee, nil
+}
+
+func (s *MemoryStore) CreateEmployee(ctx context.Context, e *dto.Employee) (*models.Employee, error) {
+	defer s.lock()()
+	for _, employee := range s.data.employees {
+		if employee.Username == e.Username {
+			return nil, ErrDuplicateEntry
+		}
+	}
+	if _, ok := s.data.hospitals[e.HospitalID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.employeeSeq++
+	employee := &models.Employee{
+		ID:         s.data.employeeSeq,
+		HospitalID: e.HospitalID,
+		Username:   e.Username,
+		FirstName:  e.FirstName,
+		LastName:   e.LastName,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	s.data.employees[employee.ID] = employee
+	ret := *employee
+	return &ret, nil
+}
+
+func (s *MemoryStore) DeleteEmployee(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	e, ok := s.data.employees[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&e.DeletedAt, &e.UpdatedAt), nil
+}
+
+func (s *MemoryStore) RestoreEmployee(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	e, ok := s.data.employees[id]
+	if !ok {
+		return 0, nil
+	}
+	return restore(&e.DeletedAt, &e.UpdatedAt), nil
+}
+
+func (s *MemoryStore) DeleteEmployeesByHospital(ctx context.Context, hid int64, at time.Time) (int64, error) {
+	defer s.lock()()
+	var count int64
+	for _, e := range s.data.employees {
+		if e.HospitalID == hid {
+			count += softDeleteAt(&e.DeletedAt, &e.UpdatedAt, at)
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) FindEmployees(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.Employee, error) {
+	defer s.rlock()()
+	var employees []*models.Employee
+	for _, e := range s.data.employees {
+		if e.HospitalID == hid && e.ID > opts.AfterID && (e.DeletedAt == nil || opts.IncludeDeleted) {
+			employee := *e
+			employees = append(employees, &employee)
+		}
+	}
+	sort.Slice(employees, func(i, j int) bool { return employees[i].ID < employees[j].ID })
+	return paginate(employees, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountEmployees(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, e := range s.data.employees {
+		if e.HospitalID == hid && (e.DeletedAt == nil || opts.IncludeDeleted) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	defer s.rlock()()
+	t, ok := s.data.tasks[id]
+	if !ok || t.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	task := *t
+	return &task, nil
+}
+
+func (s *MemoryStore) CreateTask(ctx context.Context, task *dto.Task) (*models.Task, error) {
+	defer s.lock()()
+	if _, ok := s.data.hospitals[task.HospitalID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.data.employees[task.OwnerID]; task.OwnerID != 0 && !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.data.tasks[task.ParentID]; task.ParentID != 0 && !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if task.RecurrenceID != 0 {
+		if _, ok := s.data.taskRecurrences[task.RecurrenceID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+		for _, t := range s.data.tasks {
+			if t.RecurrenceID != nil && *t.RecurrenceID == task.RecurrenceID && t.OccurrenceAt != nil && task.OccurrenceAt != nil && t.OccurrenceAt.Equal(*task.OccurrenceAt) {
+				return nil, ErrDuplicateEntry
+			}
+		}
+	}
+	s.data.taskSeq++
+	t := &models.Task{
+		ID:           s.data.taskSeq,
+		HospitalID:   task.HospitalID,
+		OwnerID:      nullID(task.OwnerID),
+		ParentID:     nullID(task.ParentID),
+		RecurrenceID: nullID(task.RecurrenceID),
+		OccurrenceAt: utcTime(task.OccurrenceAt),
+		Title:        task.Title,
+		Description:  task.Description,
+		Priority:     task.Priority,
+		Status:       task.Status,
+		DueAt:        utcTime(task.DueAt),
+		Version:      1,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+	}
+	s.data.tasks[t.ID] = t
+	ret := *t
+	return &ret, nil
+}
+
+func (s *MemoryStore) UpdateTask(ctx context.Context, task *dto.Task) (int64, error) {
+	defer s.lock()()
+	t, ok := s.data.tasks[task.ID]
+	if !ok || t.DeletedAt != nil || (task.Version > 0 && task.Version != t.Version) {
+		return 0, nil
+	}
+	if _, ok := s.data.employees[task.OwnerID]; task.OwnerID != 0 && !ok {
+		return 0, ErrForeignKeyViolation
+	}
+	t.OwnerID = nullID(task.OwnerID)
+	t.Title = task.Title
+	t.Description = task.Description
+	t.Priority = task.Priority
+	t.Status = task.Status
+	t.DueAt = utcTime(task.DueAt)
+	t.Version++
+	t.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) ClaimTask(ctx context.Context, id, oid int64) (int64, error) {
+	defer s.lock()()
+	t, ok := s.data.tasks[id]
+	if !ok || t.DeletedAt != nil || t.OwnerID != nil {
+		return 0, nil
+	}
+	if _, ok := s.data.employees[oid]; !ok {
+		return 0, ErrForeignKeyViolation
+	}
+	t.OwnerID = &oid
+	t.Version++
+	t.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteTask(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	t, ok := s.data.tasks[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&t.DeletedAt, &t.UpdatedAt), nil
+}
+
+func (s *MemoryStore) RestoreTask(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	t, ok := s.data.tasks[id]
+	if !ok {
+		return 0, nil
+	}
+	return restore(&t.DeletedAt, &t.UpdatedAt), nil
+}
+
+func (s *MemoryStore) FindTasksByHospital(ctx context.Context, hosptialID int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error) {
+	return s.findTasks(func(t *models.Task) bool {
+		return t.HospitalID == hosptialID && matchTask(t, s.data.taskLabelIDs(t.ID), filter, opts)
+	}, opts)
+}
+
+func (s *MemoryStore) CountTasksByHospital(ctx context.Context, hosptialID int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error) {
+	return s.countTasks(func(t *models.Task) bool {
+		return t.HospitalID == hosptialID && matchTask(t, s.data.taskLabelIDs(t.ID), filter, opts)
+	}), nil
+}
+
+func (s *MemoryStore) FindTasksByOwner(ctx context.Context, oid int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error) {
+	return s.findTasks(func(t *models.Task) bool {
+		return isOwnedBy(t, oid) && matchTask(t, s.data.taskLabelIDs(t.ID), filter, opts)
+	}, opts)
+}
+
+func (s *MemoryStore) CountTasksByOwner(ctx context.Context, oid int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error) {
+	return s.countTasks(func(t *models.Task) bool {
+		return isOwnedBy(t, oid) && matchTask(t, s.data.taskLabelIDs(t.ID), filter, opts)
+	}), nil
+}
+
+func (s *MemoryStore) CountOpenTasksByHospital(ctx context.Context, hosptialID int64) (uint, error) {
+	return s.countTasks(func(t *models.Task) bool {
+		return t.HospitalID == hosptialID && t.DeletedAt == nil && !models.IsTaskClosed(t.Status)
+	}), nil
+}
+
+func (s *MemoryStore) CountOpenTasksPerOwner(ctx context.Context, hosptialID int64) ([]*models.OwnerTaskCount, error) {
+	defer s.rlock()()
+	type key struct {
+		owner    int64
+		priority string
+	}
+	var counts []*models.OwnerTaskCount
+	index := make(map[key]*models.OwnerTaskCount)
+	for _, t := range s.data.tasks {
+		if t.HospitalID != hosptialID || t.OwnerID == nil || t.DeletedAt != nil || models.IsTaskClosed(t.Status) {
+			continue
+		}
+		k := key{*t.OwnerID, t.Priority}
+		c, ok := index[k]
+		if !ok {
+			c = &models.OwnerTaskCount{OwnerID: k.owner, Priority: k.priority}
+			index[k] = c
+			counts = append(counts, c)
+		}
+		c.Count++
+	}
+	return counts, nil
+}
+
+func (s *MemoryStore) CountOpenTasksByOwner(ctx context.Context, oid int64) (uint, error) {
+	return s.countTasks(func(t *models.Task) bool {
+		return isOwnedBy(t, oid) && t.DeletedAt == nil && !models.IsTaskClosed(t.Status)
+	}), nil
+}
+
+func (s *MemoryStore) SweepOverdueTasks(ctx context.Context, now time.Time) (int64, error) {
+	defer s.lock()()
+	var count int64
+	for _, t := range s.data.tasks {
+		overdue := t.DueAt != nil && t.DueAt.Before(now) && !models.IsTaskClosed(t.Status)
+		if overdue && !t.Overdue && t.DeletedAt == nil {
+			t.Overdue = true
+			count++
+		} else if !overdue && t.Overdue {
+			t.Overdue = false
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) DeleteTasksByHospital(ctx context.Context, hosptialID int64, at time.Time) (int64, error) {
+	return s.deleteTasks(func(t *models.Task) bool { return t.HospitalID == hosptialID }, at), nil
+}
+
+func (s *MemoryStore) DeleteTasksByOwner(ctx context.Context, oid int64, at time.Time) (int64, error) {
+	return s.deleteTasks(func(t *models.Task) bool { return isOwnedBy(t, oid) }, at), nil
+}
+
+func (s *MemoryStore) deleteTasks(match func(*models.Task) bool, at time.Time) int64 {
+	defer s.lock()()
+	var count int64
+	for _, t := range s.data.tasks {
+		if match(t) {
+			count += softDeleteAt(&t.DeletedAt, &t.UpdatedAt, at)
+		}
+	}
+	return count
+}
+
+func (s *MemoryStore) findTasks(match func(*models.Task) bool, opts dto.ListOptions) ([]*models.Task, error) {
+	defer s.rlock()()
+	var tasks []*models.Task
+	for _, t := range s.data.tasks {
+		if match(t) {
+			task := *t
+			tasks = append(tasks, &task)
+		}
+	}
+	tasks, err := sortTasks(tasks, opts)
+	if err != nil {
+		return nil, err
+	}
+	return paginate(tasks, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) countTasks(match func(*models.Task) bool) uint {
+	defer s.rlock()()
+	var count uint
+	for _, t := range s.data.tasks {
+		if match(t) {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *MemoryStore) SetTaskParent(ctx context.Context, id, parentID int64) (int64, error) {
+	defer s.lock()()
+	t, ok := s.data.tasks[id]
+	if !ok || t.DeletedAt != nil {
+		return 0, nil
+	}
+	if _, ok := s.data.tasks[parentID]; parentID != 0 && !ok {
+		return 0, ErrForeignKeyViolation
+	}
+	t.ParentID = nullID(parentID)
+	t.Version++
+	t.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) GetTaskParentID(ctx context.Context, id int64) (int64, error) {
+	defer s.rlock()()
+	t, ok := s.data.tasks[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	if t.ParentID == nil {
+		return 0, nil
+	}
+	return *t.ParentID, nil
+}
+
+func (s *MemoryStore) FindSubtasks(ctx context.Context, parentID int64) ([]*models.Task, error) {
+	return s.findTasks(func(t *models.Task) bool {
+		return t.ParentID != nil && *t.ParentID == parentID && t.DeletedAt == nil
+	}, dto.ListOptions{Limit: math.MaxInt32})
+}
+
+func (s *MemoryStore) CountSubtasks(ctx context.Context, parentID int64) (uint, uint, error) {
+	total := s.countTasks(func(t *models.Task) bool {
+		return t.ParentID != nil && *t.ParentID == parentID && t.DeletedAt == nil
+	})
+	done := s.countTasks(func(t *models.Task) bool {
+		return t.ParentID != nil && *t.ParentID == parentID && t.DeletedAt == nil && t.Status == models.TaskStatusCOMPLETED
+	})
+	return total, done, nil
+}
+
+func (s *MemoryStore) CreateTaskDependency(ctx context.Context, taskID, blockerID int64) error {
+	defer s.lock()()
+	if _, ok := s.data.tasks[taskID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if _, ok := s.data.tasks[blockerID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if containsID(s.data.taskBlockerIDs(taskID), blockerID) {
+		return ErrDuplicateEntry
+	}
+	s.data.taskDependencySeq++
+	s.data.taskDependencies[s.data.taskDependencySeq] = &models.TaskDependency{
+		TaskID:    taskID,
+		BlockerID: blockerID,
+		CreatedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTaskDependency(ctx context.Context, taskID, blockerID int64) (int64, error) {
+	defer s.lock()()
+	for k, d := range s.data.taskDependencies {
+		if d.TaskID == taskID && d.BlockerID == blockerID {
+			delete(s.data.taskDependencies, k)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) FindTaskBlockerIDs(ctx context.Context, taskID int64) ([]int64, error) {
+	defer s.rlock()()
+	ids := s.data.taskBlockerIDs(taskID)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *MemoryStore) FindTaskBlockers(ctx context.Context, taskID int64) ([]*models.Task, error) {
+	return s.findTasks(func(t *models.Task) bool {
+		return containsID(s.data.taskBlockerIDs(taskID), t.ID) && t.DeletedAt == nil
+	}, dto.ListOptions{Limit: math.MaxInt32})
+}
+
+func (s *MemoryStore) CountOpenBlockers(ctx context.Context, taskID int64) (uint, error) {
+	return s.countTasks(func(t *models.Task) bool {
+		return containsID(s.data.taskBlockerIDs(taskID), t.ID) && t.DeletedAt == nil && !models.IsTaskClosed(t.Status)
+	}), nil
+}
+
+// taskBlockerIDs returns the ids of the blockers of the task taskID.
+func (d *memoryData) taskBlockerIDs(taskID int64) []int64 {
+	var ids []int64
+	for _, dep := range d.taskDependencies {
+		if dep.TaskID == taskID {
+			ids = append(ids, dep.BlockerID)
+		}
+	}
+	return ids
+}
+
+func (s *MemoryStore) CreateTaskTransition(ctx context.Context, taskID int64, from, to string) (*models.TaskTransition, error) {
+	defer s.lock()()
+	if _, ok := s.data.tasks[taskID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.taskTransitionSeq++
+	t := &models.TaskTransition{
+		ID:         s.data.taskTransitionSeq,
+		TaskID:     taskID,
+		FromStatus: from,
+		ToStatus:   to,
+		CreatedAt:  time.Now().UTC(),
+	}
+	s.data.taskTransitions[t.ID] = t
+	ret := *t
+	return &ret, nil
+}
+
+func (s *MemoryStore) FindTaskTransitions(ctx context.Context, taskID int64) ([]*models.TaskTransition, error) {
+	defer s.rlock()()
+	var transitions []*models.TaskTransition
+	for _, t := range s.data.taskTransitions {
+		if t.TaskID == taskID {
+			transition := *t
+			transitions = append(transitions, &transition)
+		}
+	}
+	sort.Slice(transitions, func(i, j int) bool { return transitions[i].ID < transitions[j].ID })
+	return transitions, nil
+}
+
+func (s *MemoryStore) CreateTaskChange(ctx context.Context, c *dto.TaskChange) (*models.TaskChange, error) {
+	defer s.lock()()
+	if _, ok := s.data.tasks[c.TaskID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.taskChangeSeq++
+	change := &models.TaskChange{
+		ID:        s.data.taskChangeSeq,
+		TaskID:    c.TaskID,
+		Field:     c.Field,
+		OldValue:  c.OldValue,
+		NewValue:  c.NewValue,
+		CreatedAt: time.Now().UTC(),
+	}
+	if c.ActorID > 0 {
+		if _, ok := s.data.employees[c.ActorID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+		actorID := c.ActorID
+		change.ActorID = &actorID
+	}
+	s.data.taskChanges[change.ID] = change
+	ret := *change
+	return &ret, nil
+}
+
+func (s *MemoryStore) GetTaskChange(ctx context.Context, id int64) (*models.TaskChange, error) {
+	defer s.rlock()()
+	c, ok := s.data.taskChanges[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	change := *c
+	return &change, nil
+}
+
+func (s *MemoryStore) FindTaskChanges(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.TaskChange, error) {
+	defer s.rlock()()
+	var changes []*models.TaskChange
+	for _, c := range s.data.taskChanges {
+		if c.TaskID == taskID && c.ID > opts.AfterID {
+			change := *c
+			changes = append(changes, &change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	return paginate(changes, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountTaskChanges(ctx context.Context, taskID int64) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, c := range s.data.taskChanges {
+		if c.TaskID == taskID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) GetComment(ctx context.Context, id int64) (*models.Comment, error) {
+	defer s.rlock()()
+	c, ok := s.data.comments[id]
+	if !ok || c.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	comment := *c
+	return &comment, nil
+}
+
+func (s *MemoryStore) CreateComment(ctx context.Context, c *dto.Comment) (*models.Comment, error) {
+	defer s.lock()()
+	if _, ok := s.data.tasks[c.TaskID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.data.employees[c.AuthorID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.commentSeq++
+	comment := &models.Comment{
+		ID:        s.data.commentSeq,
+		TaskID:    c.TaskID,
+		AuthorID:  c.AuthorID,
+		Body:      c.Body,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	s.data.comments[comment.ID] = comment
+	ret := *comment
+	return &ret, nil
+}
+
+func (s *MemoryStore) UpdateComment(ctx context.Context, c *dto.Comment) (int64, error) {
+	defer s.lock()()
+	comment, ok := s.data.comments[c.ID]
+	if !ok || comment.DeletedAt != nil {
+		return 0, nil
+	}
+	comment.Body = c.Body
+	comment.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteComment(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	c, ok := s.data.comments[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&c.DeletedAt, &c.UpdatedAt), nil
+}
+
+func (s *MemoryStore) FindComments(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.Comment, error) {
+	defer s.rlock()()
+	var comments []*models.Comment
+	for _, c := range s.data.comments {
+		if c.TaskID == taskID && c.ID > opts.AfterID && (c.DeletedAt == nil || opts.IncludeDeleted) {
+			comment := *c
+			comments = append(comments, &comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return paginate(comments, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountComments(ctx context.Context, taskID int64, opts dto.ListOptions) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, c := range s.data.comments {
+		if c.TaskID == taskID && (c.DeletedAt == nil || opts.IncludeDeleted) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) GetAttachment(ctx context.Context, id int64) (*models.Attachment, error) {
+	defer s.rlock()()
+	a, ok := s.data.attachments[id]
+	if !ok || a.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	attachment := *a
+	return &attachment, nil
+}
+
+func (s *MemoryStore) CreateAttachment(ctx context.Context, a *dto.Attachment) (*models.Attachment, error) {
+	defer s.lock()()
+	if _, ok := s.data.tasks[a.TaskID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	var uploaderID *int64
+	if a.UploaderID != 0 {
+		if _, ok := s.data.employees[a.UploaderID]; !ok {
+			return nil, ErrForeignKeyViolation
+		}
+		uploaderID = &a.UploaderID
+	}
+	for _, v := range s.data.attachments {
+		if v.BlobKey == a.BlobKey {
+			return nil, ErrDuplicateEntry
+		}
+	}
+	s.data.attachmentSeq++
+	attachment := &models.Attachment{
+		ID:          s.data.attachmentSeq,
+		TaskID:      a.TaskID,
+		UploaderID:  uploaderID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		BlobKey:     a.BlobKey,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	s.data.attachments[attachment.ID] = attachment
+	ret := *attachment
+	return &ret, nil
+}
+
+func (s *MemoryStore) DeleteAttachment(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	a, ok := s.data.attachments[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&a.DeletedAt, &a.UpdatedAt), nil
+}
+
+func (s *MemoryStore) FindAttachments(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.Attachment, error) {
+	defer s.rlock()()
+	var attachments []*models.Attachment
+	for _, a := range s.data.attachments {
+		if a.TaskID == taskID && a.ID > opts.AfterID && (a.DeletedAt == nil || opts.IncludeDeleted) {
+			attachment := *a
+			attachments = append(attachments, &attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+	return paginate(attachments, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountAttachments(ctx context.Context, taskID int64, opts dto.ListOptions) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, a := range s.data.attachments {
+		if a.TaskID == taskID && (a.DeletedAt == nil || opts.IncludeDeleted) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) GetLabel(ctx context.Context, id int64) (*models.Label, error) {
+	defer s.rlock()()
+	l, ok := s.data.labels[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	label := *l
+	return &label, nil
+}
+
+func (s *MemoryStore) CreateLabel(ctx context.Context, l *dto.Label) (*models.Label, error) {
+	defer s.lock()()
+	if _, ok := s.data.hospitals[l.HospitalID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if s.labelNameTaken(l.HospitalID, l.Name, 0) {
+		return nil, ErrDuplicateEntry
+	}
+	s.data.labelSeq++
+	label := &models.Label{
+		ID:         s.data.labelSeq,
+		HospitalID: l.HospitalID,
+		Name:       l.Name,
+		Color:      l.Color,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	s.data.labels[label.ID] = label
+	ret := *label
+	return &ret, nil
+}
+
+func (s *MemoryStore) UpdateLabel(ctx context.Context, l *dto.Label) (int64, error) {
+	defer s.lock()()
+	label, ok := s.data.labels[l.ID]
+	if !ok {
+		return 0, nil
+	}
+	if s.labelNameTaken(label.HospitalID, l.Name, l.ID) {
+		return 0, ErrDuplicateEntry
+	}
+	label.Name = l.Name
+	label.Color = l.Color
+	label.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) labelNameTaken(hid int64, name string, exceptID int64) bool {
+	for _, l := range s.data.labels {
+		if l.HospitalID == hid && l.Name == name && l.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) DeleteLabel(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	if _, ok := s.data.labels[id]; !ok {
+		return 0, nil
+	}
+	for k, tl := range s.data.taskLabels {
+		if tl.LabelID == id {
+			delete(s.data.taskLabels, k)
+		}
+	}
+	for k, tl := range s.data.taskTemplateLabels {
+		if tl.LabelID == id {
+			delete(s.data.taskTemplateLabels, k)
+		}
+	}
+	delete(s.data.labels, id)
+	return 1, nil
+}
+
+func (s *MemoryStore) FindLabels(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.Label, error) {
+	defer s.rlock()()
+	var labels []*models.Label
+	for _, l := range s.data.labels {
+		if l.HospitalID == hid && l.ID > opts.AfterID {
+			label := *l
+			labels = append(labels, &label)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].ID < labels[j].ID })
+	return paginate(labels, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountLabels(ctx context.Context, hid int64) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, l := range s.data.labels {
+		if l.HospitalID == hid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) CreateTaskLabel(ctx context.Context, taskID, labelID int64) error {
+	defer s.lock()()
+	if _, ok := s.data.tasks[taskID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if _, ok := s.data.labels[labelID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if containsID(s.data.taskLabelIDs(taskID), labelID) {
+		return ErrDuplicateEntry
+	}
+	s.data.taskLabelSeq++
+	s.data.taskLabels[s.data.taskLabelSeq] = &models.TaskLabel{
+		TaskID:    taskID,
+		LabelID:   labelID,
+		CreatedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTaskLabel(ctx context.Context, taskID, labelID int64) (int64, error) {
+	defer s.lock()()
+	for k, tl := range s.data.taskLabels {
+		if tl.TaskID == taskID && tl.LabelID == labelID {
+			delete(s.data.taskLabels, k)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) FindTaskLabels(ctx context.Context, taskIDs []int64) (map[int64][]*models.Label, error) {
+	defer s.rlock()()
+	labels := make(map[int64][]*models.Label)
+	for _, taskID := range uniqueIDs(taskIDs) {
+		for _, id := range s.data.taskLabelIDs(taskID) {
+			label := *s.data.labels[id]
+			labels[taskID] = append(labels[taskID], &label)
+		}
+		sort.Slice(labels[taskID], func(i, j int) bool { return labels[taskID][i].Name < labels[taskID][j].Name })
+	}
+	return labels, nil
+}
+
+// taskLabelIDs returns the ids of the labels of the task taskID.
+func (d *memoryData) taskLabelIDs(taskID int64) []int64 {
+	var ids []int64
+	for _, tl := range d.taskLabels {
+		if tl.TaskID == taskID {
+			ids = append(ids, tl.LabelID)
+		}
+	}
+	return ids
+}
+
+// softDelete sets deletedAt unless it's set already, like SQLStore.softDelete.
+func softDelete(deletedAt **time.Time, updatedAt *time.Time) int64 {
+	return softDeleteAt(deletedAt, updatedAt, time.Now().UTC())
+}
+
+// softDeleteAt is softDelete setting deletedAt to at, like
+// SQLStore.softDeleteAt.
+func softDeleteAt(deletedAt **time.Time, updatedAt *time.Time, at time.Time) int64 {
+	if *deletedAt != nil {
+		return 0
+	}
+	*deletedAt = &at
+	*updatedAt = time.Now().UTC()
+	return 1
+}
+
+// restore clears deletedAt if it's set, like SQLStore.restore.
+func restore(deletedAt **time.Time, updatedAt *time.Time) int64 {
+	if *deletedAt == nil {
+		return 0
+	}
+	*deletedAt = nil
+	*updatedAt = time.Now().UTC()
+	return 1
+}
+
+func (s *MemoryStore) GetTaskRecurrence(ctx context.Context, id int64) (*models.TaskRecurrence, error) {
+	defer s.rlock()()
+	r, ok := s.data.taskRecurrences[id]
+	if !ok || r.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	recurrence := *r
+	return &recurrence, nil
+}
+
+func (s *MemoryStore) CreateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (*models.TaskRecurrence, error) {
+	defer s.lock()()
+	if _, ok := s.data.hospitals[r.HospitalID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.taskRecurrenceSeq++
+	recurrence := &models.TaskRecurrence{
+		ID:          s.data.taskRecurrenceSeq,
+		HospitalID:  r.HospitalID,
+		Title:       r.Title,
+		Description: r.Description,
+		Priority:    r.Priority,
+		DueIn:       r.DueIn,
+		Schedule:    r.Schedule,
+		NextAt:      r.NextAt.UTC(),
+		Version:     1,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	s.data.taskRecurrences[recurrence.ID] = recurrence
+	ret := *recurrence
+	return &ret, nil
+}
+
+func (s *MemoryStore) UpdateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (int64, error) {
+	defer s.lock()()
+	recurrence, ok := s.data.taskRecurrences[r.ID]
+	if !ok || recurrence.DeletedAt != nil || (r.Version > 0 && r.Version != recurrence.Version) {
+		return 0, nil
+	}
+	recurrence.Title = r.Title
+	recurrence.Description = r.Description
+	recurrence.Priority = r.Priority
+	recurrence.DueIn = r.DueIn
+	recurrence.Schedule = r.Schedule
+	recurrence.NextAt = r.NextAt.UTC()
+	recurrence.Version++
+	recurrence.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteTaskRecurrence(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	r, ok := s.data.taskRecurrences[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&r.DeletedAt, &r.UpdatedAt), nil
+}
+
+func (s *MemoryStore) FindTaskRecurrences(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.TaskRecurrence, error) {
+	defer s.rlock()()
+	var recurrences []*models.TaskRecurrence
+	for _, r := range s.data.taskRecurrences {
+		if r.HospitalID == hid && r.ID > opts.AfterID && (r.DeletedAt == nil || opts.IncludeDeleted) {
+			recurrence := *r
+			recurrences = append(recurrences, &recurrence)
+		}
+	}
+	sort.Slice(recurrences, func(i, j int) bool { return recurrences[i].ID < recurrences[j].ID })
+	return paginate(recurrences, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountTaskRecurrences(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, r := range s.data.taskRecurrences {
+		if r.HospitalID == hid && (r.DeletedAt == nil || opts.IncludeDeleted) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) SetTaskRecurrenceOwners(ctx context.Context, id int64, ownerIDs []int64) error {
+	defer s.lock()()
+	if _, ok := s.data.taskRecurrences[id]; !ok {
+		return ErrForeignKeyViolation
+	}
+	for _, oid := range ownerIDs {
+		if _, ok := s.data.employees[oid]; !ok {
+			return ErrForeignKeyViolation
+		}
+	}
+	for k, o := range s.data.taskRecurrenceOwners {
+		if o.RecurrenceID == id {
+			delete(s.data.taskRecurrenceOwners, k)
+		}
+	}
+	for i, oid := range ownerIDs {
+		s.data.taskRecurrenceOwnerSeq++
+		s.data.taskRecurrenceOwners[s.data.taskRecurrenceOwnerSeq] = &models.TaskRecurrenceOwner{
+			RecurrenceID: id,
+			Position:     i,
+			EmployeeID:   oid,
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) FindTaskRecurrenceOwners(ctx context.Context, ids []int64) (map[int64][]int64, error) {
+	defer s.rlock()()
+	var rows []*models.TaskRecurrenceOwner
+	for _, o := range s.data.taskRecurrenceOwners {
+		if containsID(ids, o.RecurrenceID) {
+			rows = append(rows, o)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Position < rows[j].Position })
+	owners := make(map[int64][]int64)
+	for _, o := range rows {
+		owners[o.RecurrenceID] = append(owners[o.RecurrenceID], o.EmployeeID)
+	}
+	return owners, nil
+}
+
+func (s *MemoryStore) FindDueTaskRecurrenceIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	defer s.rlock()()
+	var due []*models.TaskRecurrence
+	for _, r := range s.data.taskRecurrences {
+		h := s.data.hospitals[r.HospitalID]
+		if r.DeletedAt == nil && h.DeletedAt == nil && !r.NextAt.After(now) {
+			due = append(due, r)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAt.Equal(due[j].NextAt) {
+			return due[i].NextAt.Before(due[j].NextAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	ids := make([]int64, len(due))
+	for i, r := range due {
+		ids[i] = r.ID
+	}
+	return paginate(ids, 0, uint(limit)), nil
+}
+
+func (s *MemoryStore) AdvanceTaskRecurrence(ctx context.Context, id, occurrences int64, nextAt time.Time) (int64, error) {
+	defer s.lock()()
+	r, ok := s.data.taskRecurrences[id]
+	if !ok || r.DeletedAt != nil || r.Occurrences != occurrences {
+		return 0, nil
+	}
+	r.NextAt = nextAt.UTC()
+	r.Occurrences++
+	return 1, nil
+}
+
+func (s *MemoryStore) GetTaskSLA(ctx context.Context, hid int64, priority string) (*models.TaskSLA, error) {
+	defer s.rlock()()
+	for _, sla := range s.data.taskSLAs {
+		if sla.HospitalID == hid && sla.Priority == priority {
+			ret := *sla
+			return &ret, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *MemoryStore) CreateTaskSLA(ctx context.Context, sla *dto.TaskSLA) (*models.TaskSLA, error) {
+	defer s.lock()()
+	if _, ok := s.data.hospitals[sla.HospitalID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	for _, other := range s.data.taskSLAs {
+		if other.HospitalID == sla.HospitalID && other.Priority == sla.Priority {
+			return nil, ErrDuplicateEntry
+		}
+	}
+	s.data.taskSLASeq++
+	created := &models.TaskSLA{
+		ID:            s.data.taskSLASeq,
+		HospitalID:    sla.HospitalID,
+		Priority:      sla.Priority,
+		RespondWithin: sla.RespondWithin,
+		Escalation:    sla.Escalation,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	s.data.taskSLAs[created.ID] = created
+	ret := *created
+	return &ret, nil
+}
+
+func (s *MemoryStore) UpdateTaskSLA(ctx context.Context, sla *dto.TaskSLA) (int64, error) {
+	defer s.lock()()
+	for _, current := range s.data.taskSLAs {
+		if current.HospitalID == sla.HospitalID && current.Priority == sla.Priority {
+			current.RespondWithin = sla.RespondWithin
+			current.Escalation = sla.Escalation
+			current.UpdatedAt = time.Now().UTC()
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) DeleteTaskSLA(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	if _, ok := s.data.taskSLAs[id]; !ok {
+		return 0, nil
+	}
+	for k, c := range s.data.taskSLAChains {
+		if c.SLAID == id {
+			delete(s.data.taskSLAChains, k)
+		}
+	}
+	delete(s.data.taskSLAs, id)
+	return 1, nil
+}
+
+func (s *MemoryStore) FindTaskSLAs(ctx context.Context, hid int64) ([]*models.TaskSLA, error) {
+	return s.findTaskSLAs(func(sla *models.TaskSLA) bool { return sla.HospitalID == hid }), nil
+}
+
+func (s *MemoryStore) FindAllTaskSLAs(ctx context.Context) ([]*models.TaskSLA, error) {
+	return s.findTaskSLAs(func(sla *models.TaskSLA) bool { return s.data.hospitals[sla.HospitalID].DeletedAt == nil }), nil
+}
+
+func (s *MemoryStore) findTaskSLAs(match func(*models.TaskSLA) bool) []*models.TaskSLA {
+	defer s.rlock()()
+	var slas []*models.TaskSLA
+	for _, sla := range s.data.taskSLAs {
+		if match(sla) {
+			ret := *sla
+			slas = append(slas, &ret)
+		}
+	}
+	sort.Slice(slas, func(i, j int) bool { return slas[i].ID < slas[j].ID })
+	return slas
+}
+
+func (s *MemoryStore) SetTaskSLAChain(ctx context.Context, id int64, employeeIDs []int64) error {
+	defer s.lock()()
+	if _, ok := s.data.taskSLAs[id]; !ok {
+		return ErrForeignKeyViolation
+	}
+	for _, eid := range employeeIDs {
+		if _, ok := s.data.employees[eid]; !ok {
+			return ErrForeignKeyViolation
+		}
+	}
+	for k, c := range s.data.taskSLAChains {
+		if c.SLAID == id {
+			delete(s.data.taskSLAChains, k)
+		}
+	}
+	for i, eid := range employeeIDs {
+		s.data.taskSLAChainSeq++
+		s.data.taskSLAChains[s.data.taskSLAChainSeq] = &models.TaskSLAChain{
+			SLAID:      id,
+			Position:   i,
+			EmployeeID: eid,
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) FindTaskSLAChains(ctx context.Context, ids []int64) (map[int64][]int64, error) {
+	defer s.rlock()()
+	var rows []*models.TaskSLAChain
+	for _, c := range s.data.taskSLAChains {
+		if containsID(ids, c.SLAID) {
+			rows = append(rows, c)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Position < rows[j].Position })
+	chains := make(map[int64][]int64)
+	for _, c := range rows {
+		chains[c.SLAID] = append(chains[c.SLAID], c.EmployeeID)
+	}
+	return chains, nil
+}
+
+func (s *MemoryStore) FindBreachingTaskIDs(ctx context.Context, sla *models.TaskSLA, since time.Time, limit int) ([]int64, error) {
+	defer s.rlock()()
+	// The tasks moved or escalated after since.
+	active := make(map[int64]bool)
+	for _, t := range s.data.taskTransitions {
+		if t.CreatedAt.After(since) {
+			active[t.TaskID] = true
+		}
+	}
+	for _, e := range s.data.taskEscalations {
+		if e.CreatedAt.After(since) {
+			active[e.TaskID] = true
+		}
+	}
+	var ids []int64
+	for _, t := range s.data.tasks {
+		if t.HospitalID == sla.HospitalID && t.Priority == sla.Priority && t.Status == models.TaskStatusOpen && t.DeletedAt == nil && !active[t.ID] {
+			ids = append(ids, t.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return paginate(ids, 0, uint(limit)), nil
+}
+
+func (s *MemoryStore) MarkTaskBreached(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	t, ok := s.data.tasks[id]
+	if !ok || t.Breached {
+		return 0, nil
+	}
+	t.Breached = true
+	return 1, nil
+}
+
+func (s *MemoryStore) ClearTaskBreaches(ctx context.Context) (int64, error) {
+	defer s.lock()()
+	var n int64
+	for _, t := range s.data.tasks {
+		if t.Breached && t.Status != models.TaskStatusOpen {
+			t.Breached = false
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) CreateTaskEscalation(ctx context.Context, e *dto.TaskEscalation) (*models.TaskEscalation, error) {
+	defer s.lock()()
+	if _, ok := s.data.tasks[e.TaskID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.taskEscalationSeq++
+	escalation := &models.TaskEscalation{
+		ID:        s.data.taskEscalationSeq,
+		TaskID:    e.TaskID,
+		Level:     e.Level,
+		Action:    e.Action,
+		OldValue:  e.OldValue,
+		NewValue:  e.NewValue,
+		CreatedAt: e.CreatedAt.UTC(),
+	}
+	s.data.taskEscalations[escalation.ID] = escalation
+	ret := *escalation
+	return &ret, nil
+}
+
+func (s *MemoryStore) FindTaskEscalations(ctx context.Context, taskID int64) ([]*models.TaskEscalation, error) {
+	defer s.rlock()()
+	var escalations []*models.TaskEscalation
+	for _, e := range s.data.taskEscalations {
+		if e.TaskID == taskID {
+			escalation := *e
+			escalations = append(escalations, &escalation)
+		}
+	}
+	sort.Slice(escalations, func(i, j int) bool { return escalations[i].ID < escalations[j].ID })
+	return escalations, nil
+}
+
+func (s *MemoryStore) CreateTaskWatcher(ctx context.Context, taskID, eid int64) error {
+	defer s.lock()()
+	if _, ok := s.data.tasks[taskID]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if _, ok := s.data.employees[eid]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if containsID(s.data.taskWatcherIDs(taskID), eid) {
+		return ErrDuplicateEntry
+	}
+	s.data.taskWatcherSeq++
+	s.data.taskWatchers[s.data.taskWatcherSeq] = &models.TaskWatcher{
+		TaskID:     taskID,
+		EmployeeID: eid,
+		CreatedAt:  time.Now().UTC(),
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTaskWatcher(ctx context.Context, taskID, eid int64) (int64, error) {
+	defer s.lock()()
+	for k, w := range s.data.taskWatchers {
+		if w.TaskID == taskID && w.EmployeeID == eid {
+			delete(s.data.taskWatchers, k)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) FindTaskWatcherIDs(ctx context.Context, taskID int64) ([]int64, error) {
+	defer s.rlock()()
+	ids := s.data.taskWatcherIDs(taskID)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *MemoryStore) FindWatchedTasks(ctx context.Context, eid int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error) {
+	return s.findTasks(func(t *models.Task) bool {
+		return containsID(s.data.taskWatcherIDs(t.ID), eid) && matchTask(t, s.data.taskLabelIDs(t.ID), filter, opts)
+	}, opts)
+}
+
+func (s *MemoryStore) CountWatchedTasks(ctx context.Context, eid int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error) {
+	return s.countTasks(func(t *models.Task) bool {
+		return containsID(s.data.taskWatcherIDs(t.ID), eid) && matchTask(t, s.data.taskLabelIDs(t.ID), filter, opts)
+	}), nil
+}
+
+// taskWatcherIDs returns the ids of the watchers of the task, in no order.
+func (d *memoryData) taskWatcherIDs(taskID int64) []int64 {
+	var ids []int64
+	for _, w := range d.taskWatchers {
+		if w.TaskID == taskID {
+			ids = append(ids, w.EmployeeID)
+		}
+	}
+	return ids
+}
+
+func (s *MemoryStore) CreateTaskEvent(ctx context.Context, eid, changeID int64) error {
+	defer s.lock()()
+	if _, ok := s.data.employees[eid]; !ok {
+		return ErrForeignKeyViolation
+	}
+	c, ok := s.data.taskChanges[changeID]
+	if !ok {
+		return ErrForeignKeyViolation
+	}
+	s.data.taskEventSeq++
+	s.data.taskEvents[s.data.taskEventSeq] = &models.TaskEvent{
+		ID:         s.data.taskEventSeq,
+		EmployeeID: eid,
+		ChangeID:   c.ID,
+		TaskID:     c.TaskID,
+		Field:      c.Field,
+		OldValue:   c.OldValue,
+		NewValue:   c.NewValue,
+		ActorID:    c.ActorID,
+		CreatedAt:  c.CreatedAt,
+	}
+	return nil
+}
+
+func (s *MemoryStore) FindTaskEvents(ctx context.Context, eid int64, opts dto.ListOptions) ([]*models.TaskEvent, error) {
+	defer s.rlock()()
+	var events []*models.TaskEvent
+	for _, e := range s.data.taskEvents {
+		if e.EmployeeID == eid && e.ID > opts.AfterID {
+			event := *e
+			events = append(events, &event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return paginate(events, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountTaskEvents(ctx context.Context, eid int64) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, e := range s.data.taskEvents {
+		if e.EmployeeID == eid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) GetShift(ctx context.Context, id int64) (*models.Shift, error) {
+	defer s.rlock()()
+	sh, ok := s.data.shifts[id]
+	if !ok || sh.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	shift := *sh
+	return &shift, nil
+}
+
+func (s *MemoryStore) CreateShift(ctx context.Context, sh *dto.Shift) (*models.Shift, error) {
+	defer s.lock()()
+	if _, ok := s.data.hospitals[sh.HospitalID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	if _, ok := s.data.employees[sh.EmployeeID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.shiftSeq++
+	shift := &models.Shift{
+		ID:         s.data.shiftSeq,
+		HospitalID: sh.HospitalID,
+		EmployeeID: sh.EmployeeID,
+		StartsAt:   sh.StartsAt.UTC(),
+		EndsAt:     sh.EndsAt.UTC(),
+		Ward:       sh.Ward,
+		Version:    1,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	s.data.shifts[shift.ID] = shift
+	ret := *shift
+	return &ret, nil
+}
+
+func (s *MemoryStore) UpdateShift(ctx context.Context, sh *dto.Shift) (int64, error) {
+	defer s.lock()()
+	shift, ok := s.data.shifts[sh.ID]
+	if !ok || shift.DeletedAt != nil || (sh.Version > 0 && sh.Version != shift.Version) {
+		return 0, nil
+	}
+	if _, ok := s.data.employees[sh.EmployeeID]; !ok {
+		return 0, ErrForeignKeyViolation
+	}
+	shift.EmployeeID = sh.EmployeeID
+	shift.StartsAt = sh.StartsAt.UTC()
+	shift.EndsAt = sh.EndsAt.UTC()
+	shift.Ward = sh.Ward
+	shift.Version++
+	shift.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteShift(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	sh, ok := s.data.shifts[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&sh.DeletedAt, &sh.UpdatedAt), nil
+}
+
+func matchShift(sh *models.Shift, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) bool {
+	if sh.HospitalID != hid || (sh.DeletedAt != nil && !opts.IncludeDeleted) {
+		return false
+	}
+	if filter.EmployeeID > 0 && sh.EmployeeID != filter.EmployeeID {
+		return false
+	}
+	if filter.Ward != "" && sh.Ward != filter.Ward {
+		return false
+	}
+	if !filter.At.IsZero() && (sh.StartsAt.After(filter.At) || !sh.EndsAt.After(filter.At)) {
+		return false
+	}
+	return true
+}
+
+func (s *MemoryStore) FindShifts(ctx context.Context, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) ([]*models.Shift, error) {
+	defer s.rlock()()
+	var shifts []*models.Shift
+	for _, sh := range s.data.shifts {
+		if sh.ID > opts.AfterID && matchShift(sh, hid, filter, opts) {
+			shift := *sh
+			shifts = append(shifts, &shift)
+		}
+	}
+	sort.Slice(shifts, func(i, j int) bool { return shifts[i].ID < shifts[j].ID })
+	return paginate(shifts, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountShifts(ctx context.Context, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, sh := range s.data.shifts {
+		if matchShift(sh, hid, filter, opts) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) CountOverlappingShifts(ctx context.Context, eid int64, start, end time.Time, excludeID int64) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, sh := range s.data.shifts {
+		if sh.EmployeeID == eid && sh.ID != excludeID && sh.DeletedAt == nil && sh.StartsAt.Before(end) && sh.EndsAt.After(start) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) GetTaskTemplate(ctx context.Context, id int64) (*models.TaskTemplate, error) {
+	defer s.rlock()()
+	t, ok := s.data.taskTemplates[id]
+	if !ok || t.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	template := *t
+	return &template, nil
+}
+
+func (s *MemoryStore) CreateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (*models.TaskTemplate, error) {
+	defer s.lock()()
+	if _, ok := s.data.hospitals[t.HospitalID]; !ok {
+		return nil, ErrForeignKeyViolation
+	}
+	s.data.taskTemplateSeq++
+	template := &models.TaskTemplate{
+		ID:          s.data.taskTemplateSeq,
+		HospitalID:  t.HospitalID,
+		Title:       t.Title,
+		Description: t.Description,
+		Priority:    t.Priority,
+		Version:     1,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	s.data.taskTemplates[template.ID] = template
+	ret := *template
+	return &ret, nil
+}
+
+func (s *MemoryStore) UpdateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (int64, error) {
+	defer s.lock()()
+	template, ok := s.data.taskTemplates[t.ID]
+	if !ok || template.DeletedAt != nil || (t.Version > 0 && t.Version != template.Version) {
+		return 0, nil
+	}
+	template.Title = t.Title
+	template.Description = t.Description
+	template.Priority = t.Priority
+	template.Version++
+	template.UpdatedAt = time.Now().UTC()
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteTaskTemplate(ctx context.Context, id int64) (int64, error) {
+	defer s.lock()()
+	t, ok := s.data.taskTemplates[id]
+	if !ok {
+		return 0, nil
+	}
+	return softDelete(&t.DeletedAt, &t.UpdatedAt), nil
+}
+
+func (s *MemoryStore) FindTaskTemplates(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.TaskTemplate, error) {
+	defer s.rlock()()
+	var templates []*models.TaskTemplate
+	for _, t := range s.data.taskTemplates {
+		if t.HospitalID == hid && t.ID > opts.AfterID && (t.DeletedAt == nil || opts.IncludeDeleted) {
+			template := *t
+			templates = append(templates, &template)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return paginate(templates, opts.Offset, opts.Limit), nil
+}
+
+func (s *MemoryStore) CountTaskTemplates(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error) {
+	defer s.rlock()()
+	var count uint
+	for _, t := range s.data.taskTemplates {
+		if t.HospitalID == hid && (t.DeletedAt == nil || opts.IncludeDeleted) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) SetTaskTemplateLabels(ctx context.Context, id int64, labelIDs []int64) error {
+	defer s.lock()()
+	if _, ok := s.data.taskTemplates[id]; !ok {
+		return ErrForeignKeyViolation
+	}
+	for _, lid := range labelIDs {
+		if _, ok := s.data.labels[lid]; !ok {
+			return ErrForeignKeyViolation
+		}
+	}
+	for k, tl := range s.data.taskTemplateLabels {
+		if tl.TemplateID == id {
+			delete(s.data.taskTemplateLabels, k)
+		}
+	}
+	for _, lid := range uniqueIDs(labelIDs) {
+		s.data.taskTemplateLabelSeq++
+		s.data.taskTemplateLabels[s.data.taskTemplateLabelSeq] = &models.TaskTemplateLabel{TemplateID: id, LabelID: lid}
+	}
+	return nil
+}
+
+func (s *MemoryStore) FindTaskTemplateLabelIDs(ctx context.Context, ids []int64) (map[int64][]int64, error) {
+	defer s.rlock()()
+	labels := make(map[int64][]int64)
+	for _, tl := range s.data.taskTemplateLabels {
+		if containsID(ids, tl.TemplateID) {
+			labels[tl.TemplateID] = append(labels[tl.TemplateID], tl.LabelID)
+		}
+	}
+	for _, lids := range labels {
+		sort.Slice(lids, func(i, j int) bool { return lids[i] < lids[j] })
+	}
+	return labels, nil
+}
+
+func (s *MemoryStore) SetTaskTemplateItems(ctx context.Context, id int64, items []string) error {
+	defer s.lock()()
+	if _, ok := s.data.taskTemplates[id]; !ok {
+		return ErrForeignKeyViolation
+	}
+	for k, item := range s.data.taskTemplateItems {
+		if item.TemplateID == id {
+			delete(s.data.taskTemplateItems, k)
+		}
+	}
+	for i, title := range items {
+		s.data.taskTemplateItemSeq++
+		s.data.taskTemplateItems[s.data.taskTemplateItemSeq] = &models.TaskTemplateItem{
+			TemplateID: id,
+			Position:   i,
+			Title:      title,
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) FindTaskTemplateItems(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	defer s.rlock()()
+	var rows []*models.TaskTemplateItem
+	for _, item := range s.data.taskTemplateItems {
+		if containsID(ids, item.TemplateID) {
+			rows = append(rows, item)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Position < rows[j].Position })
+	items := make(map[int64][]string)
+	for _, item := range rows {
+		items[item.TemplateID] = append(items[item.TemplateID], item.Title)
+	}
+	return items, nil
+}
+
+func paginate[T any](items []T, offset, limit uint) []T {
+	if offset >= uint(len(items)) {
+		return nil
+	}
+	items = items[offset:]
+	if limit < uint(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
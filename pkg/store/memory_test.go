@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "mem"})
+	assert.NoError(t, err)
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := store.GetHospital(ctx, hospital.ID+1)
+		assert.True(t, IsErrNotFound(err))
+		_, err = store.GetEmployee(ctx, 1)
+		assert.True(t, IsErrNotFound(err))
+		_, err = store.GetTask(ctx, 1)
+		assert.True(t, IsErrNotFound(err))
+	})
+
+	t.Run("DuplicateEntry", func(t *testing.T) {
+		_, err := store.CreateHospital(ctx, &dto.Hospital{Name: hospital.Name})
+		assert.True(t, IsErrDuplicateEntry(err))
+
+		other, err := store.CreateHospital(ctx, &dto.Hospital{Name: "mem-other"})
+		assert.NoError(t, err)
+		_, err = store.UpdateHospital(ctx, &dto.Hospital{ID: other.ID, Name: hospital.Name})
+		assert.True(t, IsErrDuplicateEntry(err))
+
+		_, err = store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "dup"})
+		assert.NoError(t, err)
+		_, err = store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "dup"})
+		assert.True(t, IsErrDuplicateEntry(err))
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		h, err := store.GetHospital(ctx, hospital.ID)
+		assert.NoError(t, err)
+		h.Name = "changed"
+
+		h, err = store.GetHospital(ctx, hospital.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, hospital.Name, h.Name)
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		employee, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "deleted"})
+		assert.NoError(t, err)
+
+		n, err := store.DeleteEmployee(ctx, employee.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.GetEmployee(ctx, employee.ID)
+		assert.True(t, IsErrNotFound(err))
+
+		total, err := store.CountEmployees(ctx, hospital.ID, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), total)
+		total, err = store.CountEmployees(ctx, hospital.ID, dto.ListOptions{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+
+		n, err = store.RestoreEmployee(ctx, employee.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.DeleteEmployee(ctx, employee.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	})
+
+	t.Run("WithTx", func(t *testing.T) {
+		errRollback := errors.New("rollback")
+		err := store.WithTx(ctx, func(tx Store) error {
+			_, err := tx.CreateHospital(ctx, &dto.Hospital{Name: "mem-tx"})
+			assert.NoError(t, err)
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+		total, err := store.CountHosptials(ctx, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), total)
+
+		err = store.WithTx(ctx, func(tx Store) error {
+			_, err := tx.CreateHospital(ctx, &dto.Hospital{Name: "mem-tx"})
+			return err
+		})
+		assert.NoError(t, err)
+		total, err = store.CountHosptials(ctx, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), total)
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := store.CreateEmployee(ctx, &dto.Employee{
+					HospitalID: hospital.ID,
+					Username:   fmt.Sprintf("user-%d", i),
+				})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		total, err := store.CountEmployees(ctx, hospital.ID, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(51), total)
+
+		employees, err := store.FindEmployees(ctx, hospital.ID, dto.ListOptions{Offset: 0, Limit: 100})
+		assert.NoError(t, err)
+		assert.Len(t, employees, 51)
+		for i := 1; i < len(employees); i++ {
+			assert.Less(t, employees[i-1].ID, employees[i].ID)
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// The schema version table has the same name and layout as golang-migrate's,
+// so databases migrated by scripts/migrate keep working.
+const (
+	migrationTable = "schema_migrations"
+	migrationLock  = "boxpractice-migrate"
+	// migrationLockKey is the pg_advisory_lock key, it is the crc32 of migrationLock.
+	migrationLockKey = 3621101178
+	migrationTimeout = 5 * time.Minute
+)
+
+var migrationFileRe = regexp.MustCompile(`^([0-9]+)_(.*)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes the schema version of a database.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Applied []*Migration
+	Pending []*Migration
+}
+
+// Migrator applies the migrations found in a fs.FS. Only one Migrator can
+// run at a time against a database: the others block on an advisory lock
+// until it is done.
+type Migrator struct {
+	store      *SQLStore
+	migrations []*Migration
+}
+
+// NewMigrator loads the migrations of the store's dialect from fsys. The
+// MySQL migrations are expected at the root of fsys, the SQLite and
+// PostgreSQL ones in the sqlite and postgres directories.
+func NewMigrator(s *SQLStore, fsys fs.FS) (*Migrator, error) {
+	dir := "."
+	switch s.dialect {
+	case DialectSQLite:
+		dir = "sqlite"
+	case DialectPostgres:
+		dir = "postgres"
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := migrationFileRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: m[2]}
+			byVersion[uint(version)] = migration
+		}
+		if m[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return &Migrator{store: s, migrations: migrations}, nil
+}
+
+// Up applies all the pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("database is dirty at version %d, fix it and force a version", version)
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration.Version, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last n applied migrations, all of them if n <= 0.
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("database is dirty at version %d, fix it and force a version", version)
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			var prev uint
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, conn, migration.Version, migration.Down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			if n--; n == 0 {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// Force sets the schema version without running any migration and clears
+// the dirty flag. It is the way out after a migration failed halfway.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		return m.setVersion(ctx, conn, version, false)
+	})
+}
+
+// Status reports the current schema version and the pending migrations.
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	conn, err := m.store.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	version, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	status := &MigrationStatus{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// apply runs the statements of a migration. The version is marked dirty
+// while it runs so that a failure halfway can't go unnoticed: MySQL can't
+// roll back DDL.
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, version uint, script string, target uint) error {
+	if err := m.setVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return m.setVersion(ctx, conn, target, false)
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, migrationTimeout)
+	defer cancel()
+	// The advisory locks belong to a session, so everything has to run on
+	// the same connection.
+	conn, err := m.store.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch m.store.dialect {
+	case DialectMySQL:
+		var ok int
+		if err := conn.GetContext(ctx, &ok, "SELECT GET_LOCK(?, ?)", migrationLock, int(migrationTimeout.Seconds())); err != nil {
+			return err
+		}
+		if ok != 1 {
+			return fmt.Errorf("failed to acquire the migration lock")
+		}
+		defer func() {
+			if _, uErr := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLock); uErr != nil && err == nil {
+				err = uErr
+			}
+		}()
+	case DialectPostgres:
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+			return err
+		}
+		defer func() {
+			if _, uErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); uErr != nil && err == nil {
+				err = uErr
+			}
+		}()
+	case DialectSQLite:
+		// SQLite has no advisory locks. A SQLite database is only ever used
+		// by a single process, which already runs everything on one
+		// connection.
+	}
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sqlx.Conn) error {
+	sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)", migrationTable)
+	_, err := conn.ExecContext(ctx, sql)
+	return err
+}
+
+func (m *Migrator) version(ctx context.Context, conn *sqlx.Conn) (uint, bool, error) {
+	var rows []struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	if err := conn.SelectContext(ctx, &rows, fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", migrationTable)); err != nil {
+		return 0, false, err
+	}
+	if len(rows) == 0 || rows[0].Version < 0 {
+		return 0, false, nil
+	}
+	return uint(rows[0].Version), rows[0].Dirty, nil
+}
+
+func (m *Migrator) setVersion(ctx context.Context, conn *sqlx.Conn, version uint, dirty bool) error {
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", migrationTable)); err != nil {
+		return err
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	sql := m.store.db.Rebind(fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES (?, ?)", migrationTable))
+	_, err := conn.ExecContext(ctx, sql, version, dirty)
+	return err
+}
+
+// splitStatements splits a migration script into single statements, since
+// the MySQL driver refuses to run several of them at once. Statements are
+// expected to end with a semicolon at the end of a line.
+func splitStatements(script string) []string {
+	var stmts []string
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if b.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		b.WriteString(line)
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(b.String()))
+			b.Reset()
+		}
+	}
+	if s := strings.TrimSpace(b.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liuerfire/boxpractice/database/migrations"
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := OpenSQLStore("sqlite://" + filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	migrator, err := NewMigrator(s, migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrator.migrations)
+	latest := migrator.migrations[len(migrator.migrations)-1].Version
+
+	t.Run("Up", func(t *testing.T) {
+		status, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), status.Version)
+		assert.Len(t, status.Pending, len(migrator.migrations))
+
+		assert.NoError(t, migrator.Up(ctx))
+		// Applying again is a no-op.
+		assert.NoError(t, migrator.Up(ctx))
+
+		status, err = migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, latest, status.Version)
+		assert.False(t, status.Dirty)
+		assert.Empty(t, status.Pending)
+
+		_, err = s.CreateHospital(ctx, &dto.Hospital{Name: "migrated"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		assert.NoError(t, migrator.Down(ctx, 0))
+
+		status, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), status.Version)
+
+		_, err = s.CreateHospital(ctx, &dto.Hospital{Name: "migrated"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		assert.NoError(t, migrator.Force(ctx, latest))
+
+		status, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, latest, status.Version)
+		assert.False(t, status.Dirty)
+	})
+}
+
+func TestMigratorOrphans(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := OpenSQLStore("sqlite://" + filepath.Join(t.TempDir(), "orphans.db"))
+	require.NoError(t, err)
+	defer s.Close()
+
+	migrator, err := NewMigrator(s, migrations.FS)
+	require.NoError(t, err)
+
+	// Go back to the schema right before the foreign keys, and seed it with
+	// the rows they would reject.
+	require.NoError(t, migrator.Up(ctx))
+	var n int
+	for _, m := range migrator.migrations {
+		if m.Version > 3 {
+			n++
+		}
+	}
+	require.NoError(t, migrator.Down(ctx, n))
+	for _, stmt := range []string{
+		"INSERT INTO hospital (id, name) VALUES (1, 'h1')",
+		"INSERT INTO employee (id, hospital_id, username) VALUES (1, 1, 'e1')",
+		"INSERT INTO employee (id, hospital_id, username) VALUES (2, 2, 'orphan')",
+		"INSERT INTO task (id, hospital_id, owner_id, title, description, priority, status) VALUES (1, 1, 1, 't1', '', 'low', 'open')",
+		"INSERT INTO task (id, hospital_id, owner_id, title, description, priority, status) VALUES (2, 1, 3, 'no owner', '', 'low', 'open')",
+		"INSERT INTO task (id, hospital_id, owner_id, title, description, priority, status) VALUES (3, 2, 1, 'no hospital', '', 'low', 'open')",
+	} {
+		_, err := s.db.ExecContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, migrator.Up(ctx))
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Dirty)
+	assert.Empty(t, status.Pending)
+
+	_, err = s.GetEmployee(ctx, 1)
+	assert.NoError(t, err)
+	// The orphans are kept, soft-deleted along a deleted placeholder of their
+	// hospital.
+	_, err = s.GetEmployee(ctx, 2)
+	assert.True(t, IsErrNotFound(err))
+	employee, err := s.GetDeletedEmployee(ctx, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), employee.HospitalID)
+	}
+	hospital, err := s.GetDeletedHospital(ctx, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "deleted-2", hospital.Name)
+	}
+
+	task, err := s.GetTask(ctx, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), *task.OwnerID)
+	}
+	task, err = s.GetTask(ctx, 2)
+	if assert.NoError(t, err) {
+		assert.Nil(t, task.OwnerID)
+	}
+	_, err = s.GetTask(ctx, 3)
+	assert.True(t, IsErrNotFound(err))
+	var deleted bool
+	require.NoError(t, s.db.GetContext(ctx, &deleted, "SELECT deleted_at IS NOT NULL FROM task WHERE id = 3"))
+	assert.True(t, deleted)
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- a comment
+CREATE TABLE a (
+  id int -- the id; not the end
+);
+
+CREATE TABLE b (id int);
+DROP TABLE c`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (\n  id int -- the id; not the end\n);",
+		"CREATE TABLE b (id int);",
+		"DROP TABLE c",
+	}, splitStatements(script))
+}
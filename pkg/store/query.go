@@ -0,0 +1,394 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+// ErrInvalidCursor is returned when the key of a cursor doesn't match the
+// sort fields of the list, e.g. because they were changed between pages.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// query builds a where clause out of conditions written with ? placeholders.
+// The conditions and the columns are always SQL written in this package,
+// whatever comes from the request only ever goes in the args.
+type query struct {
+	conds []string
+	args  []any
+}
+
+func (q *query) where(cond string, args ...any) {
+	q.conds = append(q.conds, cond)
+	q.args = append(q.args, args...)
+}
+
+// in adds a "column in (values)" condition, unless values is empty.
+func (q *query) in(column string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	marks, args := inArgs(values)
+	q.where(column+" in ("+marks+")", args...)
+}
+
+// String returns the where clause, with a leading space.
+func (q *query) String() string {
+	if len(q.conds) == 0 {
+		return ""
+	}
+	return " where " + strings.Join(q.conds, " and ")
+}
+
+// taskQuery returns the query selecting the tasks matching filter.
+func taskQuery(filter dto.TaskFilter, opts dto.ListOptions) *query {
+	q := &query{}
+	q.in("status", filter.Statuses)
+	q.in("priority", filter.Priorities)
+	if filter.OwnerID > 0 {
+		q.where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.Unassigned {
+		q.where("owner_id is null")
+	}
+	if !filter.CreatedAfter.IsZero() {
+		q.where("created_at > ?", filter.CreatedAfter.UTC())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		q.where("created_at < ?", filter.CreatedBefore.UTC())
+	}
+	if !filter.DueAfter.IsZero() {
+		q.where("due_at > ?", filter.DueAfter.UTC())
+	}
+	if !filter.DueBefore.IsZero() {
+		q.where("due_at < ?", filter.DueBefore.UTC())
+	}
+	if filter.Overdue {
+		marks, args := inArgs(models.TaskClosedStatuses)
+		q.where("overdue = ? and status not in ("+marks+")", append([]any{true}, args...)...)
+	}
+	if filter.Breached {
+		q.where("breached = ? and status = ?", true, models.TaskStatusOpen)
+	}
+	if labelIDs := uniqueIDs(filter.LabelIDs); len(labelIDs) > 0 {
+		marks, args := inArgs(labelIDs)
+		sub := "select task_id from task_label where label_id in (" + marks + ")"
+		if filter.LabelMatch == dto.LabelMatchAll {
+			sub += " group by task_id having count(1) = ?"
+			args = append(args, len(labelIDs))
+		}
+		q.where("id in ("+sub+")", args...)
+	}
+	if !opts.IncludeDeleted {
+		q.where("deleted_at is null")
+	}
+	return q
+}
+
+// matchTask is taskQuery for the MemoryStore. labelIDs are the labels of t.
+func matchTask(t *models.Task, labelIDs []int64, filter dto.TaskFilter, opts dto.ListOptions) bool {
+	if len(filter.Statuses) > 0 && !contains(filter.Statuses, t.Status) {
+		return false
+	}
+	if len(filter.Priorities) > 0 && !contains(filter.Priorities, t.Priority) {
+		return false
+	}
+	if filter.OwnerID > 0 && !isOwnedBy(t, filter.OwnerID) {
+		return false
+	}
+	if filter.Unassigned && t.OwnerID != nil {
+		return false
+	}
+	if !filter.CreatedAfter.IsZero() && !t.CreatedAt.After(filter.CreatedAfter) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !t.CreatedAt.Before(filter.CreatedBefore) {
+		return false
+	}
+	if !filter.DueAfter.IsZero() && (t.DueAt == nil || !t.DueAt.After(filter.DueAfter)) {
+		return false
+	}
+	if !filter.DueBefore.IsZero() && (t.DueAt == nil || !t.DueAt.Before(filter.DueBefore)) {
+		return false
+	}
+	if filter.Overdue && (!t.Overdue || models.IsTaskClosed(t.Status)) {
+		return false
+	}
+	if filter.Breached && (!t.Breached || t.Status != models.TaskStatusOpen) {
+		return false
+	}
+	if wanted := uniqueIDs(filter.LabelIDs); len(wanted) > 0 {
+		var n int
+		for _, id := range wanted {
+			if containsID(labelIDs, id) {
+				n++
+			}
+		}
+		if n == 0 || (filter.LabelMatch == dto.LabelMatchAll && n < len(wanted)) {
+			return false
+		}
+	}
+	return t.DeletedAt == nil || opts.IncludeDeleted
+}
+
+// uniqueIDs returns ids without the duplicates, in the same order.
+func uniqueIDs(ids []int64) []int64 {
+	var unique []int64
+	for _, id := range ids {
+		if !containsID(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, elem := range ids {
+		if elem == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isOwnedBy reports whether the employee oid owns the task t.
+func isOwnedBy(t *models.Task, oid int64) bool {
+	return t.OwnerID != nil && *t.OwnerID == oid
+}
+
+func contains(values []string, v string) bool {
+	for _, elem := range values {
+		if elem == v {
+			return true
+		}
+	}
+	return false
+}
+
+// taskSortField is a field the tasks can be sorted by. value returns an
+// int64, a string or a time.Time.
+type taskSortField struct {
+	expr  string
+	value func(t *models.Task) any
+}
+
+var taskSortFields = map[string]taskSortField{
+	"id": {
+		expr:  "id",
+		value: func(t *models.Task) any { return t.ID },
+	},
+	"createdAt": {
+		expr:  "created_at",
+		value: func(t *models.Task) any { return t.CreatedAt.UTC() },
+	},
+	"priority": {
+		expr: fmt.Sprintf("case priority when '%s' then %d when '%s' then %d else %d end",
+			models.TaskPriorityUrgent, models.TaskPriorityRank(models.TaskPriorityUrgent),
+			models.TaskPriorityHight, models.TaskPriorityRank(models.TaskPriorityHight),
+			models.TaskPriorityRank(models.TaskPriorityLow)),
+		value: func(t *models.Task) any { return models.TaskPriorityRank(t.Priority) },
+	},
+	"status": {
+		expr:  "status",
+		value: func(t *models.Task) any { return t.Status },
+	},
+}
+
+// IsTaskSortField reports whether the tasks can be sorted by name.
+func IsTaskSortField(name string) bool {
+	_, ok := taskSortFields[name]
+	return ok
+}
+
+type taskOrderField struct {
+	taskSortField
+	id   bool
+	desc bool
+}
+
+// taskOrder returns the fields the tasks are ordered by, which always end
+// with the id.
+func taskOrder(fields []dto.SortField) ([]taskOrderField, error) {
+	var order []taskOrderField
+	for _, f := range fields {
+		field, ok := taskSortFields[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field: %s", f.Name)
+		}
+		order = append(order, taskOrderField{taskSortField: field, id: f.Name == "id", desc: f.Desc})
+		if f.Name == "id" {
+			// The ids are unique, the next fields would never be compared.
+			return order, nil
+		}
+	}
+	return append(order, taskOrderField{taskSortField: taskSortFields["id"], id: true}), nil
+}
+
+// TaskCursorKey returns the key of the cursor of the page starting after t.
+func TaskCursorKey(t *models.Task, fields []dto.SortField) []string {
+	order, err := taskOrder(fields)
+	if err != nil {
+		return nil
+	}
+	var key []string
+	for _, f := range order {
+		if f.id {
+			continue
+		}
+		switch v := f.value(t).(type) {
+		case int64:
+			key = append(key, strconv.FormatInt(v, 10))
+		case string:
+			key = append(key, v)
+		case time.Time:
+			key = append(key, v.Format(time.RFC3339Nano))
+		}
+	}
+	return key
+}
+
+// afterValues returns the values of the order fields of the item the page
+// opts asks for starts after, or nil if it starts at the beginning.
+func afterValues(order []taskOrderField, opts dto.ListOptions) ([]any, error) {
+	if opts.AfterID <= 0 {
+		return nil, nil
+	}
+	if len(opts.AfterKey) != len(order)-1 {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]any, len(order))
+	key := opts.AfterKey
+	for i, f := range order {
+		if f.id {
+			values[i] = opts.AfterID
+			continue
+		}
+		var err error
+		switch f.value(&models.Task{}).(type) {
+		case int64:
+			values[i], err = strconv.ParseInt(key[0], 10, 64)
+		case string:
+			values[i] = key[0]
+		case time.Time:
+			values[i], err = time.Parse(time.RFC3339Nano, key[0])
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		key = key[1:]
+	}
+	return values, nil
+}
+
+// taskPage adds the keyset condition of the page opts asks for to q, and
+// returns the end of the query with its args.
+func taskPage(q *query, opts dto.ListOptions) (string, []any, error) {
+	order, err := taskOrder(opts.Sort)
+	if err != nil {
+		return "", nil, err
+	}
+	values, err := afterValues(order, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if values != nil {
+		// (a > ?) or (a = ? and b > ?) or ...
+		var ors []string
+		var args []any
+		for i, f := range order {
+			var ands []string
+			for j := 0; j < i; j++ {
+				ands = append(ands, order[j].expr+" = ?")
+				args = append(args, values[j])
+			}
+			op := " > ?"
+			if f.desc {
+				op = " < ?"
+			}
+			ands = append(ands, f.expr+op)
+			args = append(args, values[i])
+			ors = append(ors, "("+strings.Join(ands, " and ")+")")
+		}
+		q.where("("+strings.Join(ors, " or ")+")", args...)
+	}
+	var orderBy []string
+	for _, f := range order {
+		if f.desc {
+			orderBy = append(orderBy, f.expr+" desc")
+		} else {
+			orderBy = append(orderBy, f.expr)
+		}
+	}
+	return " order by " + strings.Join(orderBy, ", ") + " limit ? offset ?", []any{opts.Limit, opts.Offset}, nil
+}
+
+// sortTasks is taskPage for the MemoryStore. It sorts the tasks and drops
+// the ones before the page.
+func sortTasks(tasks []*models.Task, opts dto.ListOptions) ([]*models.Task, error) {
+	order, err := taskOrder(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	values, err := afterValues(order, opts)
+	if err != nil {
+		return nil, err
+	}
+	compare := func(t *models.Task, values []any) int {
+		for i, f := range order {
+			c := compareValues(f.value(t), values[i])
+			if f.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	taskValues := func(t *models.Task) []any {
+		values := make([]any, len(order))
+		for i, f := range order {
+			values[i] = f.value(t)
+		}
+		return values
+	}
+	if values != nil {
+		var after []*models.Task
+		for _, t := range tasks {
+			if compare(t, values) > 0 {
+				after = append(after, t)
+			}
+		}
+		tasks = after
+	}
+	sort.Slice(tasks, func(i, j int) bool { return compare(tasks[i], taskValues(tasks[j])) < 0 })
+	return tasks, nil
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		b := b.(time.Time)
+		if a.Before(b) {
+			return -1
+		} else if a.After(b) {
+			return 1
+		}
+	}
+	return 0
+}
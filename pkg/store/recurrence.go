@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+const taskRecurrenceColumns = "id, hospital_id, title, description, priority, due_in, schedule, next_at, occurrences, version, created_at, updated_at, deleted_at"
+
+func (s *SQLStore) GetTaskRecurrence(ctx context.Context, id int64) (*models.TaskRecurrence, error) {
+	var r models.TaskRecurrence
+	sql := "select " + taskRecurrenceColumns + " from task_recurrence where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &r, sql, id)
+	return &r, err
+}
+
+func (s *SQLStore) CreateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (*models.TaskRecurrence, error) {
+	recurrence := &models.TaskRecurrence{
+		HospitalID:  r.HospitalID,
+		Title:       r.Title,
+		Description: r.Description,
+		Priority:    r.Priority,
+		DueIn:       r.DueIn,
+		Schedule:    r.Schedule,
+		NextAt:      r.NextAt.UTC(),
+		Version:     1,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	sql := "insert into task_recurrence (hospital_id, title, description, priority, due_in, schedule, next_at, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, recurrence.HospitalID, recurrence.Title, recurrence.Description, recurrence.Priority,
+		recurrence.DueIn, recurrence.Schedule, recurrence.NextAt, recurrence.Version, recurrence.CreatedAt, recurrence.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	recurrence.ID = id
+	return recurrence, nil
+}
+
+// UpdateTaskRecurrence updates the template and the schedule of the
+// recurrence and bumps its version. If r.Version is set, the recurrence is
+// only updated if it is still at that version.
+func (s *SQLStore) UpdateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (int64, error) {
+	sql := "update task_recurrence set title=?, description=?, priority=?, due_in=?, schedule=?, next_at=?, version=version+1, updated_at=? where id = ? and deleted_at is null"
+	args := []any{r.Title, r.Description, r.Priority, r.DueIn, r.Schedule, r.NextAt.UTC(), time.Now().UTC(), r.ID}
+	if r.Version > 0 {
+		sql += " and version = ?"
+		args = append(args, r.Version)
+	}
+	res, err := s.execContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *SQLStore) DeleteTaskRecurrence(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "task_recurrence", "id = ?", id)
+}
+
+func (s *SQLStore) FindTaskRecurrences(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.TaskRecurrence, error) {
+	var recurrences []*models.TaskRecurrence
+	cond, args := page(opts)
+	sql := "select " + taskRecurrenceColumns + " from task_recurrence where hospital_id = ?" + notDeleted(opts) + cond
+	if err := s.selectContext(ctx, &recurrences, sql, append([]any{hid}, args...)...); err != nil {
+		return nil, err
+	}
+	return recurrences, nil
+}
+
+func (s *SQLStore) CountTaskRecurrences(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error) {
+	var count uint
+	sql := "select count(1) from task_recurrence where hospital_id = ?" + notDeleted(opts)
+	if err := s.getContext(ctx, &count, sql, hid); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// SetTaskRecurrenceOwners replaces the owner rotation of the recurrence, so
+// it has to run in a transaction.
+func (s *SQLStore) SetTaskRecurrenceOwners(ctx context.Context, id int64, ownerIDs []int64) error {
+	if _, err := s.execContext(ctx, "delete from task_recurrence_owner where recurrence_id = ?", id); err != nil {
+		return err
+	}
+	for i, oid := range ownerIDs {
+		sql := "insert into task_recurrence_owner (recurrence_id, position, employee_id) VALUES (?, ?, ?)"
+		if _, err := s.execContext(ctx, sql, id, i, oid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) FindTaskRecurrenceOwners(ctx context.Context, ids []int64) (map[int64][]int64, error) {
+	owners := make(map[int64][]int64)
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return owners, nil
+	}
+	var rows []*models.TaskRecurrenceOwner
+	marks, args := inArgs(ids)
+	sql := "select recurrence_id, position, employee_id from task_recurrence_owner where recurrence_id in (" + marks + ") order by recurrence_id, position"
+	if err := s.selectContext(ctx, &rows, sql, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		owners[row.RecurrenceID] = append(owners[row.RecurrenceID], row.EmployeeID)
+	}
+	return owners, nil
+}
+
+func (s *SQLStore) FindDueTaskRecurrenceIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	sql := "select id from task_recurrence where next_at <= ? and deleted_at is null" +
+		" and hospital_id in (select id from hospital where deleted_at is null) order by next_at, id limit ?"
+	if err := s.selectContext(ctx, &ids, sql, now.UTC(), limit); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SQLStore) AdvanceTaskRecurrence(ctx context.Context, id, occurrences int64, nextAt time.Time) (int64, error) {
+	sql := "update task_recurrence set next_at=?, occurrences=occurrences+1 where id = ? and occurrences = ? and deleted_at is null"
+	r, err := s.execContext(ctx, sql, nextAt.UTC(), id, occurrences)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
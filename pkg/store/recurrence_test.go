@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestTaskRecurrence(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "recurrence_hospital", Timezone: "Asia/Tokyo"})
+	assert.NoError(t, err)
+	h, err := store.GetHospital(ctx, hospital.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", h.Timezone)
+	alice, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "rotation_alice"})
+	assert.NoError(t, err)
+	bob, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "rotation_bob"})
+	assert.NoError(t, err)
+
+	nextAt := time.Date(2022, 3, 1, 8, 0, 0, 0, time.UTC)
+	var recurrence *models.TaskRecurrence
+
+	t.Run("CreateTaskRecurrence", func(t *testing.T) {
+		recurrence, err = store.CreateTaskRecurrence(ctx, &dto.TaskRecurrence{
+			HospitalID: hospital.ID,
+			Title:      "medication round",
+			Priority:   models.TaskPriorityHight,
+			DueIn:      3600,
+			Schedule:   "0 8,20 * * *",
+			NextAt:     nextAt,
+		})
+		assert.NoError(t, err)
+		assert.Greater(t, recurrence.ID, int64(0))
+
+		got, err := store.GetTaskRecurrence(ctx, recurrence.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "0 8,20 * * *", got.Schedule)
+		assert.Equal(t, int64(3600), got.DueIn)
+		assert.True(t, nextAt.Equal(got.NextAt))
+
+		_, err = store.CreateTaskRecurrence(ctx, &dto.TaskRecurrence{HospitalID: hospital.ID + 100, Title: "x", Priority: models.TaskPriorityLow, Schedule: "@daily", NextAt: nextAt})
+		assert.True(t, IsErrForeignKeyViolation(err))
+	})
+
+	t.Run("Owners", func(t *testing.T) {
+		assert.NoError(t, store.SetTaskRecurrenceOwners(ctx, recurrence.ID, []int64{bob.ID, alice.ID}))
+		owners, err := store.FindTaskRecurrenceOwners(ctx, []int64{recurrence.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{bob.ID, alice.ID}, owners[recurrence.ID])
+
+		assert.NoError(t, store.SetTaskRecurrenceOwners(ctx, recurrence.ID, []int64{alice.ID, bob.ID}))
+		owners, err = store.FindTaskRecurrenceOwners(ctx, []int64{recurrence.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{alice.ID, bob.ID}, owners[recurrence.ID])
+
+		err = store.SetTaskRecurrenceOwners(ctx, recurrence.ID, []int64{bob.ID + 100})
+		assert.True(t, IsErrForeignKeyViolation(err))
+	})
+
+	t.Run("Due", func(t *testing.T) {
+		ids, err := store.FindDueTaskRecurrenceIDs(ctx, nextAt.Add(-time.Minute), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+		ids, err = store.FindDueTaskRecurrenceIDs(ctx, nextAt, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{recurrence.ID}, ids)
+
+		// The occurrences of a recurrence are unique.
+		occurrenceAt := nextAt
+		task := &dto.Task{
+			HospitalID:   hospital.ID,
+			OwnerID:      alice.ID,
+			RecurrenceID: recurrence.ID,
+			OccurrenceAt: &occurrenceAt,
+			Title:        recurrence.Title,
+			Priority:     recurrence.Priority,
+			Status:       models.TaskStatusOpen,
+		}
+		created, err := store.CreateTask(ctx, task)
+		assert.NoError(t, err)
+		got, err := store.GetTask(ctx, created.ID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, got.RecurrenceID) && assert.NotNil(t, got.OccurrenceAt) {
+			assert.Equal(t, recurrence.ID, *got.RecurrenceID)
+			assert.True(t, nextAt.Equal(*got.OccurrenceAt))
+		}
+		_, err = store.CreateTask(ctx, task)
+		assert.True(t, IsErrDuplicateEntry(err))
+
+		n, err := store.AdvanceTaskRecurrence(ctx, recurrence.ID, 0, nextAt.Add(12*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.AdvanceTaskRecurrence(ctx, recurrence.ID, 0, nextAt.Add(24*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+		r, err := store.GetTaskRecurrence(ctx, recurrence.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r.Occurrences)
+		assert.True(t, nextAt.Add(12*time.Hour).Equal(r.NextAt))
+
+		_, err = store.DeleteHospital(ctx, hospital.ID)
+		assert.NoError(t, err)
+		ids, err = store.FindDueTaskRecurrenceIDs(ctx, nextAt.Add(24*time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+		_, err = store.RestoreHospital(ctx, hospital.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("UpdateTaskRecurrence", func(t *testing.T) {
+		n, err := store.UpdateTaskRecurrence(ctx, &dto.TaskRecurrence{
+			ID:       recurrence.ID,
+			Title:    "equipment check",
+			Priority: models.TaskPriorityLow,
+			Schedule: "@daily",
+			NextAt:   nextAt,
+			Version:  recurrence.Version + 1,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+		n, err = store.UpdateTaskRecurrence(ctx, &dto.TaskRecurrence{
+			ID:       recurrence.ID,
+			Title:    "equipment check",
+			Priority: models.TaskPriorityLow,
+			Schedule: "@daily",
+			NextAt:   nextAt,
+			Version:  recurrence.Version,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		recurrences, err := store.FindTaskRecurrences(ctx, hospital.ID, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, recurrences, 1) {
+			assert.Equal(t, "equipment check", recurrences[0].Title)
+			assert.Equal(t, recurrence.Version+1, recurrences[0].Version)
+		}
+
+		r, err := store.DeleteTaskRecurrence(ctx, recurrence.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r)
+		_, err = store.GetTaskRecurrence(ctx, recurrence.ID)
+		assert.True(t, IsErrNotFound(err))
+		total, err := store.CountTaskRecurrences(ctx, hospital.ID, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), total)
+		total, err = store.CountTaskRecurrences(ctx, hospital.ID, dto.ListOptions{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), total)
+	})
+}
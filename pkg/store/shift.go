@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+const shiftColumns = "id, hospital_id, employee_id, starts_at, ends_at, ward, version, created_at, updated_at, deleted_at"
+
+func (s *SQLStore) GetShift(ctx context.Context, id int64) (*models.Shift, error) {
+	var shift models.Shift
+	sql := "select " + shiftColumns + " from shift where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &shift, sql, id)
+	return &shift, err
+}
+
+func (s *SQLStore) CreateShift(ctx context.Context, sh *dto.Shift) (*models.Shift, error) {
+	shift := &models.Shift{
+		HospitalID: sh.HospitalID,
+		EmployeeID: sh.EmployeeID,
+		StartsAt:   sh.StartsAt.UTC(),
+		EndsAt:     sh.EndsAt.UTC(),
+		Ward:       sh.Ward,
+		Version:    1,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+	sql := "insert into shift (hospital_id, employee_id, starts_at, ends_at, ward, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, shift.HospitalID, shift.EmployeeID, shift.StartsAt, shift.EndsAt, shift.Ward,
+		shift.Version, shift.CreatedAt, shift.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	shift.ID = id
+	return shift, nil
+}
+
+// UpdateShift updates the employee, the times and the ward of the shift and
+// bumps its version. If sh.Version is set, the shift is only updated if it
+// is still at that version.
+func (s *SQLStore) UpdateShift(ctx context.Context, sh *dto.Shift) (int64, error) {
+	sql := "update shift set employee_id=?, starts_at=?, ends_at=?, ward=?, version=version+1, updated_at=? where id = ? and deleted_at is null"
+	args := []any{sh.EmployeeID, sh.StartsAt.UTC(), sh.EndsAt.UTC(), sh.Ward, time.Now().UTC(), sh.ID}
+	if sh.Version > 0 {
+		sql += " and version = ?"
+		args = append(args, sh.Version)
+	}
+	r, err := s.execContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) DeleteShift(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "shift", "id = ?", id)
+}
+
+func shiftQuery(hid int64, filter dto.ShiftFilter, opts dto.ListOptions) (string, []any) {
+	where := " where hospital_id = ?" + notDeleted(opts)
+	args := []any{hid}
+	if filter.EmployeeID > 0 {
+		where += " and employee_id = ?"
+		args = append(args, filter.EmployeeID)
+	}
+	if filter.Ward != "" {
+		where += " and ward = ?"
+		args = append(args, filter.Ward)
+	}
+	if !filter.At.IsZero() {
+		where += " and starts_at <= ? and ends_at > ?"
+		args = append(args, filter.At.UTC(), filter.At.UTC())
+	}
+	return where, args
+}
+
+func (s *SQLStore) FindShifts(ctx context.Context, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) ([]*models.Shift, error) {
+	var shifts []*models.Shift
+	where, args := shiftQuery(hid, filter, opts)
+	cond, pageArgs := page(opts)
+	sql := "select " + shiftColumns + " from shift" + where + cond
+	if err := s.selectContext(ctx, &shifts, sql, append(args, pageArgs...)...); err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+func (s *SQLStore) CountShifts(ctx context.Context, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) (uint, error) {
+	var count uint
+	where, args := shiftQuery(hid, filter, opts)
+	if err := s.getContext(ctx, &count, "select count(1) from shift"+where, args...); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *SQLStore) CountOverlappingShifts(ctx context.Context, eid int64, start, end time.Time, excludeID int64) (uint, error) {
+	var count uint
+	sql := "select count(1) from shift where employee_id = ? and starts_at < ? and ends_at > ? and id <> ? and deleted_at is null"
+	if err := s.getContext(ctx, &count, sql, eid, end.UTC(), start.UTC(), excludeID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestShift(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "shift_hospital", OffShiftAssignment: models.OffShiftWarn})
+	assert.NoError(t, err)
+	h, err := store.GetHospital(ctx, hospital.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.OffShiftWarn, h.OffShiftAssignment)
+	alice, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "shift_alice"})
+	assert.NoError(t, err)
+	bob, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "shift_bob"})
+	assert.NoError(t, err)
+
+	morning := time.Date(2022, 3, 1, 8, 0, 0, 0, time.UTC)
+	var shift *models.Shift
+
+	t.Run("CreateShift", func(t *testing.T) {
+		shift, err = store.CreateShift(ctx, &dto.Shift{
+			HospitalID: hospital.ID,
+			EmployeeID: alice.ID,
+			StartsAt:   morning,
+			EndsAt:     morning.Add(8 * time.Hour),
+			Ward:       "icu",
+		})
+		assert.NoError(t, err)
+		assert.Greater(t, shift.ID, int64(0))
+
+		got, err := store.GetShift(ctx, shift.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, alice.ID, got.EmployeeID)
+		assert.Equal(t, "icu", got.Ward)
+		assert.True(t, morning.Equal(got.StartsAt))
+		assert.True(t, morning.Add(8*time.Hour).Equal(got.EndsAt))
+
+		_, err = store.CreateShift(ctx, &dto.Shift{HospitalID: hospital.ID, EmployeeID: bob.ID + 100, StartsAt: morning, EndsAt: morning.Add(time.Hour)})
+		assert.True(t, IsErrForeignKeyViolation(err))
+
+		_, err = store.CreateShift(ctx, &dto.Shift{
+			HospitalID: hospital.ID,
+			EmployeeID: bob.ID,
+			StartsAt:   morning.Add(8 * time.Hour),
+			EndsAt:     morning.Add(16 * time.Hour),
+			Ward:       "er",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("FindShifts", func(t *testing.T) {
+		shifts, err := store.FindShifts(ctx, hospital.ID, dto.ShiftFilter{}, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, shifts, 2)
+
+		for _, c := range []struct {
+			filter dto.ShiftFilter
+			want   uint
+		}{
+			{dto.ShiftFilter{EmployeeID: bob.ID}, 1},
+			{dto.ShiftFilter{Ward: "icu"}, 1},
+			{dto.ShiftFilter{Ward: "ward"}, 0},
+			{dto.ShiftFilter{At: morning}, 1},
+			{dto.ShiftFilter{At: morning.Add(-time.Second)}, 0},
+			// The end of a shift is excluded, so only bob is on shift.
+			{dto.ShiftFilter{At: morning.Add(8 * time.Hour)}, 1},
+			{dto.ShiftFilter{EmployeeID: alice.ID, At: morning.Add(8 * time.Hour)}, 0},
+		} {
+			n, err := store.CountShifts(ctx, hospital.ID, c.filter, dto.ListOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, n, "%+v", c.filter)
+			shifts, err := store.FindShifts(ctx, hospital.ID, c.filter, dto.ListOptions{Limit: 10})
+			assert.NoError(t, err)
+			assert.Len(t, shifts, int(c.want), "%+v", c.filter)
+		}
+	})
+
+	t.Run("CountOverlappingShifts", func(t *testing.T) {
+		n, err := store.CountOverlappingShifts(ctx, alice.ID, morning.Add(7*time.Hour), morning.Add(9*time.Hour), 0)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), n)
+		n, err = store.CountOverlappingShifts(ctx, alice.ID, morning.Add(7*time.Hour), morning.Add(9*time.Hour), shift.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), n)
+		// Back-to-back shifts don't overlap.
+		n, err = store.CountOverlappingShifts(ctx, alice.ID, morning.Add(8*time.Hour), morning.Add(16*time.Hour), 0)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), n)
+	})
+
+	t.Run("UpdateShift", func(t *testing.T) {
+		n, err := store.UpdateShift(ctx, &dto.Shift{
+			ID:         shift.ID,
+			EmployeeID: alice.ID,
+			StartsAt:   morning,
+			EndsAt:     morning.Add(4 * time.Hour),
+			Ward:       "er",
+			Version:    shift.Version,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.UpdateShift(ctx, &dto.Shift{ID: shift.ID, EmployeeID: alice.ID, StartsAt: morning, EndsAt: morning.Add(time.Hour), Version: shift.Version})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		got, err := store.GetShift(ctx, shift.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "er", got.Ward)
+		assert.True(t, morning.Add(4*time.Hour).Equal(got.EndsAt))
+		assert.Equal(t, shift.Version+1, got.Version)
+	})
+
+	t.Run("DeleteShift", func(t *testing.T) {
+		n, err := store.DeleteShift(ctx, shift.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.GetShift(ctx, shift.ID)
+		assert.True(t, IsErrNotFound(err))
+
+		count, err := store.CountShifts(ctx, hospital.ID, dto.ShiftFilter{}, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+		count, err = store.CountShifts(ctx, hospital.ID, dto.ShiftFilter{}, dto.ListOptions{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), count)
+		count, err = store.CountOverlappingShifts(ctx, alice.ID, morning, morning.Add(time.Hour), 0)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), count)
+	})
+}
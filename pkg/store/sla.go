@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+const taskSLAColumns = "id, hospital_id, priority, respond_within, escalation, created_at, updated_at"
+
+const taskEscalationColumns = "id, task_id, level, action, old_value, new_value, created_at"
+
+func (s *SQLStore) GetTaskSLA(ctx context.Context, hid int64, priority string) (*models.TaskSLA, error) {
+	var sla models.TaskSLA
+	sql := "select " + taskSLAColumns + " from task_sla where hospital_id = ? and priority = ?" + s.forUpdate()
+	err := s.getContext(ctx, &sla, sql, hid, priority)
+	return &sla, err
+}
+
+func (s *SQLStore) CreateTaskSLA(ctx context.Context, sla *dto.TaskSLA) (*models.TaskSLA, error) {
+	created := &models.TaskSLA{
+		HospitalID:    sla.HospitalID,
+		Priority:      sla.Priority,
+		RespondWithin: sla.RespondWithin,
+		Escalation:    sla.Escalation,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	sql := "insert into task_sla (hospital_id, priority, respond_within, escalation, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, created.HospitalID, created.Priority, created.RespondWithin, created.Escalation, created.CreatedAt, created.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	created.ID = id
+	return created, nil
+}
+
+// UpdateTaskSLA updates the SLA of the hospital sla.HospitalID for the
+// priority sla.Priority. The SLAs have no version, they're only ever
+// replaced as a whole.
+func (s *SQLStore) UpdateTaskSLA(ctx context.Context, sla *dto.TaskSLA) (int64, error) {
+	sql := "update task_sla set respond_within=?, escalation=?, updated_at=? where hospital_id = ? and priority = ?"
+	r, err := s.execContext(ctx, sql, sla.RespondWithin, sla.Escalation, time.Now().UTC(), sla.HospitalID, sla.Priority)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+// DeleteTaskSLA deletes the SLA along with its escalation chain, so it has
+// to run in a transaction. The SLAs are settings rather than records, they
+// aren't soft-deleted.
+func (s *SQLStore) DeleteTaskSLA(ctx context.Context, id int64) (int64, error) {
+	if _, err := s.execContext(ctx, "delete from task_sla_chain where sla_id = ?", id); err != nil {
+		return 0, err
+	}
+	r, err := s.execContext(ctx, "delete from task_sla where id = ?", id)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) FindTaskSLAs(ctx context.Context, hid int64) ([]*models.TaskSLA, error) {
+	var slas []*models.TaskSLA
+	sql := "select " + taskSLAColumns + " from task_sla where hospital_id = ? order by id"
+	if err := s.selectContext(ctx, &slas, sql, hid); err != nil {
+		return nil, err
+	}
+	return slas, nil
+}
+
+func (s *SQLStore) FindAllTaskSLAs(ctx context.Context) ([]*models.TaskSLA, error) {
+	var slas []*models.TaskSLA
+	sql := "select " + taskSLAColumns + " from task_sla where hospital_id in (select id from hospital where deleted_at is null) order by id"
+	if err := s.selectContext(ctx, &slas, sql); err != nil {
+		return nil, err
+	}
+	return slas, nil
+}
+
+// SetTaskSLAChain replaces the escalation chain of the SLA, so it has to run
+// in a transaction.
+func (s *SQLStore) SetTaskSLAChain(ctx context.Context, id int64, employeeIDs []int64) error {
+	if _, err := s.execContext(ctx, "delete from task_sla_chain where sla_id = ?", id); err != nil {
+		return err
+	}
+	for i, eid := range employeeIDs {
+		sql := "insert into task_sla_chain (sla_id, position, employee_id) VALUES (?, ?, ?)"
+		if _, err := s.execContext(ctx, sql, id, i, eid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) FindTaskSLAChains(ctx context.Context, ids []int64) (map[int64][]int64, error) {
+	chains := make(map[int64][]int64)
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return chains, nil
+	}
+	var rows []*models.TaskSLAChain
+	marks, args := inArgs(ids)
+	sql := "select sla_id, position, employee_id from task_sla_chain where sla_id in (" + marks + ") order by sla_id, position"
+	if err := s.selectContext(ctx, &rows, sql, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		chains[row.SLAID] = append(chains[row.SLAID], row.EmployeeID)
+	}
+	return chains, nil
+}
+
+func (s *SQLStore) FindBreachingTaskIDs(ctx context.Context, sla *models.TaskSLA, since time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	sql := "select id from task where hospital_id = ? and priority = ? and status = ? and deleted_at is null" +
+		" and not exists (select 1 from task_transition where task_transition.task_id = task.id and task_transition.created_at > ?)" +
+		" and not exists (select 1 from task_escalation where task_escalation.task_id = task.id and task_escalation.created_at > ?)" +
+		" order by id limit ?"
+	args := []any{sla.HospitalID, sla.Priority, models.TaskStatusOpen, since.UTC(), since.UTC(), limit}
+	if err := s.selectContext(ctx, &ids, sql, args...); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MarkTaskBreached flags the task. Like the overdue flag, it isn't a change
+// of the task, so the version is left as is.
+func (s *SQLStore) MarkTaskBreached(ctx context.Context, id int64) (int64, error) {
+	r, err := s.execContext(ctx, "update task set breached = ? where id = ? and breached = ?", true, id, false)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) ClearTaskBreaches(ctx context.Context) (int64, error) {
+	r, err := s.execContext(ctx, "update task set breached = ? where breached = ? and status <> ?", false, true, models.TaskStatusOpen)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) CreateTaskEscalation(ctx context.Context, e *dto.TaskEscalation) (*models.TaskEscalation, error) {
+	escalation := &models.TaskEscalation{
+		TaskID:    e.TaskID,
+		Level:     e.Level,
+		Action:    e.Action,
+		OldValue:  e.OldValue,
+		NewValue:  e.NewValue,
+		CreatedAt: e.CreatedAt.UTC(),
+	}
+	sql := "insert into task_escalation (task_id, level, action, old_value, new_value, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, escalation.TaskID, escalation.Level, escalation.Action, escalation.OldValue, escalation.NewValue, escalation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	escalation.ID = id
+	return escalation, nil
+}
+
+func (s *SQLStore) FindTaskEscalations(ctx context.Context, taskID int64) ([]*models.TaskEscalation, error) {
+	var escalations []*models.TaskEscalation
+	sql := "select " + taskEscalationColumns + " from task_escalation where task_id = ? order by id"
+	if err := s.selectContext(ctx, &escalations, sql, taskID); err != nil {
+		return nil, err
+	}
+	return escalations, nil
+}
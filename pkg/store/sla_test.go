@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestTaskSLA(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "sla_hospital"})
+	assert.NoError(t, err)
+	alice, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "sla_alice"})
+	assert.NoError(t, err)
+	bob, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "sla_bob"})
+	assert.NoError(t, err)
+
+	var sla *models.TaskSLA
+
+	t.Run("CreateTaskSLA", func(t *testing.T) {
+		sla, err = store.CreateTaskSLA(ctx, &dto.TaskSLA{
+			HospitalID:    hospital.ID,
+			Priority:      models.TaskPriorityUrgent,
+			RespondWithin: 600,
+			Escalation:    models.EscalationReassign,
+		})
+		assert.NoError(t, err)
+		assert.Greater(t, sla.ID, int64(0))
+
+		_, err = store.CreateTaskSLA(ctx, &dto.TaskSLA{
+			HospitalID:    hospital.ID,
+			Priority:      models.TaskPriorityUrgent,
+			RespondWithin: 60,
+			Escalation:    models.EscalationNone,
+		})
+		assert.True(t, IsErrDuplicateEntry(err))
+
+		assert.NoError(t, store.SetTaskSLAChain(ctx, sla.ID, []int64{bob.ID, alice.ID}))
+		chains, err := store.FindTaskSLAChains(ctx, []int64{sla.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{bob.ID, alice.ID}, chains[sla.ID])
+	})
+
+	t.Run("UpdateTaskSLA", func(t *testing.T) {
+		n, err := store.UpdateTaskSLA(ctx, &dto.TaskSLA{
+			HospitalID:    hospital.ID,
+			Priority:      models.TaskPriorityUrgent,
+			RespondWithin: 300,
+			Escalation:    models.EscalationBumpPriority,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		got, err := store.GetTaskSLA(ctx, hospital.ID, models.TaskPriorityUrgent)
+		assert.NoError(t, err)
+		assert.Equal(t, sla.ID, got.ID)
+		assert.Equal(t, int64(300), got.RespondWithin)
+		assert.Equal(t, models.EscalationBumpPriority, got.Escalation)
+
+		_, err = store.GetTaskSLA(ctx, hospital.ID, models.TaskPriorityLow)
+		assert.True(t, IsErrNotFound(err))
+	})
+
+	t.Run("FindTaskSLAs", func(t *testing.T) {
+		slas, err := store.FindTaskSLAs(ctx, hospital.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, slas, 1) {
+			assert.Equal(t, sla.ID, slas[0].ID)
+		}
+		slas, err = store.FindAllTaskSLAs(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, slas, 1)
+	})
+
+	t.Run("Breaches", func(t *testing.T) {
+		task, err := store.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    alice.ID,
+			Title:      "sla",
+			Priority:   models.TaskPriorityUrgent,
+			Status:     models.TaskStatusOpen,
+		})
+		assert.NoError(t, err)
+		_, err = store.CreateTaskTransition(ctx, task.ID, "", models.TaskStatusOpen)
+		assert.NoError(t, err)
+
+		// The task was moved after the past since, not the future one.
+		ids, err := store.FindBreachingTaskIDs(ctx, sla, time.Now().Add(-time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+		since := time.Now().Add(time.Hour)
+		ids, err = store.FindBreachingTaskIDs(ctx, sla, since, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{task.ID}, ids)
+
+		n, err := store.MarkTaskBreached(ctx, task.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.CreateTaskEscalation(ctx, &dto.TaskEscalation{
+			TaskID:    task.ID,
+			Level:     1,
+			Action:    models.EscalationNone,
+			CreatedAt: since.Add(time.Minute),
+		})
+		assert.NoError(t, err)
+		ids, err = store.FindBreachingTaskIDs(ctx, sla, since, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+
+		escalations, err := store.FindTaskEscalations(ctx, task.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, escalations, 1) {
+			assert.Equal(t, int64(1), escalations[0].Level)
+			assert.Equal(t, models.EscalationNone, escalations[0].Action)
+		}
+
+		breached := dto.TaskFilter{Breached: true}
+		count, err := store.CountTasksByHospital(ctx, hospital.ID, breached, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+
+		// Only the tasks which left OPEN are unflagged.
+		n, err = store.ClearTaskBreaches(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+		got, err := store.GetTask(ctx, task.ID)
+		assert.NoError(t, err)
+		assert.True(t, got.Breached)
+		assert.Equal(t, task.Version, got.Version)
+		n, err = store.UpdateTask(ctx, &dto.Task{
+			ID:       task.ID,
+			OwnerID:  alice.ID,
+			Title:    task.Title,
+			Priority: task.Priority,
+			Status:   models.TaskStatusInProgress,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.ClearTaskBreaches(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		count, err = store.CountTasksByHospital(ctx, hospital.ID, breached, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), count)
+	})
+
+	t.Run("DeleteTaskSLA", func(t *testing.T) {
+		n, err := store.DeleteTaskSLA(ctx, sla.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.GetTaskSLA(ctx, hospital.ID, models.TaskPriorityUrgent)
+		assert.True(t, IsErrNotFound(err))
+		chains, err := store.FindTaskSLAChains(ctx, []int64{sla.ID})
+		assert.NoError(t, err)
+		assert.Empty(t, chains)
+	})
+}
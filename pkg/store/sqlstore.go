@@ -1,68 +1,359 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
 )
 
+// ErrDuplicateEntry is returned by the non-SQL stores when a unique key is violated.
+var ErrDuplicateEntry = errors.New("duplicate entry")
+
+// ErrForeignKeyViolation is returned by the non-SQL stores when a row refers
+// to a row which doesn't exist.
+var ErrForeignKeyViolation = errors.New("foreign key violation")
+
+// Dialect is the SQL flavour spoken by the underlying database.
+type Dialect string
+
+const (
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite3"
+	DialectPostgres Dialect = "postgres"
+)
+
+// queryer is implemented by both *sqlx.DB and *sqlx.Tx.
+type queryer interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
 type SQLStore struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	dialect Dialect
+	// q runs the queries, it is the transaction in a store returned by WithTx.
+	q  queryer
+	tx *sqlx.Tx
+}
+
+func newSQLStore(db *sqlx.DB, dialect Dialect) *SQLStore {
+	return &SQLStore{db: db, dialect: dialect, q: db}
 }
 
+// NewSQLStore connects to the database given by the DATABASE_URL environment
+// variable. mysql://, sqlite:// and postgres:// URLs are supported.
 func NewSQLStore() (*SQLStore, error) {
 	u, ok := os.LookupEnv("DATABASE_URL")
 	if !ok {
 		u = "mysql://root@tcp(127.0.0.1:3306)/boxpractice"
 	}
-	dsn := fmt.Sprintf("%s?parseTime=true", strings.TrimPrefix(u, "mysql://"))
-	db, err := sqlx.Connect("mysql", dsn)
-	if err != nil {
-		return nil, err
+	return OpenSQLStore(u)
+}
+
+// OpenSQLStore connects to the database given by the URL u.
+func OpenSQLStore(u string) (*SQLStore, error) {
+	switch {
+	case strings.HasPrefix(u, "mysql://"):
+		dsn := fmt.Sprintf("%s?parseTime=true", strings.TrimPrefix(u, "mysql://"))
+		db, err := sqlx.Connect("mysql", dsn)
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxIdleConns(5)
+		db.SetMaxOpenConns(50)
+		return newSQLStore(db, DialectMySQL), nil
+	case strings.HasPrefix(u, "sqlite://"):
+		path := strings.TrimPrefix(u, "sqlite://")
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		db, err := sqlx.Connect("sqlite3", path+sep+"_foreign_keys=on&_busy_timeout=5000&_txlock=immediate")
+		if err != nil {
+			return nil, err
+		}
+		// SQLite only allows one writer at a time, so serialize everything
+		// on a single connection instead of failing with SQLITE_BUSY.
+		db.SetMaxOpenConns(1)
+		return newSQLStore(db, DialectSQLite), nil
+	case strings.HasPrefix(u, "postgres://"), strings.HasPrefix(u, "postgresql://"):
+		db, err := sqlx.Connect("postgres", u)
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxIdleConns(5)
+		db.SetMaxOpenConns(50)
+		return newSQLStore(db, DialectPostgres), nil
 	}
-	db.SetMaxIdleConns(5)
-	db.SetMaxOpenConns(50)
-	return &SQLStore{
-		db: db,
-	}, nil
+	return nil, fmt.Errorf("unsupported database url: %s", u)
 }
 
 func (s *SQLStore) DB() *sqlx.DB {
 	return s.db
 }
 
+func (s *SQLStore) Dialect() Dialect {
+	return s.dialect
+}
+
 func (s *SQLStore) Close() {
 	s.db.Close()
 }
 
 func (s *SQLStore) Cleanup() error {
+	switch s.dialect {
+	case DialectSQLite:
+		return s.cleanupSQLite()
+	case DialectPostgres:
+		return s.cleanupPostgres()
+	default:
+		return s.cleanupMySQL()
+	}
+}
+
+// cleanupMySQL truncates the tables in whatever order SHOW TABLES gives
+// them, so the foreign key checks are disabled meanwhile. That's a session
+// setting, hence the single connection.
+func (s *SQLStore) cleanupMySQL() error {
+	ctx := context.Background()
+	conn, err := s.DB().Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var tables []string
+	if err := conn.SelectContext(ctx, &tables, "SHOW TABLES"); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+	for _, table := range tables {
+		if table == migrationTable {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE `%s`", table)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupSQLite empties the tables with the foreign keys disabled, for the
+// same reason as cleanupMySQL.
+func (s *SQLStore) cleanupSQLite() error {
+	ctx := context.Background()
+	conn, err := s.DB().Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
 	var tables []string
-	if err := s.DB().Select(&tables, "SHOW TABLES"); err != nil {
+	if err := conn.SelectContext(ctx, &tables, "select name from sqlite_master where type = 'table' and name not like 'sqlite_%'"); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
 		return err
 	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
 	for _, table := range tables {
-		if _, err := s.DB().Exec(fmt.Sprintf("TRUNCATE TABLE `%s`", table)); err != nil {
+		if table == migrationTable {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf(`DELETE FROM "%s"`, table)); err != nil {
+			return err
+		}
+	}
+	// Reset the AUTOINCREMENT counters like TRUNCATE does in MySQL.
+	var n int
+	if err := conn.GetContext(ctx, &n, "select count(1) from sqlite_master where type = 'table' and name = 'sqlite_sequence'"); err != nil {
+		return err
+	}
+	if n > 0 {
+		if _, err := conn.ExecContext(ctx, "DELETE FROM sqlite_sequence"); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func (s *SQLStore) cleanupPostgres() error {
+	var tables []string
+	sql := "select tablename from pg_tables where schemaname = current_schema() and tablename <> ?"
+	if err := s.DB().Select(&tables, s.db.Rebind(sql), migrationTable); err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	for i := range tables {
+		tables[i] = fmt.Sprintf(`"%s"`, tables[i])
+	}
+	_, err := s.DB().Exec(fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY CASCADE", strings.Join(tables, ", ")))
+	return err
+}
+
+// The queries in this package are written with ? placeholders, the helpers
+// below rebind them to whatever the dialect expects.
+
+func (s *SQLStore) getContext(ctx context.Context, dest any, query string, args ...any) error {
+	return s.q.GetContext(ctx, dest, s.db.Rebind(query), args...)
+}
+
+func (s *SQLStore) selectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return s.q.SelectContext(ctx, dest, s.db.Rebind(query), args...)
+}
+
+func (s *SQLStore) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.q.ExecContext(ctx, s.db.Rebind(query), args...)
+}
+
+// insert runs an insert statement and returns the id of the new row.
+// PostgreSQL has no LastInsertId, so the id is read back with RETURNING.
+func (s *SQLStore) insert(ctx context.Context, query string, args ...any) (int64, error) {
+	if s.dialect == DialectPostgres {
+		var id int64
+		err := s.q.QueryRowxContext(ctx, s.db.Rebind(query+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+	r, err := s.execContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return r.LastInsertId()
+}
+
+// softDelete sets the deleted_at of the rows of table matching the where
+// condition. It returns the number of rows which weren't deleted already.
+func (s *SQLStore) softDelete(ctx context.Context, table, where string, args ...any) (int64, error) {
+	return s.softDeleteAt(ctx, time.Now().UTC(), table, where, args...)
+}
+
+// softDeleteAt is softDelete setting the deleted_at to at, so that the rows
+// deleted along another one share its deleted_at.
+func (s *SQLStore) softDeleteAt(ctx context.Context, at time.Time, table, where string, args ...any) (int64, error) {
+	query := "update " + table + " set deleted_at=?, updated_at=? where " + where + " and deleted_at is null"
+	r, err := s.execContext(ctx, query, append([]any{at, time.Now().UTC()}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+// restore clears the deleted_at of the row id of table. It returns 0 if
+// there is no such row or if it isn't deleted.
+func (s *SQLStore) restore(ctx context.Context, table string, id int64) (int64, error) {
+	query := "update " + table + " set deleted_at=null, updated_at=? where id = ? and deleted_at is not null"
+	r, err := s.execContext(ctx, query, time.Now().UTC(), id)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+// inArgs returns the placeholders and the args of an "in (...)" condition.
+func inArgs[T any](values []T) (string, []any) {
+	args := make([]any, len(values))
+	for i := range values {
+		args[i] = values[i]
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "), args
+}
+
+// page returns the end of a query selecting the page opts asks for, along
+// with its args. The AfterID keyset condition goes first, so the query has
+// to end with its where clause.
+func page(opts dto.ListOptions) (string, []any) {
+	var query string
+	var args []any
+	if opts.AfterID > 0 {
+		query = " and id > ?"
+		args = append(args, opts.AfterID)
+	}
+	query += " order by id limit ? offset ?"
+	return query, append(args, opts.Limit, opts.Offset)
+}
+
+// notDeleted returns the condition which filters out the soft-deleted rows,
+// unless opts asks for them.
+func notDeleted(opts dto.ListOptions) string {
+	if opts.IncludeDeleted {
+		return ""
+	}
+	return " and deleted_at is null"
+}
+
 func IsErrDuplicateEntry(err error) bool {
+	if errors.Is(err, ErrDuplicateEntry) {
+		return true
+	}
 	var mErr *mysql.MySQLError
 	if errors.As(err, &mErr) {
 		if mErr.Number == 1062 {
 			return true
 		}
 	}
+	var sErr sqlite3.Error
+	if errors.As(err, &sErr) {
+		if sErr.ExtendedCode == sqlite3.ErrConstraintUnique || sErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return true
+		}
+	}
+	var pErr *pq.Error
+	if errors.As(err, &pErr) {
+		// unique_violation
+		if pErr.Code == "23505" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsErrForeignKeyViolation reports whether err was caused by a row referring
+// to a row which doesn't exist, or by deleting a row which is still referred
+// to.
+func IsErrForeignKeyViolation(err error) bool {
+	if errors.Is(err, ErrForeignKeyViolation) {
+		return true
+	}
+	var mErr *mysql.MySQLError
+	if errors.As(err, &mErr) {
+		// ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+		if mErr.Number == 1451 || mErr.Number == 1452 {
+			return true
+		}
+	}
+	var sErr sqlite3.Error
+	if errors.As(err, &sErr) {
+		if sErr.ExtendedCode == sqlite3.ErrConstraintForeignKey {
+			return true
+		}
+	}
+	var pErr *pq.Error
+	if errors.As(err, &pErr) {
+		// foreign_key_violation
+		if pErr.Code == "23503" {
+			return true
+		}
+	}
 	return false
 }
 
 func IsErrNotFound(err error) bool {
-	return err == sql.ErrNoRows
+	return errors.Is(err, sql.ErrNoRows)
 }
@@ -0,0 +1,287 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+// Rows are soft-deleted: Delete* only sets their deleted_at, and Restore*
+// clears it. The Get* methods never return a deleted row, and the Find* and
+// Count* methods only include them when asked to by the ListOptions. The
+// Count* methods ignore the paging options.
+
+// HospitalStore persists hospitals.
+type HospitalStore interface {
+	GetHospital(ctx context.Context, id int64) (*models.Hospital, error)
+	// GetDeletedHospital returns the hospital id only if it is deleted.
+	GetDeletedHospital(ctx context.Context, id int64) (*models.Hospital, error)
+	CreateHospital(ctx context.Context, h *dto.Hospital) (*models.Hospital, error)
+	UpdateHospital(ctx context.Context, h *dto.Hospital) (int64, error)
+	DeleteHospital(ctx context.Context, id int64) (int64, error)
+	RestoreHospital(ctx context.Context, id int64) (int64, error)
+	// SetLastAssignee records the employee eid as the last one picked by
+	// the round-robin strategy of the hospital hid.
+	SetLastAssignee(ctx context.Context, hid, eid int64) (int64, error)
+	FindHospitals(ctx context.Context, opts dto.ListOptions) ([]*models.Hospital, error)
+	CountHosptials(ctx context.Context, opts dto.ListOptions) (uint, error)
+}
+
+// EmployeeStore persists employees.
+type EmployeeStore interface {
+	GetEmployee(ctx context.Context, id int64) (*models.Employee, error)
+	// GetDeletedEmployee returns the employee id only if they are deleted.
+	GetDeletedEmployee(ctx context.Context, id int64) (*models.Employee, error)
+	CreateEmployee(ctx context.Context, e *dto.Employee) (*models.Employee, error)
+	DeleteEmployee(ctx context.Context, id int64) (int64, error)
+	RestoreEmployee(ctx context.Context, id int64) (int64, error)
+	// DeleteEmployeesByHospital deletes the employees of the hospital hid
+	// at at, the time the hospital itself was deleted.
+	DeleteEmployeesByHospital(ctx context.Context, hid int64, at time.Time) (int64, error)
+	FindEmployees(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.Employee, error)
+	CountEmployees(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error)
+}
+
+// TaskStore persists tasks.
+type TaskStore interface {
+	GetTask(ctx context.Context, id int64) (*models.Task, error)
+	CreateTask(ctx context.Context, task *dto.Task) (*models.Task, error)
+	UpdateTask(ctx context.Context, task *dto.Task) (int64, error)
+	// ClaimTask makes the employee oid the owner of the task id, unless the
+	// task has an owner already, in which case it returns 0.
+	ClaimTask(ctx context.Context, id, oid int64) (int64, error)
+	DeleteTask(ctx context.Context, id int64) (int64, error)
+	RestoreTask(ctx context.Context, id int64) (int64, error)
+	FindTasksByHospital(ctx context.Context, hosptialID int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error)
+	CountTasksByHospital(ctx context.Context, hosptialID int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error)
+	FindTasksByOwner(ctx context.Context, oid int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error)
+	CountTasksByOwner(ctx context.Context, oid int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error)
+
+	// The open tasks are the tasks which are neither closed nor deleted.
+	CountOpenTasksByHospital(ctx context.Context, hosptialID int64) (uint, error)
+	CountOpenTasksByOwner(ctx context.Context, oid int64) (uint, error)
+	// CountOpenTasksPerOwner counts the open tasks of the hospital per owner
+	// and priority, leaving out the pooled ones.
+	CountOpenTasksPerOwner(ctx context.Context, hosptialID int64) ([]*models.OwnerTaskCount, error)
+	// SweepOverdueTasks flags the open tasks past their due date at now,
+	// clears the flag of the other ones, and returns the number of newly
+	// flagged tasks.
+	SweepOverdueTasks(ctx context.Context, now time.Time) (int64, error)
+	// DeleteTasksByHospital and DeleteTasksByOwner delete the tasks at at,
+	// the time their hospital or owner was deleted, so that restoring it can
+	// tell them from the tasks deleted before.
+	DeleteTasksByHospital(ctx context.Context, hosptialID int64, at time.Time) (int64, error)
+	DeleteTasksByOwner(ctx context.Context, oid int64, at time.Time) (int64, error)
+
+	// CreateTaskTransition records the task taskID moving from the status
+	// from to the status to.
+	CreateTaskTransition(ctx context.Context, taskID int64, from, to string) (*models.TaskTransition, error)
+	// FindTaskTransitions returns the transitions of the task, oldest first.
+	FindTaskTransitions(ctx context.Context, taskID int64) ([]*models.TaskTransition, error)
+
+	// The changes are the history of the task, they are never updated.
+	CreateTaskChange(ctx context.Context, c *dto.TaskChange) (*models.TaskChange, error)
+	GetTaskChange(ctx context.Context, id int64) (*models.TaskChange, error)
+	FindTaskChanges(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.TaskChange, error)
+	CountTaskChanges(ctx context.Context, taskID int64) (uint, error)
+
+	// SetTaskParent makes the task id a subtask of the task parentID, or a
+	// top level task if parentID is 0.
+	SetTaskParent(ctx context.Context, id, parentID int64) (int64, error)
+	// GetTaskParentID returns the parent of the task id, 0 if it has none,
+	// even if the task is deleted.
+	GetTaskParentID(ctx context.Context, id int64) (int64, error)
+	FindSubtasks(ctx context.Context, parentID int64) ([]*models.Task, error)
+	// CountSubtasks returns the number of subtasks of the task parentID and
+	// how many of them are completed.
+	CountSubtasks(ctx context.Context, parentID int64) (total, done uint, err error)
+
+	// CreateTaskDependency records that the task taskID is blocked by the
+	// task blockerID. It fails with ErrDuplicateEntry if it is already.
+	CreateTaskDependency(ctx context.Context, taskID, blockerID int64) error
+	DeleteTaskDependency(ctx context.Context, taskID, blockerID int64) (int64, error)
+	// FindTaskBlockerIDs returns the ids of all the blockers of the task,
+	// deleted or not.
+	FindTaskBlockerIDs(ctx context.Context, taskID int64) ([]int64, error)
+	FindTaskBlockers(ctx context.Context, taskID int64) ([]*models.Task, error)
+	// CountOpenBlockers counts the blockers of the task which are neither
+	// closed nor deleted.
+	CountOpenBlockers(ctx context.Context, taskID int64) (uint, error)
+}
+
+// CommentStore persists the comments of the tasks.
+type CommentStore interface {
+	GetComment(ctx context.Context, id int64) (*models.Comment, error)
+	CreateComment(ctx context.Context, c *dto.Comment) (*models.Comment, error)
+	UpdateComment(ctx context.Context, c *dto.Comment) (int64, error)
+	DeleteComment(ctx context.Context, id int64) (int64, error)
+	FindComments(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.Comment, error)
+	CountComments(ctx context.Context, taskID int64, opts dto.ListOptions) (uint, error)
+}
+
+// AttachmentStore persists the metadata of the files attached to the tasks.
+// The files themselves are kept in a blob.Store.
+type AttachmentStore interface {
+	GetAttachment(ctx context.Context, id int64) (*models.Attachment, error)
+	CreateAttachment(ctx context.Context, a *dto.Attachment) (*models.Attachment, error)
+	DeleteAttachment(ctx context.Context, id int64) (int64, error)
+	FindAttachments(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.Attachment, error)
+	CountAttachments(ctx context.Context, taskID int64, opts dto.ListOptions) (uint, error)
+}
+
+// LabelStore persists the labels of the hospitals and the labels of the
+// tasks. The labels are deleted for good, along with their task labels and
+// their template labels.
+type LabelStore interface {
+	GetLabel(ctx context.Context, id int64) (*models.Label, error)
+	CreateLabel(ctx context.Context, l *dto.Label) (*models.Label, error)
+	UpdateLabel(ctx context.Context, l *dto.Label) (int64, error)
+	DeleteLabel(ctx context.Context, id int64) (int64, error)
+	FindLabels(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.Label, error)
+	CountLabels(ctx context.Context, hid int64) (uint, error)
+
+	// CreateTaskLabel tags the task with the label, which fails with
+	// ErrDuplicateEntry if it is tagged already.
+	CreateTaskLabel(ctx context.Context, taskID, labelID int64) error
+	DeleteTaskLabel(ctx context.Context, taskID, labelID int64) (int64, error)
+	// FindTaskLabels returns the labels of each of the tasks, sorted by name.
+	FindTaskLabels(ctx context.Context, taskIDs []int64) (map[int64][]*models.Label, error)
+}
+
+// TaskRecurrenceStore persists the recurrences creating the recurring tasks
+// and their owner rotations.
+type TaskRecurrenceStore interface {
+	GetTaskRecurrence(ctx context.Context, id int64) (*models.TaskRecurrence, error)
+	CreateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (*models.TaskRecurrence, error)
+	UpdateTaskRecurrence(ctx context.Context, r *dto.TaskRecurrence) (int64, error)
+	DeleteTaskRecurrence(ctx context.Context, id int64) (int64, error)
+	FindTaskRecurrences(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.TaskRecurrence, error)
+	CountTaskRecurrences(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error)
+
+	// SetTaskRecurrenceOwners replaces the owner rotation of the recurrence.
+	SetTaskRecurrenceOwners(ctx context.Context, id int64, ownerIDs []int64) error
+	// FindTaskRecurrenceOwners returns the owner rotation of each of the
+	// recurrences.
+	FindTaskRecurrenceOwners(ctx context.Context, ids []int64) (map[int64][]int64, error)
+	// FindDueTaskRecurrenceIDs returns up to limit recurrences of the
+	// hospitals which aren't deleted whose next occurrence is at or before
+	// now, the earliest first.
+	FindDueTaskRecurrenceIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	// AdvanceTaskRecurrence moves the next occurrence of the recurrence to
+	// nextAt and counts one more occurrence, unless the recurrence has
+	// counted other occurrences than the given ones since it was read.
+	AdvanceTaskRecurrence(ctx context.Context, id, occurrences int64, nextAt time.Time) (int64, error)
+}
+
+// TaskSLAStore persists the SLAs of the hospitals, their escalation chains,
+// and the escalations of the tasks breaching them.
+type TaskSLAStore interface {
+	// GetTaskSLA returns the SLA of the hospital hid for the priority.
+	GetTaskSLA(ctx context.Context, hid int64, priority string) (*models.TaskSLA, error)
+	CreateTaskSLA(ctx context.Context, sla *dto.TaskSLA) (*models.TaskSLA, error)
+	UpdateTaskSLA(ctx context.Context, sla *dto.TaskSLA) (int64, error)
+	DeleteTaskSLA(ctx context.Context, id int64) (int64, error)
+	FindTaskSLAs(ctx context.Context, hid int64) ([]*models.TaskSLA, error)
+	// FindAllTaskSLAs returns the SLAs of all the hospitals which aren't
+	// deleted.
+	FindAllTaskSLAs(ctx context.Context) ([]*models.TaskSLA, error)
+
+	// SetTaskSLAChain replaces the escalation chain of the SLA.
+	SetTaskSLAChain(ctx context.Context, id int64, employeeIDs []int64) error
+	// FindTaskSLAChains returns the escalation chain of each of the SLAs.
+	FindTaskSLAChains(ctx context.Context, ids []int64) (map[int64][]int64, error)
+
+	// FindBreachingTaskIDs returns up to limit OPEN tasks of the hospital
+	// and priority of the SLA which were neither moved nor escalated after
+	// since, by id.
+	FindBreachingTaskIDs(ctx context.Context, sla *models.TaskSLA, since time.Time, limit int) ([]int64, error)
+	// MarkTaskBreached flags the task as breaching its SLA, and
+	// ClearTaskBreaches unflags the tasks which aren't OPEN anymore.
+	MarkTaskBreached(ctx context.Context, id int64) (int64, error)
+	ClearTaskBreaches(ctx context.Context) (int64, error)
+
+	// The escalations are records, they are never updated.
+	CreateTaskEscalation(ctx context.Context, e *dto.TaskEscalation) (*models.TaskEscalation, error)
+	// FindTaskEscalations returns the escalations of the task, oldest first.
+	FindTaskEscalations(ctx context.Context, taskID int64) ([]*models.TaskEscalation, error)
+}
+
+// TaskWatcherStore persists the employees following the tasks, and the
+// changes of the tasks sent to their owners and watchers.
+type TaskWatcherStore interface {
+	// CreateTaskWatcher makes the employee eid follow the task, which fails
+	// with ErrDuplicateEntry if they follow it already.
+	CreateTaskWatcher(ctx context.Context, taskID, eid int64) error
+	DeleteTaskWatcher(ctx context.Context, taskID, eid int64) (int64, error)
+	// FindTaskWatcherIDs returns the ids of the watchers of the task, by id.
+	FindTaskWatcherIDs(ctx context.Context, taskID int64) ([]int64, error)
+	FindWatchedTasks(ctx context.Context, eid int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error)
+	CountWatchedTasks(ctx context.Context, eid int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error)
+
+	// CreateTaskEvent sends the change changeID to the employee eid. The
+	// events are never updated.
+	CreateTaskEvent(ctx context.Context, eid, changeID int64) error
+	// FindTaskEvents returns the events of the employee, oldest first.
+	FindTaskEvents(ctx context.Context, eid int64, opts dto.ListOptions) ([]*models.TaskEvent, error)
+	CountTaskEvents(ctx context.Context, eid int64) (uint, error)
+}
+
+// ShiftStore persists the shifts of the employees.
+type ShiftStore interface {
+	GetShift(ctx context.Context, id int64) (*models.Shift, error)
+	CreateShift(ctx context.Context, s *dto.Shift) (*models.Shift, error)
+	UpdateShift(ctx context.Context, s *dto.Shift) (int64, error)
+	DeleteShift(ctx context.Context, id int64) (int64, error)
+	// FindShifts returns the shifts of the hospital, by id.
+	FindShifts(ctx context.Context, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) ([]*models.Shift, error)
+	CountShifts(ctx context.Context, hid int64, filter dto.ShiftFilter, opts dto.ListOptions) (uint, error)
+	// CountOverlappingShifts counts the shifts of the employee eid which
+	// overlap [start, end), leaving out the shift excludeID.
+	CountOverlappingShifts(ctx context.Context, eid int64, start, end time.Time, excludeID int64) (uint, error)
+}
+
+// TaskTemplateStore persists the templates of the tasks, along with their
+// labels and checklists.
+type TaskTemplateStore interface {
+	GetTaskTemplate(ctx context.Context, id int64) (*models.TaskTemplate, error)
+	CreateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (*models.TaskTemplate, error)
+	UpdateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (int64, error)
+	DeleteTaskTemplate(ctx context.Context, id int64) (int64, error)
+	FindTaskTemplates(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.TaskTemplate, error)
+	CountTaskTemplates(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error)
+
+	// SetTaskTemplateLabels replaces the labels of the template.
+	SetTaskTemplateLabels(ctx context.Context, id int64, labelIDs []int64) error
+	// FindTaskTemplateLabelIDs returns the labels of each of the templates,
+	// by id.
+	FindTaskTemplateLabelIDs(ctx context.Context, ids []int64) (map[int64][]int64, error)
+	// SetTaskTemplateItems replaces the checklist of the template.
+	SetTaskTemplateItems(ctx context.Context, id int64, items []string) error
+	// FindTaskTemplateItems returns the checklist of each of the templates.
+	FindTaskTemplateItems(ctx context.Context, ids []int64) (map[int64][]string, error)
+}
+
+// Store is the union of all the aggregate stores.
+type Store interface {
+	HospitalStore
+	EmployeeStore
+	TaskStore
+	CommentStore
+	AttachmentStore
+	LabelStore
+	TaskRecurrenceStore
+	TaskSLAStore
+	TaskWatcherStore
+	ShiftStore
+	TaskTemplateStore
+
+	// WithTx runs fn atomically against the Store it is given.
+	WithTx(ctx context.Context, fn func(Store) error) error
+}
+
+var (
+	_ Store = (*SQLStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
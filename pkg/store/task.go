@@ -8,30 +8,33 @@ import (
 	"github.com/liuerfire/boxpractice/pkg/models"
 )
 
+const taskColumns = "id, hospital_id, owner_id, parent_id, recurrence_id, occurrence_at, title, description, priority, status, due_at, overdue, breached, version, created_at, updated_at, deleted_at"
+
 func (s *SQLStore) GetTask(ctx context.Context, id int64) (*models.Task, error) {
 	var t models.Task
-	sql := "select id, hospital_id, owner_id, title, description, priority, status, created_at, updated_at from task where id = ?"
-	err := s.db.GetContext(ctx, &t, sql, id)
+	sql := "select " + taskColumns + " from task where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &t, sql, id)
 	return &t, err
 }
 
 func (s *SQLStore) CreateTask(ctx context.Context, task *dto.Task) (*models.Task, error) {
 	t := &models.Task{
-		HospitalID:  task.HospitalID,
-		OwnerID:     task.OwnerID,
-		Title:       task.Title,
-		Description: task.Description,
-		Priority:    task.Priority,
-		Status:      task.Status,
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
-	}
-	sql := "insert into task (hospital_id, owner_id, title, description, priority, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
-	r, err := s.db.ExecContext(ctx, sql, t.HospitalID, t.OwnerID, t.Title, t.Description, t.Priority, t.Status, t.CreatedAt, t.UpdatedAt)
-	if err != nil {
-		return nil, err
+		HospitalID:   task.HospitalID,
+		OwnerID:      nullID(task.OwnerID),
+		ParentID:     nullID(task.ParentID),
+		RecurrenceID: nullID(task.RecurrenceID),
+		OccurrenceAt: utcTime(task.OccurrenceAt),
+		Title:        task.Title,
+		Description:  task.Description,
+		Priority:     task.Priority,
+		Status:       task.Status,
+		DueAt:        utcTime(task.DueAt),
+		Version:      1,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
 	}
-	id, err := r.LastInsertId()
+	sql := "insert into task (hospital_id, owner_id, parent_id, recurrence_id, occurrence_at, title, description, priority, status, due_at, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, t.HospitalID, t.OwnerID, t.ParentID, t.RecurrenceID, t.OccurrenceAt, t.Title, t.Description, t.Priority, t.Status, t.DueAt, t.Version, t.CreatedAt, t.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -39,47 +42,235 @@ func (s *SQLStore) CreateTask(ctx context.Context, task *dto.Task) (*models.Task
 	return t, nil
 }
 
-func (s *SQLStore) FindTasksByHospital(ctx context.Context, hosptialID int64, offset, limit uint) ([]*models.Task, error) {
+func (s *SQLStore) FindTasksByHospital(ctx context.Context, hosptialID int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error) {
+	q := taskQuery(filter, opts)
+	q.where("hospital_id = ?", hosptialID)
+	return s.findTasks(ctx, q, opts)
+}
+
+func (s *SQLStore) CountTasksByHospital(ctx context.Context, hosptialID int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error) {
+	q := taskQuery(filter, opts)
+	q.where("hospital_id = ?", hosptialID)
+	return s.countTasks(ctx, q)
+}
+
+func (s *SQLStore) FindTasksByOwner(ctx context.Context, oid int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error) {
+	q := taskQuery(filter, opts)
+	q.where("owner_id = ?", oid)
+	return s.findTasks(ctx, q, opts)
+}
+
+func (s *SQLStore) CountTasksByOwner(ctx context.Context, oid int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error) {
+	q := taskQuery(filter, opts)
+	q.where("owner_id = ?", oid)
+	return s.countTasks(ctx, q)
+}
+
+func (s *SQLStore) findTasks(ctx context.Context, q *query, opts dto.ListOptions) ([]*models.Task, error) {
+	end, args, err := taskPage(q, opts)
+	if err != nil {
+		return nil, err
+	}
 	var tasks []*models.Task
-	sql := "select id, hospital_id, owner_id, title, description, priority, status, created_at, updated_at from task where hospital_id = ? order by id limit ?, ?"
-	if err := s.db.Select(&tasks, sql, hosptialID, offset, limit); err != nil {
+	sql := "select " + taskColumns + " from task" + q.String() + end
+	if err := s.selectContext(ctx, &tasks, sql, append(q.args, args...)...); err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
-func (s *SQLStore) CountTasksByHospital(ctx context.Context, hosptialID int64) (uint, error) {
+func (s *SQLStore) countTasks(ctx context.Context, q *query) (uint, error) {
 	var count uint
-	sql := "select count(1) from task where hospital_id = ?"
-	if err := s.db.Get(&count, sql, hosptialID); err != nil {
+	sql := "select count(1) from task" + q.String()
+	if err := s.getContext(ctx, &count, sql, q.args...); err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (s *SQLStore) FindTasksByOwner(ctx context.Context, oid int64, offset, limit uint) ([]*models.Task, error) {
-	var tasks []*models.Task
-	sql := "select id, title, description, priority, status, owner_id, created_at, updated_at from task where owner_id = ? order by id limit ?, ?"
-	if err := s.db.Select(&tasks, sql, oid, offset, limit); err != nil {
+// UpdateTask updates the task and bumps its version. If task.Version is
+// set, the task is only updated if it is still at that version.
+func (s *SQLStore) UpdateTask(ctx context.Context, task *dto.Task) (int64, error) {
+	sql := "update task set owner_id=?, title=?, description=?, priority=?, status=?, due_at=?, version=version+1, updated_at=? where id = ? and deleted_at is null"
+	args := []any{nullID(task.OwnerID), task.Title, task.Description, task.Priority, task.Status, utcTime(task.DueAt), time.Now().UTC(), task.ID}
+	if task.Version > 0 {
+		sql += " and version = ?"
+		args = append(args, task.Version)
+	}
+	r, err := s.execContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) ClaimTask(ctx context.Context, id, oid int64) (int64, error) {
+	sql := "update task set owner_id=?, version=version+1, updated_at=? where id = ? and owner_id is null and deleted_at is null"
+	r, err := s.execContext(ctx, sql, oid, time.Now().UTC(), id)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) DeleteTask(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "task", "id = ?", id)
+}
+
+func (s *SQLStore) RestoreTask(ctx context.Context, id int64) (int64, error) {
+	return s.restore(ctx, "task", id)
+}
+
+// openTasks returns the condition matching the open tasks.
+func openTasks() (string, []any) {
+	marks, args := inArgs(models.TaskClosedStatuses)
+	return " and status not in (" + marks + ") and deleted_at is null", args
+}
+
+func (s *SQLStore) CountOpenTasksByHospital(ctx context.Context, hosptialID int64) (uint, error) {
+	var count uint
+	cond, args := openTasks()
+	sql := "select count(1) from task where hospital_id = ?" + cond
+	if err := s.getContext(ctx, &count, sql, append([]any{hosptialID}, args...)...); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *SQLStore) CountOpenTasksPerOwner(ctx context.Context, hosptialID int64) ([]*models.OwnerTaskCount, error) {
+	var counts []*models.OwnerTaskCount
+	cond, args := openTasks()
+	sql := "select owner_id, priority, count(1) as count from task where hospital_id = ? and owner_id is not null" + cond + " group by owner_id, priority"
+	if err := s.selectContext(ctx, &counts, sql, append([]any{hosptialID}, args...)...); err != nil {
 		return nil, err
 	}
-	return tasks, nil
+	return counts, nil
 }
 
-func (s *SQLStore) CountTasksByOwner(ctx context.Context, oid int64) (uint, error) {
+func (s *SQLStore) CountOpenTasksByOwner(ctx context.Context, oid int64) (uint, error) {
 	var count uint
-	sql := "select count(1) from task where owner_id = ?"
-	if err := s.db.Get(&count, sql, oid); err != nil {
+	cond, args := openTasks()
+	sql := "select count(1) from task where owner_id = ?" + cond
+	if err := s.getContext(ctx, &count, sql, append([]any{oid}, args...)...); err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (s *SQLStore) UpdateTask(ctx context.Context, task *dto.Task) (int64, error) {
-	sql := "update task set owner_id=?, title=?, description=?, priority=?, status=? where id = ?"
-	r, err := s.db.ExecContext(ctx, sql, task.OwnerID, task.Title, task.Description, task.Priority, task.Status, task.ID)
+// SweepOverdueTasks flags the open tasks which are past their due date at
+// now, and unflags the ones which aren't anymore. It returns the number of
+// newly flagged tasks. The flag isn't a change of the task, so the version is
+// left as is.
+func (s *SQLStore) SweepOverdueTasks(ctx context.Context, now time.Time) (int64, error) {
+	marks, closed := inArgs(models.TaskClosedStatuses)
+	sql := "update task set overdue = ? where overdue = ? and (due_at is null or due_at >= ? or status in (" + marks + "))"
+	if _, err := s.execContext(ctx, sql, append([]any{false, true, now.UTC()}, closed...)...); err != nil {
+		return 0, err
+	}
+	sql = "update task set overdue = ? where overdue = ? and due_at < ? and status not in (" + marks + ") and deleted_at is null"
+	r, err := s.execContext(ctx, sql, append([]any{true, false, now.UTC()}, closed...)...)
 	if err != nil {
 		return 0, err
 	}
 	return r.RowsAffected()
 }
+
+func (s *SQLStore) DeleteTasksByHospital(ctx context.Context, hosptialID int64, at time.Time) (int64, error) {
+	return s.softDeleteAt(ctx, at, "task", "hospital_id = ?", hosptialID)
+}
+
+func (s *SQLStore) DeleteTasksByOwner(ctx context.Context, oid int64, at time.Time) (int64, error) {
+	return s.softDeleteAt(ctx, at, "task", "owner_id = ?", oid)
+}
+
+func (s *SQLStore) CreateTaskTransition(ctx context.Context, taskID int64, from, to string) (*models.TaskTransition, error) {
+	t := &models.TaskTransition{
+		TaskID:     taskID,
+		FromStatus: from,
+		ToStatus:   to,
+		CreatedAt:  time.Now().UTC(),
+	}
+	sql := "insert into task_transition (task_id, from_status, to_status, created_at) VALUES (?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, t.TaskID, t.FromStatus, t.ToStatus, t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.ID = id
+	return t, nil
+}
+
+func (s *SQLStore) FindTaskTransitions(ctx context.Context, taskID int64) ([]*models.TaskTransition, error) {
+	var transitions []*models.TaskTransition
+	sql := "select id, task_id, from_status, to_status, created_at from task_transition where task_id = ? order by id"
+	if err := s.selectContext(ctx, &transitions, sql, taskID); err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+const taskChangeColumns = "id, task_id, field, old_value, new_value, actor_id, created_at"
+
+func (s *SQLStore) CreateTaskChange(ctx context.Context, c *dto.TaskChange) (*models.TaskChange, error) {
+	change := &models.TaskChange{
+		TaskID:    c.TaskID,
+		Field:     c.Field,
+		OldValue:  c.OldValue,
+		NewValue:  c.NewValue,
+		CreatedAt: time.Now().UTC(),
+	}
+	if c.ActorID > 0 {
+		change.ActorID = &c.ActorID
+	}
+	sql := "insert into task_change (task_id, field, old_value, new_value, actor_id, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, change.TaskID, change.Field, change.OldValue, change.NewValue, change.ActorID, change.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	change.ID = id
+	return change, nil
+}
+
+func (s *SQLStore) GetTaskChange(ctx context.Context, id int64) (*models.TaskChange, error) {
+	var c models.TaskChange
+	sql := "select " + taskChangeColumns + " from task_change where id = ?"
+	err := s.getContext(ctx, &c, sql, id)
+	return &c, err
+}
+
+// FindTaskChanges returns the changes of the task, oldest first. The changes
+// are never deleted, so opts.IncludeDeleted doesn't apply.
+func (s *SQLStore) FindTaskChanges(ctx context.Context, taskID int64, opts dto.ListOptions) ([]*models.TaskChange, error) {
+	var changes []*models.TaskChange
+	cond, args := page(opts)
+	sql := "select " + taskChangeColumns + " from task_change where task_id = ?" + cond
+	if err := s.selectContext(ctx, &changes, sql, append([]any{taskID}, args...)...); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (s *SQLStore) CountTaskChanges(ctx context.Context, taskID int64) (uint, error) {
+	var count uint
+	sql := "select count(1) from task_change where task_id = ?"
+	if err := s.getContext(ctx, &count, sql, taskID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// utcTime returns t in UTC, or nil if t is nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// nullID returns a pointer to id, or nil if id is 0.
+func nullID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
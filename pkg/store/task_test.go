@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -102,7 +103,7 @@ func TestTask(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Greater(t, ta.ID, int64(0))
 				assert.Equal(t, hospital.ID, ta.HospitalID)
-				assert.Equal(t, employee.ID, ta.OwnerID)
+				assert.Equal(t, &employee.ID, ta.OwnerID)
 				assert.Equal(t, task.Title, ta.Title)
 				assert.Equal(t, task.Description, ta.Description)
 				assert.Equal(t, task.Priority, ta.Priority)
@@ -121,7 +122,7 @@ func TestTask(t *testing.T) {
 				n, err := store.UpdateTask(ctx, &dto.Task{
 					ID:          ta.ID,
 					HospitalID:  ta.HospitalID,
-					OwnerID:     ta.OwnerID,
+					OwnerID:     *ta.OwnerID,
 					Title:       ta.Title,
 					Description: ta.Description,
 					Priority:    "LOW",
@@ -141,28 +142,248 @@ func TestTask(t *testing.T) {
 				assert.Equal(t, "FAILED", taskNew.Status)
 			}
 		}
-		tasks, err := store.FindTasksByHospital(ctx, hospital.ID, 0, 10)
+		tasks, err := store.FindTasksByHospital(ctx, hospital.ID, dto.TaskFilter{}, dto.ListOptions{Offset: 0, Limit: 10})
 		assert.NoError(t, err)
 		assert.Equal(t, hospitalTasks, len(tasks))
 
-		hn, err := store.CountTasksByHospital(ctx, hospital.ID)
+		hn, err := store.CountTasksByHospital(ctx, hospital.ID, dto.TaskFilter{}, dto.ListOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint(hospitalTasks), hn)
 
-		atasks, err := store.FindTasksByOwner(ctx, employeeA.ID, 0, 10)
+		atasks, err := store.FindTasksByOwner(ctx, employeeA.ID, dto.TaskFilter{}, dto.ListOptions{Offset: 0, Limit: 10})
 		assert.NoError(t, err)
 		assert.Equal(t, employeeATasks, len(atasks))
 
-		an, err := store.CountTasksByOwner(ctx, employeeA.ID)
+		an, err := store.CountTasksByOwner(ctx, employeeA.ID, dto.TaskFilter{}, dto.ListOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint(employeeATasks), an)
 
-		btasks, err := store.FindTasksByOwner(ctx, employeeB.ID, 0, 10)
+		btasks, err := store.FindTasksByOwner(ctx, employeeB.ID, dto.TaskFilter{}, dto.ListOptions{Offset: 0, Limit: 10})
 		assert.NoError(t, err)
 		assert.Equal(t, employeeBTasks, len(btasks))
 
-		bn, err := store.CountTasksByOwner(ctx, employeeB.ID)
+		bn, err := store.CountTasksByOwner(ctx, employeeB.ID, dto.TaskFilter{}, dto.ListOptions{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint(employeeBTasks), bn)
 	})
+
+	t.Run("FilterAndSort", func(t *testing.T) {
+		h, err := store.CreateHospital(ctx, &dto.Hospital{Name: "task_filter_hospital"})
+		assert.NoError(t, err)
+		e, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: h.ID, Username: "f"})
+		assert.NoError(t, err)
+		for _, p := range []string{"LOW", "URGENT", "HIGHT", "URGENT", "LOW"} {
+			_, err := store.CreateTask(ctx, &dto.Task{
+				HospitalID: h.ID,
+				OwnerID:    e.ID,
+				Title:      "task " + p,
+				Priority:   p,
+				Status:     "OPEN",
+			})
+			assert.NoError(t, err)
+		}
+
+		filter := dto.TaskFilter{Priorities: []string{"URGENT", "HIGHT"}, OwnerID: e.ID}
+		n, err := store.CountTasksByHospital(ctx, h.ID, filter, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), n)
+		n, err = store.CountTasksByHospital(ctx, h.ID, dto.TaskFilter{Statuses: []string{"FAILED"}}, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), n)
+
+		// Walk the tasks two by two, by descending priority.
+		opts := dto.ListOptions{Limit: 2, Sort: []dto.SortField{{Name: "priority", Desc: true}}}
+		var priorities []string
+		for {
+			tasks, err := store.FindTasksByHospital(ctx, h.ID, dto.TaskFilter{}, opts)
+			assert.NoError(t, err)
+			if len(tasks) == 0 {
+				break
+			}
+			for _, task := range tasks {
+				priorities = append(priorities, task.Priority)
+			}
+			last := tasks[len(tasks)-1]
+			opts.AfterID, opts.AfterKey = last.ID, TaskCursorKey(last, opts.Sort)
+		}
+		assert.Equal(t, []string{"URGENT", "URGENT", "HIGHT", "LOW", "LOW"}, priorities)
+
+		opts.AfterKey = nil
+		_, err = store.FindTasksByHospital(ctx, h.ID, dto.TaskFilter{}, opts)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Transitions", func(t *testing.T) {
+		task, err := store.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    employeeA.ID,
+			Title:      "task transitions",
+			Priority:   "LOW",
+			Status:     "OPEN",
+		})
+		assert.NoError(t, err)
+
+		_, err = store.CreateTaskTransition(ctx, task.ID, "", "OPEN")
+		assert.NoError(t, err)
+		_, err = store.CreateTaskTransition(ctx, task.ID, "OPEN", "IN_PROGRESS")
+		assert.NoError(t, err)
+		_, err = store.CreateTaskTransition(ctx, task.ID+100, "", "OPEN")
+		assert.True(t, IsErrForeignKeyViolation(err))
+
+		transitions, err := store.FindTaskTransitions(ctx, task.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, transitions, 2) {
+			assert.Equal(t, "", transitions[0].FromStatus)
+			assert.Equal(t, "OPEN", transitions[1].FromStatus)
+			assert.Equal(t, "IN_PROGRESS", transitions[1].ToStatus)
+			assert.False(t, transitions[1].CreatedAt.IsZero())
+		}
+	})
+
+	t.Run("SweepOverdueTasks", func(t *testing.T) {
+		now := time.Now()
+		past, future := now.Add(-time.Hour), now.Add(time.Hour)
+		newTask := func(due *time.Time, status string) *models.Task {
+			task, err := store.CreateTask(ctx, &dto.Task{
+				HospitalID: hospital.ID,
+				OwnerID:    employeeB.ID,
+				Title:      "task due",
+				Priority:   "LOW",
+				Status:     status,
+				DueAt:      due,
+			})
+			assert.NoError(t, err)
+			return task
+		}
+		late := newTask(&past, "OPEN")
+		newTask(&past, "COMPLETED")
+		newTask(&future, "OPEN")
+		newTask(nil, "OPEN")
+
+		n, err := store.SweepOverdueTasks(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.SweepOverdueTasks(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		overdue := dto.TaskFilter{Overdue: true}
+		tasks, err := store.FindTasksByHospital(ctx, hospital.ID, overdue, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, tasks, 1) {
+			assert.Equal(t, late.ID, tasks[0].ID)
+			assert.True(t, tasks[0].Overdue)
+			assert.WithinDuration(t, past, *tasks[0].DueAt, time.Second)
+		}
+		count, err := store.CountTasksByHospital(ctx, hospital.ID, dto.TaskFilter{DueBefore: now}, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), count)
+
+		_, err = store.UpdateTask(ctx, &dto.Task{
+			ID:         late.ID,
+			HospitalID: late.HospitalID,
+			OwnerID:    *late.OwnerID,
+			Title:      late.Title,
+			Priority:   late.Priority,
+			Status:     "COMPLETED",
+			DueAt:      late.DueAt,
+		})
+		assert.NoError(t, err)
+		_, err = store.SweepOverdueTasks(ctx, now)
+		assert.NoError(t, err)
+		task, err := store.GetTask(ctx, late.ID)
+		assert.NoError(t, err)
+		assert.False(t, task.Overdue)
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		task, err := store.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    employeeA.ID,
+			Title:      "task changes",
+			Priority:   "LOW",
+			Status:     "OPEN",
+		})
+		assert.NoError(t, err)
+
+		first, err := store.CreateTaskChange(ctx, &dto.TaskChange{TaskID: task.ID, Field: "title", NewValue: "task changes"})
+		assert.NoError(t, err)
+		assert.Nil(t, first.ActorID)
+		second, err := store.CreateTaskChange(ctx, &dto.TaskChange{
+			TaskID:   task.ID,
+			Field:    "priority",
+			OldValue: "LOW",
+			NewValue: "URGENT",
+			ActorID:  employeeB.ID,
+		})
+		assert.NoError(t, err)
+		_, err = store.CreateTaskChange(ctx, &dto.TaskChange{TaskID: task.ID, Field: "title", ActorID: employeeB.ID + 100})
+		assert.True(t, IsErrForeignKeyViolation(err))
+
+		c, err := store.GetTaskChange(ctx, second.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "URGENT", c.NewValue)
+		if assert.NotNil(t, c.ActorID) {
+			assert.Equal(t, employeeB.ID, *c.ActorID)
+		}
+
+		changes, err := store.FindTaskChanges(ctx, task.ID, dto.ListOptions{Limit: 10, AfterID: first.ID})
+		assert.NoError(t, err)
+		if assert.Len(t, changes, 1) {
+			assert.Equal(t, second.ID, changes[0].ID)
+		}
+		n, err := store.CountTaskChanges(ctx, task.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), n)
+	})
+
+	t.Run("Pool", func(t *testing.T) {
+		task, err := store.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			Title:      "pooled",
+			Priority:   "LOW",
+			Status:     "OPEN",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, task.OwnerID)
+
+		pool := dto.TaskFilter{Unassigned: true}
+		tasks, err := store.FindTasksByHospital(ctx, hospital.ID, pool, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, tasks, 1) {
+			assert.Equal(t, task.ID, tasks[0].ID)
+		}
+
+		_, err = store.ClaimTask(ctx, task.ID, employeeB.ID+100)
+		assert.True(t, IsErrForeignKeyViolation(err))
+		n, err := store.ClaimTask(ctx, task.ID, employeeB.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.ClaimTask(ctx, task.ID, employeeA.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		got, err := store.GetTask(ctx, task.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, &employeeB.ID, got.OwnerID)
+		assert.Equal(t, task.Version+1, got.Version)
+		count, err := store.CountTasksByHospital(ctx, hospital.ID, pool, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), count)
+	})
+
+	t.Run("CountOpenTasksPerOwner", func(t *testing.T) {
+		counts, err := store.CountOpenTasksPerOwner(ctx, hospital.ID)
+		assert.NoError(t, err)
+		open := make(map[int64]uint)
+		for _, c := range counts {
+			assert.Greater(t, c.Count, uint(0))
+			open[c.OwnerID] += c.Count
+		}
+		for _, e := range []*models.Employee{employeeA, employeeB} {
+			n, err := store.CountOpenTasksByOwner(ctx, e.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, n, open[e.ID])
+		}
+	})
 }
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+const taskTemplateColumns = "id, hospital_id, title, description, priority, version, created_at, updated_at, deleted_at"
+
+func (s *SQLStore) GetTaskTemplate(ctx context.Context, id int64) (*models.TaskTemplate, error) {
+	var t models.TaskTemplate
+	sql := "select " + taskTemplateColumns + " from task_template where id = ? and deleted_at is null" + s.forUpdate()
+	err := s.getContext(ctx, &t, sql, id)
+	return &t, err
+}
+
+func (s *SQLStore) CreateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (*models.TaskTemplate, error) {
+	template := &models.TaskTemplate{
+		HospitalID:  t.HospitalID,
+		Title:       t.Title,
+		Description: t.Description,
+		Priority:    t.Priority,
+		Version:     1,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	sql := "insert into task_template (hospital_id, title, description, priority, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	id, err := s.insert(ctx, sql, template.HospitalID, template.Title, template.Description, template.Priority,
+		template.Version, template.CreatedAt, template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	template.ID = id
+	return template, nil
+}
+
+// UpdateTaskTemplate updates the template and bumps its version. If
+// t.Version is set, the template is only updated if it is still at that
+// version.
+func (s *SQLStore) UpdateTaskTemplate(ctx context.Context, t *dto.TaskTemplate) (int64, error) {
+	sql := "update task_template set title=?, description=?, priority=?, version=version+1, updated_at=? where id = ? and deleted_at is null"
+	args := []any{t.Title, t.Description, t.Priority, time.Now().UTC(), t.ID}
+	if t.Version > 0 {
+		sql += " and version = ?"
+		args = append(args, t.Version)
+	}
+	r, err := s.execContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) DeleteTaskTemplate(ctx context.Context, id int64) (int64, error) {
+	return s.softDelete(ctx, "task_template", "id = ?", id)
+}
+
+func (s *SQLStore) FindTaskTemplates(ctx context.Context, hid int64, opts dto.ListOptions) ([]*models.TaskTemplate, error) {
+	var templates []*models.TaskTemplate
+	cond, args := page(opts)
+	sql := "select " + taskTemplateColumns + " from task_template where hospital_id = ?" + notDeleted(opts) + cond
+	if err := s.selectContext(ctx, &templates, sql, append([]any{hid}, args...)...); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (s *SQLStore) CountTaskTemplates(ctx context.Context, hid int64, opts dto.ListOptions) (uint, error) {
+	var count uint
+	sql := "select count(1) from task_template where hospital_id = ?" + notDeleted(opts)
+	if err := s.getContext(ctx, &count, sql, hid); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// SetTaskTemplateLabels replaces the labels of the template, so it has to
+// run in a transaction.
+func (s *SQLStore) SetTaskTemplateLabels(ctx context.Context, id int64, labelIDs []int64) error {
+	if _, err := s.execContext(ctx, "delete from task_template_label where template_id = ?", id); err != nil {
+		return err
+	}
+	for _, lid := range uniqueIDs(labelIDs) {
+		sql := "insert into task_template_label (template_id, label_id) VALUES (?, ?)"
+		if _, err := s.execContext(ctx, sql, id, lid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) FindTaskTemplateLabelIDs(ctx context.Context, ids []int64) (map[int64][]int64, error) {
+	labels := make(map[int64][]int64)
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return labels, nil
+	}
+	var rows []*models.TaskTemplateLabel
+	marks, args := inArgs(ids)
+	sql := "select template_id, label_id from task_template_label where template_id in (" + marks + ") order by template_id, label_id"
+	if err := s.selectContext(ctx, &rows, sql, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		labels[row.TemplateID] = append(labels[row.TemplateID], row.LabelID)
+	}
+	return labels, nil
+}
+
+// SetTaskTemplateItems replaces the checklist of the template, so it has to
+// run in a transaction.
+func (s *SQLStore) SetTaskTemplateItems(ctx context.Context, id int64, items []string) error {
+	if _, err := s.execContext(ctx, "delete from task_template_item where template_id = ?", id); err != nil {
+		return err
+	}
+	for i, title := range items {
+		sql := "insert into task_template_item (template_id, position, title) VALUES (?, ?, ?)"
+		if _, err := s.execContext(ctx, sql, id, i, title); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) FindTaskTemplateItems(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	items := make(map[int64][]string)
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return items, nil
+	}
+	var rows []*models.TaskTemplateItem
+	marks, args := inArgs(ids)
+	sql := "select template_id, position, title from task_template_item where template_id in (" + marks + ") order by template_id, position"
+	if err := s.selectContext(ctx, &rows, sql, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		items[row.TemplateID] = append(items[row.TemplateID], row.Title)
+	}
+	return items, nil
+}
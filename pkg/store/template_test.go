@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestTaskTemplate(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "template_hospital"})
+	assert.NoError(t, err)
+	postOp, err := store.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "post-op", Color: "#ff0000"})
+	assert.NoError(t, err)
+	ward, err := store.CreateLabel(ctx, &dto.Label{HospitalID: hospital.ID, Name: "ward", Color: "#00ff00"})
+	assert.NoError(t, err)
+
+	var template *models.TaskTemplate
+
+	t.Run("CreateTaskTemplate", func(t *testing.T) {
+		template, err = store.CreateTaskTemplate(ctx, &dto.TaskTemplate{
+			HospitalID:  hospital.ID,
+			Title:       "post-op check",
+			Description: "check the patient after the surgery",
+			Priority:    models.TaskPriorityHight,
+		})
+		assert.NoError(t, err)
+		assert.Greater(t, template.ID, int64(0))
+
+		got, err := store.GetTaskTemplate(ctx, template.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "post-op check", got.Title)
+		assert.Equal(t, models.TaskPriorityHight, got.Priority)
+
+		_, err = store.CreateTaskTemplate(ctx, &dto.TaskTemplate{HospitalID: hospital.ID + 100, Title: "x", Priority: models.TaskPriorityLow})
+		assert.True(t, IsErrForeignKeyViolation(err))
+
+		count, err := store.CountTaskTemplates(ctx, hospital.ID, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+		templates, err := store.FindTaskTemplates(ctx, hospital.ID, dto.ListOptions{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, templates, 1)
+	})
+
+	t.Run("Labels", func(t *testing.T) {
+		assert.NoError(t, store.SetTaskTemplateLabels(ctx, template.ID, []int64{ward.ID, postOp.ID, ward.ID}))
+		labels, err := store.FindTaskTemplateLabelIDs(ctx, []int64{template.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{postOp.ID, ward.ID}, labels[template.ID])
+
+		// Deleting a label takes it off the templates.
+		_, err = store.DeleteLabel(ctx, ward.ID)
+		assert.NoError(t, err)
+		labels, err = store.FindTaskTemplateLabelIDs(ctx, []int64{template.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{postOp.ID}, labels[template.ID])
+
+		err = store.SetTaskTemplateLabels(ctx, template.ID, []int64{ward.ID})
+		assert.True(t, IsErrForeignKeyViolation(err))
+	})
+
+	t.Run("Items", func(t *testing.T) {
+		assert.NoError(t, store.SetTaskTemplateItems(ctx, template.ID, []string{"vitals", "wound", "pain"}))
+		items, err := store.FindTaskTemplateItems(ctx, []int64{template.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"vitals", "wound", "pain"}, items[template.ID])
+
+		assert.NoError(t, store.SetTaskTemplateItems(ctx, template.ID, []string{"pain", "vitals"}))
+		items, err = store.FindTaskTemplateItems(ctx, []int64{template.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"pain", "vitals"}, items[template.ID])
+	})
+
+	t.Run("UpdateTaskTemplate", func(t *testing.T) {
+		n, err := store.UpdateTaskTemplate(ctx, &dto.TaskTemplate{ID: template.ID, Title: "post-op round", Priority: models.TaskPriorityUrgent, Version: template.Version})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.UpdateTaskTemplate(ctx, &dto.TaskTemplate{ID: template.ID, Title: "stale", Priority: models.TaskPriorityLow, Version: template.Version})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		got, err := store.GetTaskTemplate(ctx, template.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "post-op round", got.Title)
+		assert.Equal(t, "", got.Description)
+		assert.Equal(t, template.Version+1, got.Version)
+	})
+
+	t.Run("DeleteTaskTemplate", func(t *testing.T) {
+		n, err := store.DeleteTaskTemplate(ctx, template.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		_, err = store.GetTaskTemplate(ctx, template.ID)
+		assert.True(t, IsErrNotFound(err))
+
+		count, err := store.CountTaskTemplates(ctx, hospital.ID, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), count)
+		count, err = store.CountTaskTemplates(ctx, hospital.ID, dto.ListOptions{IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+	})
+}
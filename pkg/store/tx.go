@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	maxTxAttempts = 3
+	txRetryDelay  = 50 * time.Millisecond
+)
+
+// WithTx runs fn in a transaction and commits it if fn returns nil. The
+// Store given to fn is bound to the transaction and the rows it reads are
+// locked until the end of it. When the transaction is aborted because of a
+// deadlock or a lock wait timeout, fn is run again in a new transaction.
+//
+// Calling WithTx on a store that is already bound to a transaction runs fn
+// in that transaction.
+func (s *SQLStore) WithTx(ctx context.Context, fn func(Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.runTx(ctx, fn)
+		if attempt == maxTxAttempts || !isErrRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(txRetryDelay * time.Duration(attempt)):
+		}
+	}
+}
+
+func (s *SQLStore) runTx(ctx context.Context, fn func(Store) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	txStore := &SQLStore{
+		db:      s.db,
+		dialect: s.dialect,
+		q:       tx,
+		tx:      tx,
+	}
+	if err := fn(txStore); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// forUpdate returns the clause that locks the selected rows when the store
+// is bound to a transaction. SQLite locks the whole database instead.
+func (s *SQLStore) forUpdate() string {
+	if s.tx == nil || s.dialect == DialectSQLite {
+		return ""
+	}
+	return " for update"
+}
+
+// isErrRetryable reports whether err aborted a transaction which is worth
+// retrying as is.
+func isErrRetryable(err error) bool {
+	var mErr *mysql.MySQLError
+	if errors.As(err, &mErr) {
+		// ER_LOCK_DEADLOCK, ER_LOCK_WAIT_TIMEOUT
+		return mErr.Number == 1213 || mErr.Number == 1205
+	}
+	var pErr *pq.Error
+	if errors.As(err, &pErr) {
+		// serialization_failure, deadlock_detected
+		return pErr.Code == "40001" || pErr.Code == "40P01"
+	}
+	var sErr sqlite3.Error
+	if errors.As(err, &sErr) {
+		return sErr.Code == sqlite3.ErrBusy || sErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
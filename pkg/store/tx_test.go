@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+)
+
+func TestWithTx(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	t.Run("Rollback", func(t *testing.T) {
+		errRollback := errors.New("rollback")
+		err := store.WithTx(ctx, func(tx Store) error {
+			_, err := tx.CreateHospital(ctx, &dto.Hospital{Name: "tx"})
+			assert.NoError(t, err)
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+
+		total, err := store.CountHosptials(ctx, dto.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), total)
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		var id int64
+		err := store.WithTx(ctx, func(tx Store) error {
+			h, err := tx.CreateHospital(ctx, &dto.Hospital{Name: "tx"})
+			if err != nil {
+				return err
+			}
+			id = h.ID
+			// Nested calls join the outer transaction.
+			return tx.WithTx(ctx, func(tx Store) error {
+				_, err := tx.GetHospital(ctx, h.ID)
+				return err
+			})
+		})
+		assert.NoError(t, err)
+
+		h, err := store.GetHospital(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "tx", h.Name)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		attempts := 0
+		err := store.WithTx(ctx, func(tx Store) error {
+			attempts++
+			if attempts == 1 {
+				return &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+// taskEventQuery selects the events along with their change, so that page
+// can order them by the id of the event.
+const taskEventQuery = "select id, employee_id, change_id, task_id, field, old_value, new_value, actor_id, created_at from" +
+	" (select task_event.id, task_event.employee_id, task_event.change_id, task_change.task_id, task_change.field," +
+	" task_change.old_value, task_change.new_value, task_change.actor_id, task_change.created_at" +
+	" from task_event join task_change on task_change.id = task_event.change_id) ev"
+
+func (s *SQLStore) CreateTaskWatcher(ctx context.Context, taskID, eid int64) error {
+	sql := "insert into task_watcher (task_id, employee_id, created_at) VALUES (?, ?, ?)"
+	_, err := s.execContext(ctx, sql, taskID, eid, time.Now().UTC())
+	return err
+}
+
+func (s *SQLStore) DeleteTaskWatcher(ctx context.Context, taskID, eid int64) (int64, error) {
+	r, err := s.execContext(ctx, "delete from task_watcher where task_id = ? and employee_id = ?", taskID, eid)
+	if err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLStore) FindTaskWatcherIDs(ctx context.Context, taskID int64) ([]int64, error) {
+	var ids []int64
+	if err := s.selectContext(ctx, &ids, "select employee_id from task_watcher where task_id = ? order by employee_id", taskID); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SQLStore) FindWatchedTasks(ctx context.Context, eid int64, filter dto.TaskFilter, opts dto.ListOptions) ([]*models.Task, error) {
+	q := taskQuery(filter, opts)
+	q.where("id in (select task_id from task_watcher where employee_id = ?)", eid)
+	return s.findTasks(ctx, q, opts)
+}
+
+func (s *SQLStore) CountWatchedTasks(ctx context.Context, eid int64, filter dto.TaskFilter, opts dto.ListOptions) (uint, error) {
+	q := taskQuery(filter, opts)
+	q.where("id in (select task_id from task_watcher where employee_id = ?)", eid)
+	return s.countTasks(ctx, q)
+}
+
+func (s *SQLStore) CreateTaskEvent(ctx context.Context, eid, changeID int64) error {
+	sql := "insert into task_event (employee_id, change_id, created_at) VALUES (?, ?, ?)"
+	_, err := s.execContext(ctx, sql, eid, changeID, time.Now().UTC())
+	return err
+}
+
+func (s *SQLStore) FindTaskEvents(ctx context.Context, eid int64, opts dto.ListOptions) ([]*models.TaskEvent, error) {
+	var events []*models.TaskEvent
+	cond, args := page(opts)
+	sql := taskEventQuery + " where employee_id = ?" + cond
+	if err := s.selectContext(ctx, &events, sql, append([]any{eid}, args...)...); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *SQLStore) CountTaskEvents(ctx context.Context, eid int64) (uint, error) {
+	var count uint
+	if err := s.getContext(ctx, &count, "select count(1) from task_event where employee_id = ?", eid); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuerfire/boxpractice/pkg/dto"
+	"github.com/liuerfire/boxpractice/pkg/models"
+)
+
+func TestTaskWatcher(t *testing.T) {
+	store, cleanup := helperConnect(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	hospital, err := store.CreateHospital(ctx, &dto.Hospital{Name: "watcher_hospital"})
+	assert.NoError(t, err)
+	owner, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "watched_owner"})
+	assert.NoError(t, err)
+	watcher, err := store.CreateEmployee(ctx, &dto.Employee{HospitalID: hospital.ID, Username: "watcher"})
+	assert.NoError(t, err)
+
+	var tasks []*models.Task
+	for _, p := range []string{models.TaskPriorityLow, models.TaskPriorityUrgent, models.TaskPriorityLow} {
+		task, err := store.CreateTask(ctx, &dto.Task{
+			HospitalID: hospital.ID,
+			OwnerID:    owner.ID,
+			Title:      "watched",
+			Priority:   p,
+			Status:     models.TaskStatusOpen,
+		})
+		assert.NoError(t, err)
+		tasks = append(tasks, task)
+	}
+
+	t.Run("Watchers", func(t *testing.T) {
+		assert.NoError(t, store.CreateTaskWatcher(ctx, tasks[0].ID, watcher.ID))
+		assert.NoError(t, store.CreateTaskWatcher(ctx, tasks[1].ID, watcher.ID))
+		assert.NoError(t, store.CreateTaskWatcher(ctx, tasks[1].ID, owner.ID))
+		err := store.CreateTaskWatcher(ctx, tasks[0].ID, watcher.ID)
+		assert.True(t, IsErrDuplicateEntry(err))
+
+		ids, err := store.FindTaskWatcherIDs(ctx, tasks[1].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{owner.ID, watcher.ID}, ids)
+
+		opts := dto.ListOptions{Limit: 10}
+		watched, err := store.FindWatchedTasks(ctx, watcher.ID, dto.TaskFilter{}, opts)
+		assert.NoError(t, err)
+		if assert.Len(t, watched, 2) {
+			assert.Equal(t, tasks[0].ID, watched[0].ID)
+			assert.Equal(t, tasks[1].ID, watched[1].ID)
+		}
+		urgent := dto.TaskFilter{Priorities: []string{models.TaskPriorityUrgent}}
+		count, err := store.CountWatchedTasks(ctx, watcher.ID, urgent, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+
+		n, err := store.DeleteTaskWatcher(ctx, tasks[0].ID, watcher.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		n, err = store.DeleteTaskWatcher(ctx, tasks[0].ID, watcher.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+		count, err = store.CountWatchedTasks(ctx, watcher.ID, dto.TaskFilter{}, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), count)
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		var changes []*models.TaskChange
+		for _, v := range []string{"a", "b", "c"} {
+			c, err := store.CreateTaskChange(ctx, &dto.TaskChange{TaskID: tasks[2].ID, Field: models.TaskFieldTitle, NewValue: v, ActorID: owner.ID})
+			assert.NoError(t, err)
+			changes = append(changes, c)
+			assert.NoError(t, store.CreateTaskEvent(ctx, watcher.ID, c.ID))
+		}
+		assert.NoError(t, store.CreateTaskEvent(ctx, owner.ID, changes[0].ID))
+
+		count, err := store.CountTaskEvents(ctx, watcher.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), count)
+		events, err := store.FindTaskEvents(ctx, watcher.ID, dto.ListOptions{Limit: 2})
+		assert.NoError(t, err)
+		if assert.Len(t, events, 2) {
+			assert.Equal(t, watcher.ID, events[0].EmployeeID)
+			assert.Equal(t, changes[0].ID, events[0].ChangeID)
+			assert.Equal(t, tasks[2].ID, events[0].TaskID)
+			assert.Equal(t, models.TaskFieldTitle, events[0].Field)
+			assert.Equal(t, "a", events[0].NewValue)
+			if assert.NotNil(t, events[0].ActorID) {
+				assert.Equal(t, owner.ID, *events[0].ActorID)
+			}
+			events, err = store.FindTaskEvents(ctx, watcher.ID, dto.ListOptions{Limit: 2, AfterID: events[1].ID})
+			assert.NoError(t, err)
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, "c", events[0].NewValue)
+			}
+		}
+	})
+}